@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/reef-pi/rpi/i2c"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+)
+
+type driversCmd struct {
+	input string
+	dev   bool
+	args  []string
+	out   io.Writer
+}
+
+const driversHelpText = `
+    Usage: reef-pi drivers [sub-command] [OPTIONS]
+
+    Inspect driver configuration schemas, validate driver configurations offline and
+    discover devices on the i2c bus.
+
+    valid sub-commands: schema | validate | scan
+
+    Example:
+     Print the configuration schema of all driver types:
+       reef-pi drivers schema
+
+     Print the configuration schema of the mqtt driver:
+       reef-pi drivers schema mqtt
+
+     Validate a driver, or a list of drivers, from a json input file:
+       reef-pi drivers validate -input drivers.json
+
+     Scan the i2c bus and suggest drivers for responding devices:
+       reef-pi drivers scan
+
+     Scan a simulated i2c bus:
+       reef-pi drivers scan -dev
+    `
+
+func (d *driversCmd) FlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("drivers", flag.ExitOnError)
+	fs.StringVar(&d.input, "input", "", "Input json file with a driver or a list of drivers")
+	fs.BoolVar(&d.dev, "dev", false, "Scan a simulated i2c bus")
+	fs.Usage = func() {
+		fmt.Println(strings.TrimSpace(driversHelpText))
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func NewDriversCmd(args []string) (*driversCmd, error) {
+	cmd := &driversCmd{out: os.Stdout}
+	// allow options after the sub-command, e.g. "validate -input drivers.json"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd.args = []string{args[0]}
+		args = args[1:]
+	}
+	fs := cmd.FlagSet()
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cmd.args = append(cmd.args, fs.Args()...)
+	return cmd, nil
+}
+
+func (d *driversCmd) Execute() error {
+	if len(d.args) == 0 {
+		return errors.New("sub-command must be specified")
+	}
+	switch d.args[0] {
+	case "schema":
+		return d.schema(d.args[1:])
+	case "validate":
+		return d.validate()
+	case "scan":
+		return d.scan()
+	}
+	return fmt.Errorf("unknown sub-command: %s", d.args[0])
+}
+
+func (d *driversCmd) schema(types []string) error {
+	var v interface{}
+	switch len(types) {
+	case 0:
+		schemas, err := drivers.DriverSchemas()
+		if err != nil {
+			return err
+		}
+		v = schemas
+	case 1:
+		s, err := drivers.DriverSchema(types[0])
+		if err != nil {
+			return err
+		}
+		v = s
+	default:
+		return errors.New("at most one driver type can be specified")
+	}
+	enc := json.NewEncoder(d.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (d *driversCmd) validate() error {
+	if d.input == "" {
+		return errors.New("input file must be specified")
+	}
+	data, err := os.ReadFile(d.input)
+	if err != nil {
+		return err
+	}
+	var ds []drivers.Driver
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(data, &ds)
+	} else {
+		var d1 drivers.Driver
+		err = json.Unmarshal(data, &d1)
+		ds = append(ds, d1)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid json in %s. %w", d.input, err)
+	}
+	invalid := 0
+	for i, d1 := range ds {
+		name := d1.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		failures, err := drivers.Validate(d1)
+		if err != nil {
+			fmt.Fprintf(d.out, "%s: %v\n", name, err)
+			invalid++
+			continue
+		}
+		if len(failures) == 0 {
+			fmt.Fprintf(d.out, "%s (%s): ok\n", name, d1.Type)
+			continue
+		}
+		invalid++
+		fmt.Fprintf(d.out, "%s (%s):\n", name, d1.Type)
+		params := make([]string, 0, len(failures))
+		for p := range failures {
+			params = append(params, p)
+		}
+		sort.Strings(params)
+		for _, p := range params {
+			for _, msg := range failures[p] {
+				fmt.Fprintf(d.out, "  %s: %s\n", p, msg)
+			}
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d drivers are invalid", invalid, len(ds))
+	}
+	return nil
+}
+
+func (d *driversCmd) scan() error {
+	bus := drivers.SimulatedI2CBus()
+	if !d.dev {
+		b, err := i2c.New()
+		if err != nil {
+			return fmt.Errorf("failed to open i2c bus. %w", err)
+		}
+		bus = b
+	}
+	defer bus.Close()
+	devices, err := drivers.ScanI2C(bus)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(d.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(devices)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/telemetry"
+)
+
+type exportCmd struct {
+	module, ids, from, to, format, series, output, sPath string
+}
+
+const exportHelpText = `
+    Usage: reef-pi export [OPTIONS]
+
+    Export stored readings, usage and journal entries of a module as csv or json lines,
+    for analysis in spreadsheets or notebooks. reef-pi controller must be stopped before
+    using this tool.
+
+    Example:
+     Export all temperature readings of January as csv:
+       reef-pi export -module temperature -from 2024-01-01 -to 2024-02-01 -format csv
+
+     Export readings of ph probes 1 and 2 as json lines in a file:
+       reef-pi export -module ph -id 1,2 -format jsonl -output ph.jsonl
+    `
+
+func (e *exportCmd) FlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	fs.StringVar(&e.module, "module", "", "Module to export ("+strings.Join(telemetry.ExportModuleNames(), ", ")+")")
+	fs.StringVar(&e.ids, "id", "", "Comma separated entity ids, all entities are exported when empty")
+	fs.StringVar(&e.from, "from", "", "Start of the time range (RFC3339 or YYYY-MM-DD)")
+	fs.StringVar(&e.to, "to", "", "End of the time range (RFC3339 or YYYY-MM-DD)")
+	fs.StringVar(&e.format, "format", telemetry.ExportCSV, "Output format (csv or jsonl)")
+	fs.StringVar(&e.series, "series", "", "Limit export to 'current' or 'historical' series")
+	fs.StringVar(&e.output, "output", "", "Output file, standard output is used when empty")
+	fs.StringVar(&e.sPath, "store", "/var/lib/reef-pi/reef-pi.db", "Database storage file")
+	fs.Usage = func() {
+		fmt.Println(strings.TrimSpace(exportHelpText))
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func NewExportCmd(args []string) (*exportCmd, error) {
+	cmd := &exportCmd{}
+	fs := cmd.FlagSet()
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func (e *exportCmd) Query() (telemetry.ExportQuery, error) {
+	q := telemetry.ExportQuery{
+		Module: e.module,
+		Series: e.series,
+	}
+	if e.module == "" {
+		return q, fmt.Errorf("module must be specified")
+	}
+	if e.ids != "" {
+		q.IDs = strings.Split(e.ids, ",")
+	}
+	from, err := telemetry.ParseExportTime(e.from)
+	if err != nil {
+		return q, fmt.Errorf("invalid start time. %w", err)
+	}
+	to, err := telemetry.ParseExportTime(e.to)
+	if err != nil {
+		return q, fmt.Errorf("invalid end time. %w", err)
+	}
+	q.From = from
+	q.To = to
+	return q, nil
+}
+
+func (e *exportCmd) Execute() error {
+	q, err := e.Query()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(e.sPath); os.IsNotExist(err) {
+		return fmt.Errorf("Database file does not exist. %w", err)
+	}
+	store, err := storage.NewStore(e.sPath)
+	if err != nil {
+		return fmt.Errorf("Failed to open database. Check if reef-pi is already running")
+	}
+	defer store.Close()
+
+	var out io.Writer = os.Stdout
+	if e.output != "" {
+		fi, err := os.Create(e.output)
+		if err != nil {
+			return err
+		}
+		defer fi.Close()
+		out = fi
+	}
+	w, err := telemetry.NewRecordWriter(e.format, out)
+	if err != nil {
+		return err
+	}
+	return telemetry.Export(store, q, w)
+}
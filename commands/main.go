@@ -18,13 +18,15 @@ func main() {
 		text := `
     Usage: reef-pi [command] [OPTIONS]
 
-    valid commands: daemon, reset-password, db, restore-db
+    valid commands: daemon, reset-password, db, restore-db, export, drivers
 
     reset-password: Reset reef-pi web ui username and password
     daemon: Run reef-pi controller
     db: Interact with reef-pi database
     restore-db: Restore and imported database
     install: Install another reef-pi version
+    export: Export historical readings as csv or json lines
+    drivers: Inspect driver schemas and validate driver configurations
 
     Options:
       -version
@@ -57,6 +59,26 @@ func main() {
 			os.Exit(1)
 		}
 		defer cmd.Close()
+	case "export":
+		cmd, err := NewExportCmd(args)
+		if err != nil {
+			fmt.Println("Failed to parse command line flags. Error:", err)
+			os.Exit(1)
+		}
+		if err := cmd.Execute(); err != nil {
+			fmt.Println("Failed due to error:", err)
+			os.Exit(1)
+		}
+	case "drivers":
+		cmd, err := NewDriversCmd(args)
+		if err != nil {
+			fmt.Println("Failed to parse command line flags. Error:", err)
+			os.Exit(1)
+		}
+		if err := cmd.Execute(); err != nil {
+			fmt.Println("Failed due to error:", err)
+			os.Exit(1)
+		}
 	case "reset-password":
 		cmd := flag.NewFlagSet("reset-password", flag.ExitOnError)
 		user := cmd.String("user", "", "New reef-pi web ui username")
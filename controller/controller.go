@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"time"
+
 	"github.com/gorilla/mux"
 
 	"github.com/reef-pi/reef-pi/controller/device_manager"
@@ -26,6 +28,32 @@ type Controller interface {
 	DM() *device_manager.DeviceManager
 }
 
+// SpeedController is implemented by subsystems with variable speed entities. Timers and macros
+// use it to run an entity at a percentage instead of just switching it on
+type SpeedController interface {
+	SetSpeed(string, float64) error
+}
+
+// Pauser is implemented by subsystems whose entities can be paused for a while, e.g. while
+// feeding. Paused entities get their previous state back afterwards, or when resumed early
+type Pauser interface {
+	PauseEntity(string, time.Duration) error
+	ResumeEntity(string) error
+}
+
+// Interlock is implemented by subsystems whose entities can be shut down until released, e.g.
+// by safety sensors. Automation and manual control can not restart an interlocked entity
+type Interlock interface {
+	Interlock(string) error
+	Release(string) error
+}
+
+// Doser is implemented by subsystems that dose on demand, outside of their schedule, e.g. food
+// dosed while feeding. Dose runs a pump for a number of seconds and returns once it stopped
+type Doser interface {
+	Dose(string, float64) error
+}
+
 type Entity interface {
 	EName() string
 	Status() (interface{}, error)
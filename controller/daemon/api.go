@@ -20,6 +20,7 @@ func (r *ReefPi) API() error {
 	r.UnAuthenticatedAPI(router)
 	if r.settings.Prometheus {
 		r.prometheus()
+		router.Use(instrumentAPI)
 	}
 	if os.Getenv("REEF_PI_LIST_API") == "1" {
 		utils.SummarizeAPI()
@@ -183,7 +184,49 @@ func (r *ReefPi) AuthenticatedAPI(router *mux.Router) {
 
 	if r.h != nil {
 		router.HandleFunc("/api/health_stats", r.h.GetStats).Methods("GET")
+
+		// swagger:route GET /api/health Health healthSummary
+		// Health summary.
+		// Outcome of the latest health checks (disk space, database size and growth, read only file system, clock sync, drivers).
+		// Responds with 503 when any check has failed, suitable for external monitors.
+		// responses:
+		// 	200: body:healthSummary
+		// 	503: body:healthSummary
+		router.HandleFunc("/api/health", r.h.Summary).Methods("GET")
 	}
+
+	// swagger:operation GET /api/export Export exportData
+	// Export historical data.
+	// Stream stored readings, usage or journal entries of a module as csv or json lines.
+	// ---
+	// parameters:
+	//  - in: query
+	//    name: module
+	//    description: The module to export (ato, doser, equipment, health, journal, lighting, ph, temperature)
+	//    required: true
+	//    type: string
+	//  - in: query
+	//    name: id
+	//    description: Comma separated entity ids, all entities are exported when absent
+	//    type: string
+	//  - in: query
+	//    name: from
+	//    description: Start of the time range (RFC3339 or YYYY-MM-DD)
+	//    type: string
+	//  - in: query
+	//    name: to
+	//    description: End of the time range (RFC3339 or YYYY-MM-DD)
+	//    type: string
+	//  - in: query
+	//    name: format
+	//    description: csv (default) or jsonl
+	//    type: string
+	// responses:
+	//  200:
+	//   description: OK
+	//  400:
+	//   description: Bad Request
+	router.HandleFunc("/api/export", r.export).Methods("GET")
 	r.dm.LoadAPI(router)
 	r.subsystems.LoadAPI(router)
 	if r.settings.Capabilities.Dashboard {
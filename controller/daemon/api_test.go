@@ -3,6 +3,9 @@ package daemon
 import (
 	"bytes"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/reef-pi/reef-pi/controller/settings"
@@ -42,6 +45,16 @@ func TestAPI(t *testing.T) {
 	if err := tr.Do("GET", "/api/health_stats", new(bytes.Buffer), nil); err != nil {
 		t.Error("Failed to get per minute health data.Error:", err)
 	}
+	rr := httptest.NewRecorder()
+	tr.Router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/export?module=health&format=jsonl", nil))
+	if rr.Code != http.StatusOK || rr.Result().Header.Get("Content-Type") != "application/x-ndjson" || !strings.Contains(rr.Body.String(), `"module":"health"`) {
+		t.Error("Expected export to include health readings held in memory", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	tr.Router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/export?module=foo", nil))
+	if rr.Code != http.StatusBadRequest || rr.Result().Header.Get("Content-Type") == "text/csv" {
+		t.Error("Expected invalid export to be rejected without a csv content type", rr.Code, rr.Result().Header)
+	}
 	body := new(bytes.Buffer)
 	json.NewEncoder(body).Encode(utils.Credentials{
 		User:     "reef-pi",
@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/reef-pi/reef-pi/controller/telemetry"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func exportQuery(req *http.Request) (telemetry.ExportQuery, error) {
+	params := req.URL.Query()
+	q := telemetry.ExportQuery{
+		Module: params.Get("module"),
+		Series: params.Get("series"),
+	}
+	for _, id := range params["id"] {
+		q.IDs = append(q.IDs, strings.Split(id, ",")...)
+	}
+	from, err := telemetry.ParseExportTime(params.Get("from"))
+	if err != nil {
+		return q, err
+	}
+	to, err := telemetry.ParseExportTime(params.Get("to"))
+	if err != nil {
+		return q, err
+	}
+	q.From = from
+	q.To = to
+	return q, nil
+}
+
+func (r *ReefPi) export(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+	q, err := exportQuery(req)
+	if err != nil {
+		utils.ErrorResponse(http.StatusBadRequest, err.Error(), w)
+		return
+	}
+	format := req.URL.Query().Get("format")
+	if err := telemetry.ValidateExport(q, format); err != nil {
+		utils.ErrorResponse(http.StatusBadRequest, err.Error(), w)
+		return
+	}
+	if err := telemetry.SaveExportStats(r.telemetry, q.Module); err != nil {
+		utils.ErrorResponse(http.StatusInternalServerError, "Failed to save readings held in memory. Error:"+err.Error(), w)
+		return
+	}
+	switch format {
+	case telemetry.ExportJSONL:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	default:
+		w.Header().Set("Content-Type", "text/csv")
+	}
+	rw, err := telemetry.NewRecordWriter(format, w)
+	if err != nil {
+		utils.ErrorResponse(http.StatusBadRequest, err.Error(), w)
+		return
+	}
+	if err := telemetry.Export(r.store, q, rw); err != nil {
+		utils.ErrorResponse(http.StatusBadRequest, err.Error(), w)
+	}
+}
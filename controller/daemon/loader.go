@@ -10,13 +10,16 @@ import (
 	"github.com/reef-pi/reef-pi/controller/modules/camera"
 	"github.com/reef-pi/reef-pi/controller/modules/doser"
 	"github.com/reef-pi/reef-pi/controller/modules/equipment"
+	"github.com/reef-pi/reef-pi/controller/modules/feeding"
 	"github.com/reef-pi/reef-pi/controller/modules/journal"
 	"github.com/reef-pi/reef-pi/controller/modules/lighting"
 	"github.com/reef-pi/reef-pi/controller/modules/macro"
 	"github.com/reef-pi/reef-pi/controller/modules/ph"
+	"github.com/reef-pi/reef-pi/controller/modules/safety"
 	"github.com/reef-pi/reef-pi/controller/modules/system"
 	"github.com/reef-pi/reef-pi/controller/modules/temperature"
 	"github.com/reef-pi/reef-pi/controller/modules/timer"
+	"github.com/reef-pi/reef-pi/controller/modules/wavemaker"
 )
 
 func (r *ReefPi) loadPhSubsystem() error {
@@ -142,6 +145,30 @@ func (r *ReefPi) loadDoserSubsystem() error {
 	return nil
 }
 
+func (r *ReefPi) loadWavemakerSubsystem() error {
+	if !r.settings.Capabilities.Wavemaker {
+		return nil
+	}
+	r.subsystems.Load(wavemaker.Bucket, wavemaker.New(r))
+	return nil
+}
+
+func (r *ReefPi) loadFeedModeSubsystem() error {
+	if !r.settings.Capabilities.FeedMode {
+		return nil
+	}
+	r.subsystems.Load(feeding.Bucket, feeding.New(r))
+	return nil
+}
+
+func (r *ReefPi) loadSafetySubsystem() error {
+	if !r.settings.Capabilities.Safety {
+		return nil
+	}
+	r.subsystems.Load(safety.Bucket, safety.New(r))
+	return nil
+}
+
 func (r *ReefPi) loadSubsystems() error {
 	if r.settings.Capabilities.Configuration {
 		conf := system.Config{
@@ -176,6 +203,18 @@ func (r *ReefPi) loadSubsystems() error {
 		log.Println("ERROR: Failed to load doser subsystem. Error:", err)
 		r.LogError("subsystem-doser", "Failed to load doser subsystem. Error:"+err.Error())
 	}
+	if err := r.loadWavemakerSubsystem(); err != nil {
+		log.Println("ERROR: Failed to load wavemaker subsystem. Error:", err)
+		r.LogError("subsystem-wavemaker", "Failed to load wavemaker subsystem. Error:"+err.Error())
+	}
+	if err := r.loadFeedModeSubsystem(); err != nil {
+		log.Println("ERROR: Failed to load feed mode subsystem. Error:", err)
+		r.LogError("subsystem-feed-mode", "Failed to load feed mode subsystem. Error:"+err.Error())
+	}
+	if err := r.loadSafetySubsystem(); err != nil {
+		log.Println("ERROR: Failed to load safety subsystem. Error:", err)
+		r.LogError("subsystem-safety", "Failed to load safety subsystem. Error:"+err.Error())
+	}
 	if err := r.loadCameraSubsystem(); err != nil {
 		log.Println("ERROR: Failed to load camera subsystem. Error:", err)
 		r.LogError("subsystem-camera", "Failed to load camera subsystem. Error:"+err.Error())
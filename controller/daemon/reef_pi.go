@@ -60,6 +60,7 @@ func New(version, database string) (*ReefPi, error) {
     }
     if s.Capabilities.HealthCheck {
         r.h = telemetry.NewHealthChecker(Bucket, 1*time.Minute, s.HealthCheck, tele, store)
+        r.h.SetDriverProbe(r.dm.Drivers().Liveness)
     }
     return r, nil
 }
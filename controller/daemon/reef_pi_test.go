@@ -39,6 +39,9 @@ func TestReefPi(t *testing.T) {
 	r.settings.Capabilities.Equipment = true
 	r.settings.Capabilities.Timers = true
 	r.settings.Capabilities.Ph = true
+	r.settings.Capabilities.Wavemaker = true
+	r.settings.Capabilities.FeedMode = true
+	r.settings.Capabilities.Safety = true
 	if err := r.Start(); err != nil {
 		t.Fatal("Failed to load subsystem. Error:", err)
 	}
@@ -28,6 +28,9 @@ func initializeSettings(store storage.Store) (settings.Settings, error) {
 		settings.DefaultSettings.Capabilities.Macro = true
 		settings.DefaultSettings.Capabilities.Doser = true
 		settings.DefaultSettings.Capabilities.Ph = true
+		settings.DefaultSettings.Capabilities.Wavemaker = true
+		settings.DefaultSettings.Capabilities.FeedMode = true
+		settings.DefaultSettings.Capabilities.Safety = true
 
 		settings.DefaultSettings.Address = "0.0.0.0:8080"
 		log.Println("DEV_MODE environment variable set. Turning on dev_mode. Address set to localhost:8080")
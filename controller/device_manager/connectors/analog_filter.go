@@ -0,0 +1,130 @@
+package connectors
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Filter methods used to combine oversampled readings
+const (
+	MeanFilter        = "mean"
+	MedianFilter      = "median"
+	TrimmedMeanFilter = "trimmed_mean"
+)
+
+const (
+	maxFilterSamples = 100
+	maxFilterTime    = 10 * time.Second
+)
+
+// Filter conditions the signal of an analog input. A zero value filter
+// takes a single sample, same as an unfiltered analog input.
+//
+// swagger:model analogFilter
+type Filter struct {
+	// Samples is the number of readings taken per measurement
+	Samples int `json:"samples"`
+	// Delay is the time in milliseconds between consecutive readings
+	Delay int `json:"delay"`
+	// Method combines the readings, one of mean (default), median or trimmed_mean
+	Method string `json:"method"`
+	// Trim is the fraction of readings dropped from each end for trimmed_mean
+	Trim float64 `json:"trim"`
+	// MaxDeviation rejects readings further than this from the median of the samples. 0 disables rejection
+	MaxDeviation float64 `json:"max_deviation"`
+	// Alpha is the exponential moving average smoothing factor applied across measurements. 0 disables smoothing
+	Alpha float64 `json:"alpha"`
+}
+
+func (f Filter) IsValid() error {
+	switch f.Method {
+	case "", MeanFilter, MedianFilter, TrimmedMeanFilter:
+	default:
+		return fmt.Errorf("filter method should be one of %s, %s or %s", MeanFilter, MedianFilter, TrimmedMeanFilter)
+	}
+	if f.Samples < 0 || f.Samples > maxFilterSamples {
+		return fmt.Errorf("filter samples should be between 0 and %d", maxFilterSamples)
+	}
+	if f.Delay < 0 {
+		return errors.New("filter delay can not be negative")
+	}
+	if time.Duration(f.Samples*f.Delay)*time.Millisecond > maxFilterTime {
+		return fmt.Errorf("filter samples and delay should not exceed %s per measurement", maxFilterTime)
+	}
+	if f.Trim < 0 || f.Trim >= 0.5 {
+		return errors.New("filter trim should be at least 0 and less than 0.5")
+	}
+	if f.MaxDeviation < 0 {
+		return errors.New("filter max deviation can not be negative")
+	}
+	if f.Alpha < 0 || f.Alpha > 1 {
+		return errors.New("filter alpha should be between 0 and 1")
+	}
+	return nil
+}
+
+func (f Filter) samples() int {
+	if f.Samples < 1 {
+		return 1
+	}
+	return f.Samples
+}
+
+// combine reduces oversampled readings into a single value, after rejecting outliers
+func (f Filter) combine(vs []float64) (float64, error) {
+	if len(vs) == 0 {
+		return 0, errors.New("no samples")
+	}
+	sorted := append([]float64{}, vs...)
+	sort.Float64s(sorted)
+	m := median(sorted)
+	if f.MaxDeviation > 0 {
+		var kept []float64
+		for _, v := range sorted {
+			if math.Abs(v-m) <= f.MaxDeviation {
+				kept = append(kept, v)
+			}
+		}
+		if len(kept) == 0 {
+			// samples spread wider than max deviation, fall back to the middle samples the median is based on
+			n := len(sorted)
+			kept = sorted[(n-1)/2 : n/2+1]
+		}
+		sorted = kept
+	}
+	switch f.Method {
+	case MedianFilter:
+		return median(sorted), nil
+	case TrimmedMeanFilter:
+		n := int(float64(len(sorted)) * f.Trim)
+		return mean(sorted[n : len(sorted)-n]), nil
+	}
+	return mean(sorted), nil
+}
+
+// smooth applies the exponential moving average to a combined value, given the previous filtered value
+func (f Filter) smooth(prev, v float64) float64 {
+	if f.Alpha == 0 {
+		return v
+	}
+	return f.Alpha*v + (1-f.Alpha)*prev
+}
+
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func mean(vs []float64) float64 {
+	var sum float64
+	for _, v := range vs {
+		sum += v
+	}
+	return sum / float64(len(vs))
+}
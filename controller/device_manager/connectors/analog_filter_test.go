@@ -0,0 +1,114 @@
+package connectors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+func TestFilterCombine(t *testing.T) {
+	samples := []float64{7.1, 7.0, 7.2, 12.5, 7.1, 0.3}
+	spread := []float64{7.0, 7.4}
+	for _, tc := range []struct {
+		f        Filter
+		samples  []float64
+		expected float64
+	}{
+		{Filter{}, samples, 6.8667},
+		{Filter{Method: MedianFilter}, samples, 7.1},
+		{Filter{Method: TrimmedMeanFilter, Trim: 0.2}, samples, 7.1},
+		{Filter{MaxDeviation: 1}, samples, 7.1},
+		{Filter{Method: MedianFilter, MaxDeviation: 0.05}, samples, 7.1},
+		{Filter{MaxDeviation: 0.1}, spread, 7.2},
+		{Filter{Method: MedianFilter, MaxDeviation: 0.1}, spread, 7.2},
+		{Filter{Method: TrimmedMeanFilter, Trim: 0.2, MaxDeviation: 0.1}, spread, 7.2},
+		{Filter{Method: MedianFilter, MaxDeviation: 0.1}, []float64{7.0, 7.2, 7.4}, 7.2},
+	} {
+		v, err := tc.f.combine(tc.samples)
+		if err != nil {
+			t.Error(err)
+		}
+		if math.IsNaN(v) || math.Abs(v-tc.expected) > 0.0001 {
+			t.Error("filter", tc.f, "samples", tc.samples, "expected", tc.expected, "got", v)
+		}
+	}
+	if _, err := (Filter{}).combine(nil); err == nil {
+		t.Error("expected error without samples")
+	}
+	if v := (Filter{Alpha: 0.25}).smooth(8, 4); v != 7 {
+		t.Error("expected ema of 7, got", v)
+	}
+}
+
+func TestFilterValidation(t *testing.T) {
+	for _, f := range []Filter{
+		{Method: "mode"},
+		{Samples: -1},
+		{Samples: maxFilterSamples + 1},
+		{Samples: 100, Delay: 1000},
+		{Trim: 0.5},
+		{MaxDeviation: -1},
+		{Alpha: 1.5},
+	} {
+		if err := f.IsValid(); err == nil {
+			t.Error("expected filter to be invalid", f)
+		}
+	}
+	if err := (Filter{Samples: 16, Delay: 5, Method: TrimmedMeanFilter, Trim: 0.1, Alpha: 0.3}).IsValid(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAnalogInputFiltering(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	drvrs := drivers.TestDrivers(store)
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := drvrs.DigitalOutputDriver("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := d.(*virtual.Driver)
+	ais := NewAnalogInputs(drvrs, store)
+	if err := ais.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	a := AnalogInput{Name: "ph", Pin: 0, Driver: "1", Filter: Filter{Samples: 4, Method: MedianFilter, Alpha: 0.5}}
+	if err := ais.Create(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Set(virtual.Input{Type: "analog-input", Pin: 0, Value: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := ais.Sample("1"); err != nil || r.Value != 8 || r.Raw != 8 {
+		t.Error("expected first reading to be 8", r, err)
+	}
+	if err := sim.Set(virtual.Input{Type: "analog-input", Pin: 0, Value: 9}); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := ais.Sample("1"); err != nil || r.Value != 8.5 || r.Raw != 9 {
+		t.Error("expected smoothed value 8.5 and raw value 9", r, err)
+	}
+	if v, err := ais.ReadRaw("1"); err != nil || v != 9 {
+		t.Error("expected raw value 9", v, err)
+	}
+	a.Filter = Filter{}
+	if err := ais.Update("1", a); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := ais.Read("1"); err != nil || v != 9 {
+		t.Error("expected unfiltered value 9 after update", v, err)
+	}
+	a.Filter.Alpha = 2
+	if err := ais.Update("1", a); err == nil {
+		t.Error("expected invalid filter to be rejected")
+	}
+}
@@ -3,6 +3,8 @@ package connectors
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"net/http"
 
@@ -23,11 +25,15 @@ type AnalogInput struct {
 	Name   string `json:"name"`
 	Pin    int    `json:"pin"`
 	Driver string `json:"driver"` // can be either hal or pca9685
+	Filter Filter `json:"filter"`
 }
 
 type AnalogInputs struct {
 	store   storage.Store
 	drivers *drivers.Drivers
+	pins    *PinRegistry
+	mu      sync.Mutex
+	ema     map[string]float64
 }
 
 func (j AnalogInput) channel(drvrs *drivers.Drivers) (hal.AnalogInputPin, error) {
@@ -46,13 +52,15 @@ func (j AnalogInput) IsValid(drvrs *drivers.Drivers) error {
 	if err != nil {
 		return fmt.Errorf("invalid pin %d: %v", j.Pin, err)
 	}
-	return nil
+	return j.Filter.IsValid()
 }
 
 func NewAnalogInputs(drivers *drivers.Drivers, store storage.Store) *AnalogInputs {
 	return &AnalogInputs{
 		store:   store,
 		drivers: drivers,
+		pins:    NewPinRegistry(drivers, store),
+		ema:     make(map[string]float64),
 	}
 }
 
@@ -86,6 +94,9 @@ func (c *AnalogInputs) Create(j AnalogInput) error {
 	if err := j.IsValid(c.drivers); err != nil {
 		return err
 	}
+	if err := c.pins.Check(PinOwner{Type: AnalogInputPin}, j.Driver, hal.AnalogInput, j.Pin); err != nil {
+		return err
+	}
 
 	fn := func(id string) interface{} {
 		j.ID = id
@@ -101,10 +112,14 @@ func (c *AnalogInputs) Update(id string, j AnalogInput) error {
 	if err := j.IsValid(c.drivers); err != nil {
 		return err
 	}
+	if err := c.pins.Check(PinOwner{Type: AnalogInputPin, ID: id}, j.Driver, hal.AnalogInput, j.Pin); err != nil {
+		return err
+	}
 	j.ID = id
 	if err := c.store.Update(AnalogInputBucket, id, j); err != nil {
 		return err
 	}
+	c.resetFilter(id)
 	return nil
 }
 
@@ -113,7 +128,11 @@ func (c *AnalogInputs) Delete(id string) error {
 	if err != nil {
 		return err
 	}
-	return c.store.Delete(AnalogInputBucket, id)
+	if err := c.store.Delete(AnalogInputBucket, id); err != nil {
+		return err
+	}
+	c.resetFilter(id)
+	return nil
 }
 
 func (c *AnalogInputs) LoadAPI(r *mux.Router) {
@@ -216,12 +235,24 @@ func (c *AnalogInputs) LoadAPI(r *mux.Router) {
 	// responses:
 	//  200:
 	//   description: OK
+	//   schema:
+	//    $ref: '#/definitions/analogReading'
 	//  404:
 	//   description: Not Found
 	r.HandleFunc("/api/analog_inputs/{id}/read", c.read).Methods("POST")
 }
 
+// Read returns the filtered value of an analog input
 func (ais *AnalogInputs) Read(id string) (float64, error) {
+	r, err := ais.Sample(id)
+	if err != nil {
+		return -1, err
+	}
+	return r.Value, nil
+}
+
+// ReadRaw returns a single unfiltered reading of an analog input
+func (ais *AnalogInputs) ReadRaw(id string) (float64, error) {
 	j, err := ais.Get(id)
 	if err != nil {
 		return -1, err
@@ -230,7 +261,66 @@ func (ais *AnalogInputs) Read(id string) (float64, error) {
 	if err != nil {
 		return -1, fmt.Errorf("pin %d on analog input %s has no driver: %v", j.Pin, id, err)
 	}
-	return ch.Value()
+	v, err := ch.Value()
+	if err != nil {
+		ais.drivers.ReportError(j.Driver)
+	}
+	return v, err
+}
+
+// Sample takes the configured number of readings from an analog input and returns
+// both the filtered value and the last raw reading. Failed readings are skipped
+// as long as at least one reading succeeds.
+func (ais *AnalogInputs) Sample(id string) (AnalogReading, error) {
+	var r AnalogReading
+	j, err := ais.Get(id)
+	if err != nil {
+		return r, err
+	}
+	ch, err := j.channel(ais.drivers)
+	if err != nil {
+		return r, fmt.Errorf("pin %d on analog input %s has no driver: %v", j.Pin, id, err)
+	}
+	var vs []float64
+	var lastErr error
+	for i := 0; i < j.Filter.samples(); i++ {
+		if i > 0 && j.Filter.Delay > 0 {
+			time.Sleep(time.Duration(j.Filter.Delay) * time.Millisecond)
+		}
+		v, err := ch.Value()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		vs = append(vs, v)
+		r.Raw = v
+	}
+	if lastErr != nil {
+		ais.drivers.ReportError(j.Driver)
+	}
+	if len(vs) == 0 {
+		return r, lastErr
+	}
+	v, err := j.Filter.combine(vs)
+	if err != nil {
+		return r, err
+	}
+	ais.mu.Lock()
+	defer ais.mu.Unlock()
+	if prev, ok := ais.ema[id]; ok {
+		v = j.Filter.smooth(prev, v)
+	}
+	if j.Filter.Alpha > 0 {
+		ais.ema[id] = v
+	}
+	r.Value = v
+	return r, nil
+}
+
+func (ais *AnalogInputs) resetFilter(id string) {
+	ais.mu.Lock()
+	defer ais.mu.Unlock()
+	delete(ais.ema, id)
 }
 func (ais *AnalogInputs) Calibrate(id string, ms []hal.Measurement) error {
 	j, err := ais.Get(id)
@@ -244,15 +334,19 @@ func (ais *AnalogInputs) Calibrate(id string, ms []hal.Measurement) error {
 	return ch.Calibrate(ms)
 }
 
+// AnalogReading contains the filtered value and the last raw reading of an analog input
+//
+// swagger:model analogReading
 type AnalogReading struct {
 	Value float64 `json:"value"`
+	Raw   float64 `json:"raw"`
 }
 
 func (c *AnalogInputs) read(w http.ResponseWriter, r *http.Request) {
 	var a AnalogReading
 	fn := func(id string) error {
-		v, err := c.Read(id)
-		a.Value = v
+		v, err := c.Sample(id)
+		a = v
 		return err
 	}
 	utils.JSONUpdateResponse(&a, fn, w, r)
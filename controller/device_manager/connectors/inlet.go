@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -25,11 +27,28 @@ type Inlet struct {
 	Equipment string `json:"equipment"`
 	Reverse   bool   `json:"reverse"`
 	Driver    string `json:"driver"`
+	// Mode is either level (default) or pulse
+	Mode string `json:"mode"`
+	// Debounce is the time in milliseconds a level has to be stable before it is reported. Inlets
+	// with a debounce are sampled in background and report edges
+	Debounce int `json:"debounce"`
+	// Interval is the background sampling interval in milliseconds
+	Interval int `json:"interval"`
+	// PulsesPerUnit converts pulse rate into flow, e.g. 450 pulses per liter
+	PulsesPerUnit float64 `json:"pulses_per_unit"`
+	// MaxRate is the highest pulse rate per second expected in pulse mode. Pulses are counted by
+	// polling, which can not count faster than one pulse per two sampling intervals (25 per second
+	// at the default 20ms interval), faster pulses are undercounted
+	MaxRate float64 `json:"max_rate"`
 }
 
 type Inlets struct {
-	store   storage.Store
-	drivers *drivers.Drivers
+	store     storage.Store
+	drivers   *drivers.Drivers
+	pins      *PinRegistry
+	mu        sync.Mutex
+	samplers  map[string]*sampler
+	listeners []func(Edge)
 }
 
 func (e *Inlets) LoadAPI(r *mux.Router) {
@@ -135,6 +154,48 @@ func (e *Inlets) LoadAPI(r *mux.Router) {
 	//  404:
 	//   description: Not Found
 	r.HandleFunc("/api/inlets/{id}/read", e.read).Methods("POST")
+
+	// swagger:operation GET /api/inlets/{id}/events Inlet inletEvents
+	// List Inlet edges.
+	// List recent debounced rising and falling edges of an Inlet.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the inlet
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    type: array
+	//    items:
+	//     $ref: '#/definitions/inletEdge'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/inlets/{id}/events", e.events).Methods("GET")
+
+	// swagger:operation GET /api/inlets/{id}/pulses Inlet inletPulses
+	// Get Inlet pulses.
+	// Get pulse count, rate and flow of an Inlet in pulse mode.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the inlet
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    $ref: '#/definitions/inletPulses'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/inlets/{id}/pulses", e.pulses).Methods("GET")
 }
 
 func (i Inlet) inputPin(drivers *drivers.Drivers) (hal.DigitalInputPin, error) {
@@ -156,18 +217,42 @@ func (i Inlet) IsValid(drivers *drivers.Drivers) error {
 	if _, err := i.inputPin(drivers); err != nil {
 		return fmt.Errorf("inlet %s did not get associated with a driver pin: %v", i.Name, err)
 	}
+	switch i.Mode {
+	case "", LevelMode, PulseMode:
+	default:
+		return fmt.Errorf("inlet mode should be %s or %s", LevelMode, PulseMode)
+	}
+	if i.Debounce < 0 || i.Interval < 0 || i.PulsesPerUnit < 0 || i.MaxRate < 0 {
+		return errors.New("debounce, interval, pulses per unit and max rate can not be negative")
+	}
+	if i.Mode == PulseMode && i.MaxRate > i.maxPulseRate() {
+		return fmt.Errorf("expected pulse rate %.1f/s exceeds %.1f/s that can be counted at %s sampling interval and %dms debounce",
+			i.MaxRate, i.maxPulseRate(), i.sampleInterval(), i.Debounce)
+	}
 	return nil
 }
 
 func NewInlets(drivers *drivers.Drivers, store storage.Store) *Inlets {
 	return &Inlets{
-		store:   store,
-		drivers: drivers,
+		store:    store,
+		drivers:  drivers,
+		pins:     NewPinRegistry(drivers, store),
+		samplers: make(map[string]*sampler),
 	}
 }
 
 func (c *Inlets) Setup() error {
-	return c.store.CreateBucket(InletBucket)
+	if err := c.store.CreateBucket(InletBucket); err != nil {
+		return err
+	}
+	inlets, err := c.List()
+	if err != nil {
+		return err
+	}
+	for _, i := range inlets {
+		c.startSampler(i)
+	}
+	return nil
 }
 
 func (c *Inlets) Read(id string) (int, error) {
@@ -176,11 +261,23 @@ func (c *Inlets) Read(id string) (int, error) {
 		return -1, fmt.Errorf("Inlet name: '%s' does not exist", err)
 	}
 
+	if s, ok := c.sampler(id); ok {
+		if v, ready, err := s.level(time.Now()); ready || err != nil {
+			if v {
+				return 1, err
+			}
+			return 0, err
+		}
+	}
+
 	inputPin, err := i.inputPin(c.drivers)
 	if err != nil {
 		return 0, fmt.Errorf("can't perform read: %v", err)
 	}
 	v, err := inputPin.Read()
+	if err != nil {
+		c.drivers.ReportError(i.Driver)
+	}
 
 	if i.Reverse {
 		v = !v
@@ -195,11 +292,18 @@ func (c *Inlets) Create(i Inlet) error {
 	if err := i.IsValid(c.drivers); err != nil {
 		return err
 	}
+	if err := c.pins.Check(PinOwner{Type: InletPin}, i.Driver, hal.DigitalInput, i.Pin); err != nil {
+		return err
+	}
 	fn := func(id string) interface{} {
 		i.ID = id
 		return &i
 	}
-	return c.store.Create(InletBucket, fn)
+	if err := c.store.Create(InletBucket, fn); err != nil {
+		return err
+	}
+	c.startSampler(i)
+	return nil
 }
 
 func (c *Inlets) Update(id string, i Inlet) error {
@@ -207,7 +311,14 @@ func (c *Inlets) Update(id string, i Inlet) error {
 	if err := i.IsValid(c.drivers); err != nil {
 		return err
 	}
-	return c.store.Update(InletBucket, id, i)
+	if err := c.pins.Check(PinOwner{Type: InletPin, ID: id}, i.Driver, hal.DigitalInput, i.Pin); err != nil {
+		return err
+	}
+	if err := c.store.Update(InletBucket, id, i); err != nil {
+		return err
+	}
+	c.startSampler(i)
+	return nil
 }
 
 func (c *Inlets) List() ([]Inlet, error) {
@@ -231,7 +342,11 @@ func (c *Inlets) Delete(id string) error {
 	if i.Equipment != "" {
 		return fmt.Errorf("Inlet: %s has equipment: %s attached to it.", i.Name, i.Equipment)
 	}
-	return c.store.Delete(InletBucket, id)
+	if err := c.store.Delete(InletBucket, id); err != nil {
+		return err
+	}
+	c.stopSampler(id)
+	return nil
 }
 
 func (c *Inlets) Get(id string) (Inlet, error) {
@@ -267,6 +382,20 @@ func (c *Inlets) read(w http.ResponseWriter, r *http.Request) {
 	utils.JSONGetResponse(fn, w, r)
 }
 
+func (c *Inlets) events(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return c.Edges(id)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (c *Inlets) pulses(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return c.Pulses(id)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
 func (c *Inlets) create(w http.ResponseWriter, r *http.Request) {
 	var i Inlet
 	fn := func() error {
@@ -0,0 +1,284 @@
+package connectors
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Inlet modes
+const (
+	LevelMode = "level"
+	PulseMode = "pulse"
+)
+
+const (
+	defaultSampleInterval = 20 * time.Millisecond
+	edgesLimit            = 100
+)
+
+// window used to calculate pulse rate
+var rateWindow = 10 * time.Second
+
+// Edge is a debounced transition of an inlet
+//
+// swagger:model inletEdge
+type Edge struct {
+	Inlet  string    `json:"inlet"`
+	Rising bool      `json:"rising"`
+	Time   time.Time `json:"time"`
+}
+
+// PulseStats summarizes pulses counted by an inlet in pulse mode
+//
+// swagger:model inletPulses
+type PulseStats struct {
+	Count uint64 `json:"count"`
+	// pulses per second over the last few seconds
+	Rate float64 `json:"rate"`
+	// units per minute, when pulses_per_unit is set
+	Flow float64 `json:"flow,omitempty"`
+}
+
+// sampler polls an inlet in background, debouncing its level and detecting edges or counting pulses
+type sampler struct {
+	sync.Mutex
+	inlet    Inlet
+	debounce time.Duration
+	quit     chan struct{}
+
+	initialized bool
+	stable      bool
+	pending     bool
+	since       time.Time
+	err         error
+
+	edges  []Edge
+	count  uint64
+	pulses []time.Time
+}
+
+func newSampler(i Inlet) *sampler {
+	return &sampler{
+		inlet:    i,
+		debounce: time.Duration(i.Debounce) * time.Millisecond,
+		quit:     make(chan struct{}),
+	}
+}
+
+func (i Inlet) sampled() bool {
+	return i.Debounce > 0 || i.Mode == PulseMode
+}
+
+func (i Inlet) sampleInterval() time.Duration {
+	if i.Interval > 0 {
+		return time.Duration(i.Interval) * time.Millisecond
+	}
+	return defaultSampleInterval
+}
+
+// maxPulseRate returns the highest pulse rate per second that polling can count. Both the high and
+// the low level of a pulse have to be sampled, and held for the debounce time
+func (i Inlet) maxPulseRate() float64 {
+	level := i.sampleInterval() + time.Duration(i.Debounce)*time.Millisecond
+	return 1 / (2 * level.Seconds())
+}
+
+// sample feeds a raw reading (reverse already applied) and returns the resulting debounced edge, if any
+func (s *sampler) sample(now time.Time, v bool) *Edge {
+	s.Lock()
+	defer s.Unlock()
+	s.err = nil
+	if !s.initialized {
+		s.initialized = true
+		s.stable = v
+		s.pending = v
+		s.since = now
+		return nil
+	}
+	if v != s.pending {
+		s.pending = v
+		s.since = now
+	}
+	if s.pending == s.stable || now.Sub(s.since) < s.debounce {
+		return nil
+	}
+	s.stable = s.pending
+	e := Edge{Inlet: s.inlet.ID, Rising: s.stable, Time: now}
+	if s.inlet.Mode == PulseMode {
+		if e.Rising {
+			s.count++
+			s.pulses = append(s.pulses, now)
+		}
+		return nil
+	}
+	s.edges = append(s.edges, e)
+	if len(s.edges) > edgesLimit {
+		s.edges = s.edges[len(s.edges)-edgesLimit:]
+	}
+	return &e
+}
+
+func (s *sampler) fail(err error) bool {
+	s.Lock()
+	defer s.Unlock()
+	first := s.err == nil
+	s.err = err
+	return first
+}
+
+// level returns the debounced level. In pulse mode the inlet is on while pulses are arriving
+func (s *sampler) level(now time.Time) (bool, bool, error) {
+	if s.inlet.Mode == PulseMode {
+		st := s.stats(now)
+		s.Lock()
+		defer s.Unlock()
+		return st.Rate > 0, s.initialized, s.err
+	}
+	s.Lock()
+	defer s.Unlock()
+	return s.stable, s.initialized, s.err
+}
+
+func (s *sampler) recentEdges() []Edge {
+	s.Lock()
+	defer s.Unlock()
+	return append([]Edge{}, s.edges...)
+}
+
+func (s *sampler) stats(now time.Time) PulseStats {
+	s.Lock()
+	defer s.Unlock()
+	cutoff := now.Add(-rateWindow)
+	i := 0
+	for i < len(s.pulses) && s.pulses[i].Before(cutoff) {
+		i++
+	}
+	s.pulses = s.pulses[i:]
+	st := PulseStats{
+		Count: s.count,
+		Rate:  float64(len(s.pulses)) / rateWindow.Seconds(),
+	}
+	if s.inlet.PulsesPerUnit > 0 {
+		st.Flow = st.Rate * 60 / s.inlet.PulsesPerUnit
+	}
+	return st
+}
+
+func (c *Inlets) poll(s *sampler, now time.Time) {
+	pin, err := s.inlet.inputPin(c.drivers)
+	if err == nil {
+		var v bool
+		if v, err = pin.Read(); err == nil {
+			if s.inlet.Reverse {
+				v = !v
+			}
+			if e := s.sample(now, v); e != nil {
+				c.emit(*e)
+			}
+			return
+		}
+	}
+	if s.fail(err) {
+		c.drivers.ReportError(s.inlet.Driver)
+	}
+}
+
+func (c *Inlets) run(s *sampler) {
+	ticker := time.NewTicker(s.inlet.sampleInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.poll(s, time.Now())
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// OnEdge registers a callback invoked for every debounced edge of inlets in level mode. Only inlets
+// with a debounce above 0 are sampled in background, inlets without debounce are read on demand
+// and produce no edges. Callbacks run on the sampler of the inlet and delay its next sample
+func (c *Inlets) OnEdge(fn func(Edge)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, fn)
+}
+
+func (c *Inlets) emit(e Edge) {
+	c.mu.Lock()
+	listeners := append([]func(Edge){}, c.listeners...)
+	c.mu.Unlock()
+	for _, fn := range listeners {
+		fn(e)
+	}
+}
+
+func (c *Inlets) sampler(id string) (*sampler, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.samplers[id]
+	return s, ok
+}
+
+// startSampler (re)starts background sampling of an inlet, if it needs one
+func (c *Inlets) startSampler(i Inlet) {
+	c.stopSampler(i.ID)
+	if !i.sampled() {
+		return
+	}
+	s := newSampler(i)
+	c.mu.Lock()
+	c.samplers[i.ID] = s
+	c.mu.Unlock()
+	go c.run(s)
+}
+
+func (c *Inlets) stopSampler(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.samplers[id]; ok {
+		close(s.quit)
+		delete(c.samplers, id)
+	}
+}
+
+// Stop halts all background samplers
+func (c *Inlets) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, s := range c.samplers {
+		close(s.quit)
+		delete(c.samplers, id)
+	}
+}
+
+// Edges returns recent debounced edges of an inlet, oldest first. It is always empty for inlets
+// without debounce, as those are not sampled in background
+func (c *Inlets) Edges(id string) ([]Edge, error) {
+	if _, err := c.Get(id); err != nil {
+		return nil, err
+	}
+	s, ok := c.sampler(id)
+	if !ok {
+		return []Edge{}, nil
+	}
+	return s.recentEdges(), nil
+}
+
+// Pulses returns pulse count and rate of an inlet in pulse mode
+func (c *Inlets) Pulses(id string) (PulseStats, error) {
+	i, err := c.Get(id)
+	if err != nil {
+		return PulseStats{}, err
+	}
+	if i.Mode != PulseMode {
+		return PulseStats{}, fmt.Errorf("inlet %s is not in pulse mode", i.Name)
+	}
+	s, ok := c.sampler(id)
+	if !ok {
+		return PulseStats{}, nil
+	}
+	return s.stats(time.Now()), nil
+}
@@ -0,0 +1,174 @@
+package connectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestSamplerDebounce(t *testing.T) {
+	s := newSampler(Inlet{ID: "1", Debounce: 50})
+	start := time.Now()
+	if e := s.sample(start, false); e != nil {
+		t.Error("first sample should not produce an edge")
+	}
+	// a short glitch is ignored
+	s.sample(start.Add(10*time.Millisecond), true)
+	s.sample(start.Add(30*time.Millisecond), false)
+	if e := s.sample(start.Add(100*time.Millisecond), false); e != nil {
+		t.Error("glitch should have been filtered", e)
+	}
+	s.sample(start.Add(110*time.Millisecond), true)
+	if e := s.sample(start.Add(140*time.Millisecond), true); e != nil {
+		t.Error("level should not be reported before debounce period", e)
+	}
+	e := s.sample(start.Add(170*time.Millisecond), true)
+	if e == nil || !e.Rising || e.Inlet != "1" {
+		t.Fatal("expected rising edge", e)
+	}
+	if v, ok, err := s.level(time.Now()); !ok || err != nil || !v {
+		t.Error("expected debounced level to be high", v, ok, err)
+	}
+	s.sample(start.Add(200*time.Millisecond), false)
+	if e := s.sample(start.Add(260*time.Millisecond), false); e == nil || e.Rising {
+		t.Error("expected falling edge", e)
+	}
+	if edges := s.recentEdges(); len(edges) != 2 {
+		t.Error("expected two recorded edges", edges)
+	}
+}
+
+func TestSamplerPulses(t *testing.T) {
+	s := newSampler(Inlet{ID: "1", Mode: PulseMode, PulsesPerUnit: 10})
+	start := time.Now()
+	s.sample(start, false)
+	for i := 1; i <= 40; i++ {
+		now := start.Add(time.Duration(i) * 125 * time.Millisecond)
+		s.sample(now, i%2 == 1)
+	}
+	now := start.Add(5 * time.Second)
+	st := s.stats(now)
+	if st.Count != 20 {
+		t.Error("expected 20 pulses, found", st.Count)
+	}
+	if st.Rate != 2 {
+		t.Error("expected 2 pulses/s over the rate window, found", st.Rate)
+	}
+	if st.Flow != 12 {
+		t.Error("expected flow of 12 units per minute, found", st.Flow)
+	}
+	if len(s.recentEdges()) != 0 {
+		t.Error("pulse mode should not record edges")
+	}
+	if v, _, _ := s.level(now); !v {
+		t.Error("inlet should be on while pulses are arriving")
+	}
+	st = s.stats(now.Add(time.Minute))
+	if st.Rate != 0 || st.Count != 20 {
+		t.Error("rate should drop to zero once pulses stop", st)
+	}
+}
+
+func TestInletSampling(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	drvrs := drivers.TestDrivers(store)
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := drvrs.List()
+	if err != nil || len(ds) != 1 {
+		t.Fatal("Expected virtual driver to be created. Error:", err)
+	}
+	id := ds[0].ID
+	d, err := drvrs.DigitalOutputDriver(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := d.(*virtual.Driver)
+
+	inlets := NewInlets(drvrs, store)
+	if err := inlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	defer inlets.Stop()
+	tr := utils.NewTestRouter()
+	inlets.LoadAPI(tr.Router)
+
+	if err := inlets.Create(Inlet{Name: "float", Pin: 0, Driver: id, Mode: "analog"}); err == nil {
+		t.Error("expected invalid mode to be rejected")
+	}
+	if err := inlets.Create(Inlet{Name: "float", Pin: 0, Driver: id, Debounce: -1}); err == nil {
+		t.Error("expected negative debounce to be rejected")
+	}
+	if err := inlets.Create(Inlet{Name: "float", Pin: 0, Driver: id, Debounce: 10, Reverse: true}); err != nil {
+		t.Fatal(err)
+	}
+	edges := make(chan Edge, 10)
+	inlets.OnEdge(func(e Edge) { edges <- e })
+
+	s, ok := inlets.sampler("1")
+	if !ok {
+		t.Fatal("expected sampler to be started for debounced inlet")
+	}
+	start := time.Now()
+	inlets.poll(s, start)
+	if v, err := inlets.Read("1"); err != nil || v != 1 {
+		t.Error("expected reversed inlet to read high", v, err)
+	}
+	if err := sim.Set(virtual.Input{Type: "digital-input", Pin: 0, Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	inlets.poll(s, start.Add(time.Second))
+	inlets.poll(s, start.Add(2*time.Second))
+	select {
+	case e := <-edges:
+		if e.Rising || e.Inlet != "1" {
+			t.Error("expected falling edge", e)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected edge callback")
+	}
+	if v, err := inlets.Read("1"); err != nil || v != 0 {
+		t.Error("expected debounced inlet to read low", v, err)
+	}
+	if err := tr.Do("GET", "/api/inlets/1/events", new(bytes.Buffer), nil); err != nil {
+		t.Error(err)
+	}
+	if err := tr.Do("GET", "/api/inlets/1/pulses", new(bytes.Buffer), nil); err == nil {
+		t.Error("expected pulses to fail for level inlet")
+	}
+
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(Inlet{Name: "flow", Pin: 0, Driver: id, Mode: PulseMode, PulsesPerUnit: 450, MaxRate: 30})
+	if err := tr.Do("POST", "/api/inlets/1", body, nil); err == nil {
+		t.Error("expected pulse rate above polling limit to be rejected")
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(Inlet{Name: "flow", Pin: 0, Driver: id, Mode: PulseMode, PulsesPerUnit: 450, MaxRate: 20})
+	if err := tr.Do("POST", "/api/inlets/1", body, nil); err != nil {
+		t.Fatal(err)
+	}
+	if s2, ok := inlets.sampler("1"); !ok || s2 == s {
+		t.Error("expected sampler to be restarted on update")
+	}
+	var st PulseStats
+	if err := tr.Do("GET", "/api/inlets/1/pulses", new(bytes.Buffer), &st); err != nil {
+		t.Error(err)
+	}
+	if err := inlets.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := inlets.sampler("1"); ok {
+		t.Error("expected sampler to be stopped on delete")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"net/http"
 
@@ -25,11 +27,24 @@ type Jack struct {
 	Pins    []int  `json:"pins"`
 	Driver  string `json:"driver"` // can be either hal or pca9685
 	Reverse bool   `json:"reverse"`
+	// RampRate limits how fast pwm values change, in percent per second. 0 applies values instantly.
+	// Dosers ignore it, their volume depends on running at speed for the whole duration
+	RampRate float64 `json:"ramp_rate"`
+	// PinRampRates overrides RampRate for individual pins
+	PinRampRates map[int]float64 `json:"pin_ramp_rates,omitempty"`
 }
 
 type Jacks struct {
-	store   storage.Store
-	drivers *drivers.Drivers
+	store        storage.Store
+	drivers      *drivers.Drivers
+	pins         *PinRegistry
+	mu           sync.Mutex
+	current      map[rampKey]float64
+	ramps        map[rampKey]*ramp
+	ramping      bool
+	rampInterval time.Duration
+	quit         chan struct{}
+	stopOnce     sync.Once
 }
 
 func (j Jack) pwmChannel(channel int, drvrs *drivers.Drivers) (hal.PWMChannel, error) {
@@ -53,13 +68,26 @@ func (j Jack) IsValid(drvrs *drivers.Drivers) error {
 			return fmt.Errorf("invalid pin %d: %v", pin, err)
 		}
 	}
+	if j.RampRate < 0 {
+		return fmt.Errorf("Jack ramp rate can not be negative")
+	}
+	for pin, r := range j.PinRampRates {
+		if r < 0 {
+			return fmt.Errorf("ramp rate of pin %d can not be negative", pin)
+		}
+	}
 	return nil
 }
 
 func NewJacks(drivers *drivers.Drivers, store storage.Store) *Jacks {
 	return &Jacks{
-		store:   store,
-		drivers: drivers,
+		store:        store,
+		drivers:      drivers,
+		pins:         NewPinRegistry(drivers, store),
+		current:      make(map[rampKey]float64),
+		ramps:        make(map[rampKey]*ramp),
+		quit:         make(chan struct{}),
+		rampInterval: rampInterval,
 	}
 }
 
@@ -93,6 +121,9 @@ func (c *Jacks) Create(j Jack) error {
 	if err := j.IsValid(c.drivers); err != nil {
 		return err
 	}
+	if err := c.pins.Check(PinOwner{Type: JackPin}, j.Driver, hal.PWM, j.Pins...); err != nil {
+		return err
+	}
 
 	fn := func(id string) interface{} {
 		j.ID = id
@@ -108,10 +139,14 @@ func (c *Jacks) Update(id string, j Jack) error {
 	if err := j.IsValid(c.drivers); err != nil {
 		return err
 	}
+	if err := c.pins.Check(PinOwner{Type: JackPin, ID: id}, j.Driver, hal.PWM, j.Pins...); err != nil {
+		return err
+	}
 	j.ID = id
 	if err := c.store.Update(JackBucket, id, j); err != nil {
 		return err
 	}
+	c.forget(id)
 	return nil
 }
 
@@ -120,7 +155,11 @@ func (c *Jacks) Delete(id string) error {
 	if err != nil {
 		return err
 	}
-	return c.store.Delete(JackBucket, id)
+	if err := c.store.Delete(JackBucket, id); err != nil {
+		return err
+	}
+	c.forget(id)
+	return nil
 }
 
 func (c *Jacks) LoadAPI(r *mux.Router) {
@@ -231,6 +270,24 @@ func (c *Jacks) LoadAPI(r *mux.Router) {
 	//  200:
 	//   description: OK
 	r.HandleFunc("/api/jacks/{id}/control", c.control).Methods("POST")
+
+	// swagger:operation GET /api/jacks/{id}/values Jack jackValues
+	// Get current values of a jack.
+	// Get current pwm values of a jack's pins, which lag behind requested values while ramping.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the jack
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/jacks/{id}/values", c.values).Methods("GET")
 }
 
 type PinValues map[int]float64
@@ -242,7 +299,18 @@ func (pv PinValues) String() (res string) {
 	return
 }
 
+// Control sets pwm values of a jack's pins, ramping them at the jack's ramp rate
 func (jacks *Jacks) Control(id string, values PinValues) error {
+	return jacks.setValues(id, values, true)
+}
+
+// ControlInstantly sets pwm values of a jack's pins ignoring ramp rates, for timed runs such as
+// doser pumps where ramping would change the delivered volume
+func (jacks *Jacks) ControlInstantly(id string, values PinValues) error {
+	return jacks.setValues(id, values, false)
+}
+
+func (jacks *Jacks) setValues(id string, values PinValues, ramp bool) error {
 	j, err := jacks.Get(id)
 	if err != nil {
 		return err
@@ -252,19 +320,22 @@ func (jacks *Jacks) Control(id string, values PinValues) error {
 		if !ok {
 			continue
 		}
-		channel, err := j.pwmChannel(pin, jacks.drivers)
-		if err != nil {
+		if _, err := j.pwmChannel(pin, jacks.drivers); err != nil {
 			return fmt.Errorf("pin %d on jack %s has no driver: %v", pin, id, err)
 		}
 		if v > 100 || v < 0 {
 			return fmt.Errorf("invalid value:%f for pin: %d", v, pin)
 		}
-		if j.Reverse {
-			v = 100 - v
+		if ramp && !jacks.schedule(j, pin, v) {
+			continue
 		}
-		if err := channel.Set(v); err != nil {
+		if !ramp {
+			jacks.cancelRamp(id, pin)
+		}
+		if err := jacks.write(j, pin, v); err != nil {
 			return err
 		}
+		jacks.setCurrent(id, pin, v)
 	}
 	log.Println("connectors: jack", j.Name, "updated pwm values:", values)
 	return nil
@@ -278,6 +349,13 @@ func (c *Jacks) control(w http.ResponseWriter, r *http.Request) {
 	utils.JSONUpdateResponse(&v, fn, w, r)
 }
 
+func (c *Jacks) values(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return c.Values(id)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
 func (c *Jacks) get(w http.ResponseWriter, r *http.Request) {
 	fn := func(id string) (interface{}, error) {
 		return c.Get(id)
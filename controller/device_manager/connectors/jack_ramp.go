@@ -0,0 +1,180 @@
+package connectors
+
+import (
+	"log"
+	"math"
+	"time"
+)
+
+// default interval between ramp steps
+const rampInterval = 100 * time.Millisecond
+
+type rampKey struct {
+	jack string
+	pin  int
+}
+
+// ramp moves a jack pin towards its target value at a limited rate (percent per second)
+type ramp struct {
+	jack   Jack
+	target float64
+	rate   float64
+}
+
+type pinWrite struct {
+	jack  Jack
+	pin   int
+	value float64
+}
+
+// rampRate returns the slew rate of a pin in percent per second, 0 means values are applied instantly
+func (j Jack) rampRate(pin int) float64 {
+	if r, ok := j.PinRampRates[pin]; ok {
+		return r
+	}
+	return j.RampRate
+}
+
+// schedule records the target value of a pin. It returns true if the value should be
+// written immediately, otherwise the value is reached gradually by the ramp loop.
+func (c *Jacks) schedule(j Jack, pin int, v float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := rampKey{jack: j.ID, pin: pin}
+	rate := j.rampRate(pin)
+	if rate <= 0 {
+		delete(c.ramps, k)
+		return true
+	}
+	// pins that were never set since start are soft started from zero
+	cur, ok := c.current[k]
+	if !ok && v == 0 {
+		return true
+	}
+	if cur == v {
+		delete(c.ramps, k)
+		return false
+	}
+	c.ramps[k] = &ramp{jack: j, target: v, rate: rate}
+	if !c.ramping {
+		c.ramping = true
+		go c.rampLoop()
+	}
+	return false
+}
+
+func (c *Jacks) rampLoop() {
+	ticker := time.NewTicker(c.rampInterval)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case now := <-ticker.C:
+			active := c.step(now.Sub(last))
+			last = now
+			if !active {
+				return
+			}
+		case <-c.quit:
+			c.mu.Lock()
+			c.ramping = false
+			c.mu.Unlock()
+			return
+		}
+	}
+}
+
+// step advances all active ramps by elapsed time and returns true while ramps remain
+func (c *Jacks) step(elapsed time.Duration) bool {
+	var writes []pinWrite
+	c.mu.Lock()
+	for k, r := range c.ramps {
+		cur := c.current[k]
+		delta := r.rate * elapsed.Seconds()
+		v := r.target
+		if math.Abs(r.target-cur) > delta {
+			v = cur + math.Copysign(delta, r.target-cur)
+		}
+		if v == r.target {
+			delete(c.ramps, k)
+		}
+		c.current[k] = v
+		writes = append(writes, pinWrite{jack: r.jack, pin: k.pin, value: v})
+	}
+	active := len(c.ramps) > 0
+	if !active {
+		c.ramping = false
+	}
+	c.mu.Unlock()
+	for _, w := range writes {
+		if err := c.write(w.jack, w.pin, w.value); err != nil {
+			log.Println("ERROR: connectors: failed to ramp pin", w.pin, "of jack", w.jack.Name, ". Error:", err)
+			c.cancelRamp(w.jack.ID, w.pin)
+		}
+	}
+	return active
+}
+
+func (c *Jacks) write(j Jack, pin int, v float64) error {
+	channel, err := j.pwmChannel(pin, c.drivers)
+	if err != nil {
+		return err
+	}
+	if j.Reverse {
+		v = 100 - v
+	}
+	if err := channel.Set(v); err != nil {
+		c.drivers.ReportError(j.Driver)
+		return err
+	}
+	return nil
+}
+
+func (c *Jacks) setCurrent(id string, pin int, v float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current[rampKey{jack: id, pin: pin}] = v
+}
+
+func (c *Jacks) cancelRamp(id string, pin int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.ramps, rampKey{jack: id, pin: pin})
+}
+
+// forget drops ramps and tracked values of a jack, e.g. when it is updated or deleted
+func (c *Jacks) forget(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.ramps {
+		if k.jack == id {
+			delete(c.ramps, k)
+		}
+	}
+	for k := range c.current {
+		if k.jack == id {
+			delete(c.current, k)
+		}
+	}
+}
+
+// Values returns the current pwm values of a jack's pins, which lag behind the
+// requested values while ramping
+func (c *Jacks) Values(id string) (PinValues, error) {
+	j, err := c.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pv := make(PinValues)
+	for _, pin := range j.Pins {
+		pv[pin] = c.current[rampKey{jack: id, pin: pin}]
+	}
+	return pv, nil
+}
+
+// Stop halts ramping. Pins are left at their current values
+func (c *Jacks) Stop() {
+	c.stopOnce.Do(func() { close(c.quit) })
+}
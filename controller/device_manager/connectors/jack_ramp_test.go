@@ -0,0 +1,105 @@
+package connectors
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestJackRamp(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	drvrs := drivers.TestDrivers(store)
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := drvrs.DigitalOutputDriver("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := d.(*virtual.Driver)
+	jacks := NewJacks(drvrs, store)
+	// ramps are stepped manually
+	jacks.rampInterval = time.Hour
+	if err := jacks.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	defer jacks.Stop()
+	tr := utils.NewTestRouter()
+	jacks.LoadAPI(tr.Router)
+
+	if err := jacks.Create(Jack{Name: "pump", Pins: []int{0, 1}, Driver: "1", RampRate: -1}); err == nil {
+		t.Error("expected negative ramp rate to be rejected")
+	}
+	j := Jack{Name: "pump", Pins: []int{0, 1}, Driver: "1", RampRate: 10, PinRampRates: map[int]float64{1: 0}}
+	if err := jacks.Create(j); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := jacks.Control("1", PinValues{0: 50, 1: 70}); err != nil {
+		t.Fatal(err)
+	}
+	writes := sim.Writes(start)
+	if len(writes) != 1 || writes[0].Pin != 1 || writes[0].Value != 70 {
+		t.Fatal("expected only the pin without ramp rate to be written instantly", writes)
+	}
+	if pv, err := jacks.Values("1"); err != nil || pv[0] != 0 || pv[1] != 70 {
+		t.Error("expected pin 0 to soft start from zero", pv, err)
+	}
+	if !jacks.step(2 * time.Second) {
+		t.Error("expected ramp to be active")
+	}
+	if pv, _ := jacks.Values("1"); pv[0] != 20 {
+		t.Error("expected pin 0 to be at 20 after 2s", pv)
+	}
+	// a new target reverses direction from the current value
+	if err := jacks.Control("1", PinValues{0: 15}); err != nil {
+		t.Fatal(err)
+	}
+	if jacks.step(time.Second) {
+		t.Error("expected ramp to finish")
+	}
+	writes = sim.Writes(start)
+	if last := writes[len(writes)-1]; last.Pin != 0 || last.Value != 15 {
+		t.Error("expected pin 0 to reach 15", last)
+	}
+	if err := tr.Do("GET", "/api/jacks/1/values", new(bytes.Buffer), nil); err != nil {
+		t.Error(err)
+	}
+
+	// timed runs bypass ramping, cancelling an active ramp
+	if err := jacks.Control("1", PinValues{0: 80}); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.ControlInstantly("1", PinValues{0: 30}); err != nil {
+		t.Fatal(err)
+	}
+	writes = sim.Writes(start)
+	if last := writes[len(writes)-1]; last.Pin != 0 || last.Value != 30 {
+		t.Error("expected pin 0 to be written instantly", last)
+	}
+	if jacks.step(time.Second) {
+		t.Error("expected instant control to cancel the ramp")
+	}
+
+	j.Reverse = true
+	j.RampRate = 0
+	if err := jacks.Update("1", j); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.Control("1", PinValues{0: 40}); err != nil {
+		t.Fatal(err)
+	}
+	writes = sim.Writes(start)
+	if last := writes[len(writes)-1]; last.Pin != 0 || last.Value != 60 {
+		t.Error("expected reversed value to be written instantly once ramp rate is removed", last)
+	}
+}
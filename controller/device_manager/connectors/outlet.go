@@ -2,6 +2,7 @@ package connectors
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -26,6 +27,14 @@ type Outlet struct {
 	Driver    string `json:"driver"`
 }
 
+// StateReader is implemented by digital output pins that can read back the actual state of
+// the device, e.g. network plugs that can also be toggled with a physical button
+type StateReader interface {
+	ReadState() (bool, error)
+}
+
+var ErrReadBackUnsupported = errors.New("outlet driver can not report state")
+
 func (c *Outlets) HalPin(id string) (hal.DigitalOutputPin, error) {
 	o, err := c.Get(id)
 	if err != nil {
@@ -58,12 +67,14 @@ func (o Outlet) IsValid(drivers *drivers.Drivers) error {
 type Outlets struct {
 	store   storage.Store
 	drivers *drivers.Drivers
+	pins    *PinRegistry
 }
 
 func NewOutlets(drivers *drivers.Drivers, store storage.Store) *Outlets {
 	return &Outlets{
 		store:   store,
 		drivers: drivers,
+		pins:    NewPinRegistry(drivers, store),
 	}
 }
 
@@ -84,13 +95,50 @@ func (c *Outlets) Configure(id string, on bool) error {
 	if o.Reverse {
 		on = !on
 	}
-	return pin.Write(on)
+	if err := pin.Write(on); err != nil {
+		c.drivers.ReportError(o.Driver)
+		return err
+	}
+	return nil
+}
+
+// Observe reads back the actual state of an outlet, taking reverse into account. Returns
+// ErrReadBackUnsupported if the driver pin can not report state
+func (c *Outlets) Observe(id string) (bool, error) {
+	o, err := c.Get(id)
+	if err != nil {
+		return false, fmt.Errorf("Outlet name: '%s' does not exist", err)
+	}
+	d, err := c.drivers.DigitalOutputDriver(o.Driver)
+	if err != nil {
+		return false, fmt.Errorf("outlet %s driver lookup failure: %v", o.Name, err)
+	}
+	pin, err := d.DigitalOutputPin(o.Pin)
+	if err != nil {
+		return false, fmt.Errorf("no valid output pin %d: %v", o.Pin, err)
+	}
+	r, ok := c.stateReader(o, d, pin)
+	if !ok {
+		return false, ErrReadBackUnsupported
+	}
+	on, err := r.ReadState()
+	if err != nil {
+		c.drivers.ReportError(o.Driver)
+		return false, err
+	}
+	if o.Reverse {
+		on = !on
+	}
+	return on, nil
 }
 
 func (c *Outlets) Create(o Outlet) error {
 	if err := o.IsValid(c.drivers); err != nil {
 		return err
 	}
+	if err := c.pins.Check(PinOwner{Type: OutletPin}, o.Driver, hal.DigitalOutput, o.Pin); err != nil {
+		return err
+	}
 	fn := func(id string) interface{} {
 		o.ID = id
 		return &o
@@ -103,6 +151,9 @@ func (c *Outlets) Update(id string, o Outlet) error {
 	if err := o.IsValid(c.drivers); err != nil {
 		return err
 	}
+	if err := c.pins.Check(PinOwner{Type: OutletPin, ID: id}, o.Driver, hal.DigitalOutput, o.Pin); err != nil {
+		return err
+	}
 	return c.store.Update(OutletBucket, id, o)
 }
 
@@ -0,0 +1,91 @@
+package connectors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/reef-pi/drivers/tplink"
+	"github.com/reef-pi/hal"
+)
+
+// PowerMeter is implemented by digital output pins, or drivers, that can measure the power
+// drawn by the attached device, e.g. energy monitoring smart plugs. Readings are in watts
+type PowerMeter interface {
+	ReadPower() (float64, error)
+}
+
+var ErrPowerMeteringUnsupported = errors.New("outlet driver can not measure power")
+
+// hs110Meter adapts the realtime emeter of tplink hs110 plugs, which report watts
+type hs110Meter struct {
+	p *tplink.HS110Plug
+}
+
+func (m hs110Meter) ReadPower() (float64, error) {
+	rt, err := m.p.RTEmeter()
+	if err != nil {
+		return 0, err
+	}
+	return rt.Power, nil
+}
+
+// hs300Meter adapts the realtime emeter of tplink hs300 strip outlets, which report milliwatts
+type hs300Meter struct {
+	o *tplink.Outlet
+}
+
+func (m hs300Meter) ReadPower() (float64, error) {
+	rt, err := m.o.RTEmeter()
+	if err != nil {
+		return 0, err
+	}
+	return rt.Power / 1000, nil
+}
+
+// powerMeter picks the meter of an outlet. hs300 and hs303 strips share the outlet type, only
+// hs300 outlets have an emeter
+func powerMeter(d hal.DigitalOutputDriver, pin hal.DigitalOutputPin) (PowerMeter, bool) {
+	if m, ok := pin.(PowerMeter); ok {
+		return m, true
+	}
+	if o, ok := pin.(*tplink.Outlet); ok {
+		if _, ok := d.(*tplink.HS300Strip); ok {
+			return hs300Meter{o: o}, true
+		}
+		return nil, false
+	}
+	switch m := d.(type) {
+	case PowerMeter:
+		return m, true
+	case *tplink.HS110Plug:
+		return hs110Meter{p: m}, true
+	}
+	return nil, false
+}
+
+// Power reads the power drawn through an outlet in watts. Returns ErrPowerMeteringUnsupported
+// if the driver can not measure power
+func (c *Outlets) Power(id string) (float64, error) {
+	o, err := c.Get(id)
+	if err != nil {
+		return 0, fmt.Errorf("Outlet name: '%s' does not exist", err)
+	}
+	d, err := c.drivers.DigitalOutputDriver(o.Driver)
+	if err != nil {
+		return 0, fmt.Errorf("driver: %s for outlet: %s is not a digital output driver", o.Driver, o.Name)
+	}
+	pin, err := d.DigitalOutputPin(o.Pin)
+	if err != nil {
+		return 0, fmt.Errorf("no valid output pin %d. Error: %w", o.Pin, err)
+	}
+	m, ok := powerMeter(d, pin)
+	if !ok {
+		return 0, ErrPowerMeteringUnsupported
+	}
+	w, err := m.ReadPower()
+	if err != nil {
+		c.drivers.ReportError(o.Driver)
+		return 0, err
+	}
+	return w, nil
+}
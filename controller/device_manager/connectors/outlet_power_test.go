@@ -0,0 +1,42 @@
+package connectors
+
+import (
+	"testing"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+func TestOutletPowerTPLinkStrips(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	drvrs := drivers.TestDrivers(store)
+	outlets := NewOutlets(drvrs, store)
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	// both strips answer system info and emeter requests alike, only the hs300 has an emeter
+	strip := fakeTPLink(t, `{"system":{"get_sysinfo":{"children":[{"id":"a","state":1}]}},"emeter":{"get_realtime":{"power_mw":12500}}}`)
+	defer strip.Close()
+	config := []byte(`{"Address":"` + strip.Addr().String() + `"}`)
+	for _, typ := range []string{"hs300", "hs303"} {
+		if err := drvrs.Create(drivers.Driver{Name: typ, Type: typ, Config: config}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := outlets.Create(Outlet{Name: "hs300", Pin: 0, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Create(Outlet{Name: "hs303", Pin: 0, Driver: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if w, err := outlets.Power("1"); err != nil || w != 12.5 {
+		t.Error("expected hs300 outlet to draw 12.5W", w, err)
+	}
+	if _, err := outlets.Power("2"); err != ErrPowerMeteringUnsupported {
+		t.Error("expected hs303 outlet not to support power metering, found:", err)
+	}
+}
@@ -0,0 +1,104 @@
+package connectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/reef-pi/drivers/shelly"
+	"github.com/reef-pi/drivers/tplink"
+	"github.com/reef-pi/hal"
+)
+
+// address parameter of tplink and shelly drivers
+const addressParam = "Address"
+
+// tplinkInfo is implemented by tplink hs103 and hs110 plugs
+type tplinkInfo interface {
+	Info() (*tplink.Sysinfo, error)
+}
+
+// tplinkPlugState reads back the relay state of tplink plugs
+type tplinkPlugState struct {
+	p tplinkInfo
+}
+
+func (s tplinkPlugState) ReadState() (bool, error) {
+	info, err := s.p.Info()
+	if err != nil {
+		return false, err
+	}
+	return info.RelayState == 1, nil
+}
+
+// tplinkOutletState reads back the state of a tplink hs300 or hs303 strip outlet. Strip outlets
+// can not query system info themselves, a plug driver is used to query the strip
+type tplinkOutletState struct {
+	addr  string
+	child int
+}
+
+func (s tplinkOutletState) ReadState() (bool, error) {
+	d, err := tplink.HS103Factory().NewDriver(map[string]interface{}{addressParam: s.addr}, nil)
+	if err != nil {
+		return false, err
+	}
+	info, err := d.(tplinkInfo).Info()
+	if err != nil {
+		return false, err
+	}
+	if s.child >= len(info.Children) {
+		return false, fmt.Errorf("strip reported %d outlets, outlet %d not found", len(info.Children), s.child)
+	}
+	return info.Children[s.child].State == 1, nil
+}
+
+// shellyState reads back the state of a shelly relay using its http api
+type shellyState struct {
+	addr    string
+	channel int
+}
+
+func (s shellyState) ReadState() (bool, error) {
+	h := &http.Client{Timeout: 5 * time.Second}
+	resp, err := h.Get(fmt.Sprintf("http://%s/relay/%d", s.addr, s.channel))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("http failure. Code:%d", resp.StatusCode)
+	}
+	var status struct {
+		IsOn bool `json:"ison"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return false, err
+	}
+	return status.IsOn, nil
+}
+
+func (c *Outlets) stateReader(o Outlet, d hal.DigitalOutputDriver, pin hal.DigitalOutputPin) (StateReader, bool) {
+	if r, ok := pin.(StateReader); ok {
+		return r, true
+	}
+	switch m := d.(type) {
+	case tplinkInfo:
+		return tplinkPlugState{p: m}, true
+	case *tplink.HS300Strip, *tplink.HS303Strip:
+		dr, err := c.drivers.Get(o.Driver)
+		if err != nil {
+			return nil, false
+		}
+		return tplinkOutletState{addr: dr.Parameter(addressParam), child: pin.Number()}, true
+	}
+	if r, ok := pin.(*shelly.Relay); ok {
+		dr, err := c.drivers.Get(o.Driver)
+		if err != nil {
+			return nil, false
+		}
+		return shellyState{addr: dr.Parameter(addressParam), channel: r.Number()}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,98 @@
+package connectors
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+// fakeTPLink serves a fixed response to every command sent to a tplink device
+func fakeTPLink(t *testing.T, response string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			header := make([]byte, 4)
+			if _, err := io.ReadFull(conn, header); err == nil {
+				io.ReadFull(conn, make([]byte, binary.BigEndian.Uint32(header)))
+				key := byte(0xAB)
+				payload := []byte(response)
+				for i := range payload {
+					payload[i] ^= key
+					key = payload[i]
+				}
+				binary.BigEndian.PutUint32(header, uint32(len(payload)))
+				conn.Write(append(header, payload...))
+			}
+			conn.Close()
+		}
+	}()
+	return l
+}
+
+func TestOutletStateReadBack(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	drvrs := drivers.TestDrivers(store)
+	outlets := NewOutlets(drvrs, store)
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	strip := fakeTPLink(t, `{"system":{"get_sysinfo":{"children":[{"id":"a","state":1},{"id":"b","state":0}]}}}`)
+	defer strip.Close()
+	plug := fakeTPLink(t, `{"system":{"get_sysinfo":{"relay_state":1}}}`)
+	defer plug.Close()
+	relay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]bool{"ison": r.URL.Path == "/relay/0"})
+	}))
+	defer relay.Close()
+
+	for _, d := range []drivers.Driver{
+		{Name: "strip", Type: "hs303", Config: []byte(`{"Address":"` + strip.Addr().String() + `"}`)},
+		{Name: "plug", Type: "hs103", Config: []byte(`{"Address":"` + plug.Addr().String() + `"}`)},
+		{Name: "relay", Type: "shelly1", Config: []byte(`{"Address":"` + strings.TrimPrefix(relay.URL, "http://") + `"}`)},
+	} {
+		if err := drvrs.Create(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, tc := range []struct {
+		o        Outlet
+		expected bool
+	}{
+		{o: Outlet{Name: "strip 0", Pin: 0, Driver: "1"}, expected: true},
+		{o: Outlet{Name: "strip 1", Pin: 1, Driver: "1"}, expected: false},
+		{o: Outlet{Name: "plug", Pin: 0, Driver: "2"}, expected: true},
+		{o: Outlet{Name: "relay", Pin: 0, Driver: "3", Reverse: true}, expected: false},
+	} {
+		if err := outlets.Create(tc.o); err != nil {
+			t.Fatal(err)
+		}
+		on, err := outlets.Observe(fmt.Sprint(i + 1))
+		if err != nil {
+			t.Error("Failed to read back state of", tc.o.Name, err)
+		}
+		if on != tc.expected {
+			t.Error("Unexpected state of", tc.o.Name, "expected:", tc.expected, "found:", on)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package connectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gorilla/mux"
+
+	"github.com/reef-pi/hal"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+// Connector types owning driver pins
+const (
+	OutletPin      = "outlet"
+	InletPin       = "inlet"
+	JackPin        = "jack"
+	AnalogInputPin = "analog_input"
+)
+
+// Module entities driving individual jack pins or outlets
+const (
+	EquipmentPin = "equipment"
+	DoserPin     = "doser"
+	LightPin     = "light"
+	WavemakerPin = "wavemaker"
+)
+
+// swagger:model pinOwner
+type PinOwner struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// swagger:model pinAssignment
+type PinAssignment struct {
+	Capability string    `json:"capability"`
+	Pin        int       `json:"pin"`
+	Name       string    `json:"name"`
+	Owner      *PinOwner `json:"owner,omitempty"`
+}
+
+type pinClaim struct {
+	owner  PinOwner
+	driver string
+	// jack is set for claims on a jack pin instead of a driver pin
+	jack string
+	// outlet is set for claims on an outlet switched by a module entity
+	outlet string
+	cap    hal.Capability
+	pin    int
+}
+
+// PinRegistry tracks which outlet, inlet, jack or analog input owns each driver pin, so that
+// two connectors can not drive the same physical pin. Claims are derived from the stored
+// connectors. Pins are the same when they share driver, capability and number, or when the
+// driver hands out the same pin for different capabilities (e.g. pca9685 channels used as
+// both pwm and digital output). Jack pins and outlets driven by module entities (equipment,
+// dosers, lights, wavemaker pumps) are tracked the same way, so that two entities can not
+// share them
+type PinRegistry struct {
+	store   storage.Store
+	drivers *drivers.Drivers
+}
+
+func NewPinRegistry(drivers *drivers.Drivers, store storage.Store) *PinRegistry {
+	return &PinRegistry{
+		store:   store,
+		drivers: drivers,
+	}
+}
+
+func (r *PinRegistry) list(bucket string, fn func([]byte) ([]pinClaim, error)) ([]pinClaim, error) {
+	var claims []pinClaim
+	var failed error
+	err := r.store.List(bucket, func(_ string, v []byte) error {
+		cs, err := fn(v)
+		if err != nil {
+			failed = err
+			return err
+		}
+		claims = append(claims, cs...)
+		return nil
+	})
+	if failed != nil {
+		return nil, failed
+	}
+	if err != nil {
+		// bucket is not created yet
+		return nil, nil
+	}
+	return claims, nil
+}
+
+func (r *PinRegistry) claims() ([]pinClaim, error) {
+	var all []pinClaim
+	for _, l := range []struct {
+		bucket string
+		fn     func([]byte) ([]pinClaim, error)
+	}{
+		{OutletBucket, func(v []byte) ([]pinClaim, error) {
+			var o Outlet
+			if err := json.Unmarshal(v, &o); err != nil {
+				return nil, err
+			}
+			return []pinClaim{{owner: PinOwner{Type: OutletPin, ID: o.ID, Name: o.Name}, driver: o.Driver, cap: hal.DigitalOutput, pin: o.Pin}}, nil
+		}},
+		{InletBucket, func(v []byte) ([]pinClaim, error) {
+			var i Inlet
+			if err := json.Unmarshal(v, &i); err != nil {
+				return nil, err
+			}
+			return []pinClaim{{owner: PinOwner{Type: InletPin, ID: i.ID, Name: i.Name}, driver: i.Driver, cap: hal.DigitalInput, pin: i.Pin}}, nil
+		}},
+		{JackBucket, func(v []byte) ([]pinClaim, error) {
+			var j Jack
+			if err := json.Unmarshal(v, &j); err != nil {
+				return nil, err
+			}
+			var cs []pinClaim
+			for _, p := range j.Pins {
+				cs = append(cs, pinClaim{owner: PinOwner{Type: JackPin, ID: j.ID, Name: j.Name}, driver: j.Driver, cap: hal.PWM, pin: p})
+			}
+			return cs, nil
+		}},
+		{AnalogInputBucket, func(v []byte) ([]pinClaim, error) {
+			var a AnalogInput
+			if err := json.Unmarshal(v, &a); err != nil {
+				return nil, err
+			}
+			return []pinClaim{{owner: PinOwner{Type: AnalogInputPin, ID: a.ID, Name: a.Name}, driver: a.Driver, cap: hal.AnalogInput, pin: a.Pin}}, nil
+		}},
+	} {
+		cs, err := r.list(l.bucket, l.fn)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, cs...)
+	}
+	return all, nil
+}
+
+func (r *PinRegistry) halPin(driver string, cap hal.Capability, n int) hal.Pin {
+	var p hal.Pin
+	switch cap {
+	case hal.DigitalOutput:
+		if d, err := r.drivers.DigitalOutputDriver(driver); err == nil {
+			p, _ = d.DigitalOutputPin(n)
+		}
+	case hal.DigitalInput:
+		if d, err := r.drivers.DigitalInputDriver(driver); err == nil {
+			p, _ = d.DigitalInputPin(n)
+		}
+	case hal.PWM:
+		if d, err := r.drivers.PWMDriver(driver); err == nil {
+			p, _ = d.PWMChannel(n)
+		}
+	case hal.AnalogInput:
+		if d, err := r.drivers.AnalogInputDriver(driver); err == nil {
+			p, _ = d.AnalogInputPin(n)
+		}
+	}
+	return p
+}
+
+func samePin(a, b hal.Pin) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if reflect.ValueOf(a).Kind() != reflect.Ptr || reflect.ValueOf(b).Kind() != reflect.Ptr {
+		return false
+	}
+	return a == b
+}
+
+func (r *PinRegistry) conflicts(c pinClaim, driver string, cap hal.Capability, pin int) bool {
+	if c.driver != driver {
+		return false
+	}
+	if c.cap == cap {
+		return c.pin == pin
+	}
+	return samePin(r.halPin(driver, c.cap, c.pin), r.halPin(driver, cap, pin))
+}
+
+// entityClaims lists the jack pins and outlets driven by equipment, dosers, lights and wavemaker pumps
+func (r *PinRegistry) entityClaims() ([]pinClaim, error) {
+	type pump struct {
+		Jack   string `json:"jack"`
+		Pin    int    `json:"pin"`
+		Outlet string `json:"outlet"`
+	}
+	type entity struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		Jack     string `json:"jack"`
+		Pin      int    `json:"pin"`
+		Outlet   string `json:"outlet"`
+		Channels map[string]struct {
+			Pin int `json:"pin"`
+		} `json:"channels"`
+		Pumps []pump `json:"pumps"`
+	}
+	var all []pinClaim
+	for _, l := range []struct {
+		bucket string
+		typ    string
+	}{
+		{storage.EquipmentBucket, EquipmentPin},
+		{storage.DoserBucket, DoserPin},
+		{storage.LightingBucket, LightPin},
+		{storage.WavemakerBucket, WavemakerPin},
+	} {
+		typ := l.typ
+		cs, err := r.list(l.bucket, func(v []byte) ([]pinClaim, error) {
+			var e entity
+			if err := json.Unmarshal(v, &e); err != nil {
+				return nil, err
+			}
+			owner := PinOwner{Type: typ, ID: e.ID, Name: e.Name}
+			var cs []pinClaim
+			switch typ {
+			case LightPin:
+				for _, ch := range e.Channels {
+					cs = append(cs, pinClaim{owner: owner, jack: e.Jack, pin: ch.Pin})
+				}
+			case WavemakerPin:
+				for _, p := range e.Pumps {
+					cs = append(cs, pinClaim{owner: owner, jack: p.Jack, pin: p.Pin, outlet: p.Outlet})
+				}
+			default:
+				cs = append(cs, pinClaim{owner: owner, jack: e.Jack, pin: e.Pin, outlet: e.Outlet})
+			}
+			return cs, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, cs...)
+	}
+	return all, nil
+}
+
+// shares is true when a claim belongs to owner, or to another wavemaker as wavemakers
+// sharing pumps are exclusive modes of the same pumps
+func (o PinOwner) shares(c pinClaim) bool {
+	if c.owner.Type != o.Type {
+		return false
+	}
+	return o.Type == WavemakerPin || (o.ID != "" && c.owner.ID == o.ID)
+}
+
+// CheckJack returns an error if any of the jack pins is already driven by an entity other than owner
+func (r *PinRegistry) CheckJack(owner PinOwner, jack string, pins ...int) error {
+	claims, err := r.entityClaims()
+	if err != nil {
+		return err
+	}
+	for _, p := range pins {
+		for _, c := range claims {
+			if owner.shares(c) {
+				continue
+			}
+			if c.jack != "" && c.jack == jack && c.pin == p {
+				return fmt.Errorf("pin %d of jack %s is already used by %s '%s'", p, jack, c.owner.Type, c.owner.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckOutlet returns an error if the outlet is already switched by an entity other than owner
+func (r *PinRegistry) CheckOutlet(owner PinOwner, outlet string) error {
+	claims, err := r.entityClaims()
+	if err != nil {
+		return err
+	}
+	for _, c := range claims {
+		if owner.shares(c) {
+			continue
+		}
+		if c.outlet != "" && c.outlet == outlet {
+			return fmt.Errorf("outlet %s is already used by %s '%s'", outlet, c.owner.Type, c.owner.Name)
+		}
+	}
+	return nil
+}
+
+// Check returns an error if any of the pins is already owned by a connector other than owner
+func (r *PinRegistry) Check(owner PinOwner, driver string, cap hal.Capability, pins ...int) error {
+	claims, err := r.claims()
+	if err != nil {
+		return err
+	}
+	for _, p := range pins {
+		for _, c := range claims {
+			if c.owner.Type == owner.Type && c.owner.ID == owner.ID && owner.ID != "" {
+				continue
+			}
+			if r.conflicts(c, driver, cap, p) {
+				return fmt.Errorf("pin %d of driver %s is already used by %s '%s'", p, driver, c.owner.Type, c.owner.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Assignments lists every pin of a driver along with the connector owning it
+func (r *PinRegistry) Assignments(driver string) ([]PinAssignment, error) {
+	d, err := r.drivers.HalDriver(driver)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := r.claims()
+	if err != nil {
+		return nil, err
+	}
+	assignments := []PinAssignment{}
+	for _, cap := range d.Metadata().Capabilities {
+		pins, err := d.Pins(cap)
+		if err != nil {
+			continue
+		}
+		for _, p := range pins {
+			a := PinAssignment{Capability: cap.String(), Pin: p.Number(), Name: p.Name()}
+			for _, c := range claims {
+				if r.conflicts(c, driver, cap, p.Number()) {
+					owner := c.owner
+					a.Owner = &owner
+					break
+				}
+			}
+			assignments = append(assignments, a)
+		}
+	}
+	return assignments, nil
+}
+
+func (r *PinRegistry) LoadAPI(router *mux.Router) {
+
+	// swagger:operation GET /api/drivers/{id}/pins Driver driverPins
+	// List driver pin assignments.
+	// List the pins of a driver along with the outlet, inlet, jack or analog input owning each pin.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the driver
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    type: array
+	//    items:
+	//     $ref: '#/definitions/pinAssignment'
+	//  404:
+	//   description: Not Found
+	router.HandleFunc("/api/drivers/{id}/pins", r.assignments).Methods("GET")
+}
+
+func (r *PinRegistry) assignments(w http.ResponseWriter, req *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return r.Assignments(id)
+	}
+	utils.JSONGetResponse(fn, w, req)
+}
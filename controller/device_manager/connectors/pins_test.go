@@ -0,0 +1,101 @@
+package connectors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestPinRegistry(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	drvrs := drivers.TestDrivers(store)
+	if err := drvrs.Create(drivers.Driver{
+		Name:   "pwm",
+		Type:   "pca9685",
+		Config: []byte(`{"address":64, "frequency":1000}`),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	outlets := NewOutlets(drvrs, store)
+	inlets := NewInlets(drvrs, store)
+	jacks := NewJacks(drvrs, store)
+	for _, s := range []func() error{outlets.Setup, inlets.Setup, jacks.Setup} {
+		if err := s(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := outlets.Create(Outlet{Name: "heater", Pin: 21, Driver: "rpi"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Create(Outlet{Name: "pump", Pin: 21, Driver: "rpi"}); err == nil {
+		t.Error("expected second outlet on the same pin to be rejected")
+	}
+	if err := inlets.Create(Inlet{Name: "float", Pin: 21, Driver: "rpi"}); err == nil {
+		t.Error("expected inlet on an outlet gpio to be rejected")
+	}
+	if err := inlets.Create(Inlet{Name: "float", Pin: 20, Driver: "rpi"}); err != nil {
+		t.Error(err)
+	}
+	if err := outlets.Update("1", Outlet{Name: "heater-2", Pin: 21, Driver: "rpi"}); err != nil {
+		t.Error("outlet should keep its own pin on update", err)
+	}
+	if err := outlets.Update("1", Outlet{Name: "heater-2", Pin: 20, Driver: "rpi"}); err == nil {
+		t.Error("expected outlet update onto an inlet gpio to be rejected")
+	}
+
+	if err := jacks.Create(Jack{Name: "lights", Pins: []int{0, 1}, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.Create(Jack{Name: "doser", Pins: []int{1}, Driver: "1"}); err == nil {
+		t.Error("expected second jack on the same channel to be rejected")
+	}
+	err = outlets.Create(Outlet{Name: "stepper", Pin: 0, Driver: "1"})
+	if err == nil || !strings.Contains(err.Error(), "jack 'lights'") {
+		t.Error("expected outlet on a pca9685 channel used by a jack to be rejected", err)
+	}
+	if err := outlets.Create(Outlet{Name: "stepper", Pin: 2, Driver: "1"}); err != nil {
+		t.Error(err)
+	}
+
+	pins := NewPinRegistry(drvrs, store)
+	as, err := pins.Assignments("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	owners := make(map[string]string)
+	for _, a := range as {
+		if a.Owner != nil && a.Pin < 3 {
+			owners[a.Capability+"-"+a.Name] = a.Owner.Type + ":" + a.Owner.Name
+		}
+	}
+	if owners["pwm-0"] != "jack:lights" || owners["digital-output-0"] != "jack:lights" || owners["pwm-2"] != "outlet:stepper" {
+		t.Error("unexpected pin owners", owners)
+	}
+
+	tr := utils.NewTestRouter()
+	pins.LoadAPI(tr.Router)
+	var rpi []PinAssignment
+	if err := tr.Do("GET", "/api/drivers/rpi/pins", strings.NewReader("{}"), &rpi); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, a := range rpi {
+		if a.Pin == 21 && a.Owner != nil && a.Owner.Type == OutletPin && a.Owner.ID == "1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected gpio 21 to be owned by outlet 1")
+	}
+	if err := tr.Do("GET", "/api/drivers/99/pins", strings.NewReader("{}"), nil); err == nil {
+		t.Error("expected error for unknown driver")
+	}
+}
@@ -0,0 +1,99 @@
+package connectors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+func TestVirtualDriverConnectors(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	drvrs := drivers.TestDrivers(store)
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := drvrs.List()
+	if err != nil || len(ds) != 1 {
+		t.Fatal("Expected virtual driver to be created. Error:", err)
+	}
+	id := ds[0].ID
+	d, err := drvrs.DigitalOutputDriver(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := d.(*virtual.Driver)
+	start := time.Now()
+
+	outlets := NewOutlets(drvrs, store)
+	inlets := NewInlets(drvrs, store)
+	jacks := NewJacks(drvrs, store)
+	ais := NewAnalogInputs(drvrs, store)
+	for _, s := range []interface{ Setup() error }{outlets, inlets, jacks, ais} {
+		if err := s.Setup(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := outlets.Create(Outlet{Name: "heater", Pin: 3, Driver: id, Reverse: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Configure("1", true); err != nil {
+		t.Error(err)
+	}
+	if err := sim.Set(virtual.Input{Type: "power", Pin: 3, Value: 40}); err != nil {
+		t.Fatal(err)
+	}
+	if w, err := outlets.Power("1"); err != nil || w != 0 {
+		t.Error("Expected no power draw while the reversed outlet is low. Watts:", w, "Error:", err)
+	}
+	if err := jacks.Create(Jack{Name: "light", Pins: []int{0, 1}, Driver: id}); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.Control("1", PinValues{1: 60}); err != nil {
+		t.Error(err)
+	}
+	writes := sim.Writes(start)
+	if len(writes) != 2 {
+		t.Fatal("Expected two recorded writes, found:", writes)
+	}
+	if writes[0].Pin != 3 || writes[0].Value != 0 {
+		t.Error("Expected reversed outlet to write low:", writes[0])
+	}
+	if writes[1].Type != "pwm" || writes[1].Pin != 1 || writes[1].Value != 60 {
+		t.Error("Unexpected jack write:", writes[1])
+	}
+
+	if err := outlets.Configure("1", false); err != nil {
+		t.Error(err)
+	}
+	if w, err := outlets.Power("1"); err != nil || w != 40 {
+		t.Error("Expected outlet to draw 40 watts. Watts:", w, "Error:", err)
+	}
+
+	if err := inlets.Create(Inlet{Name: "float", Pin: 0, Driver: id}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Set(virtual.Input{Type: "digital-input", Pin: 0, Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := inlets.Read("1"); err != nil || v != 1 {
+		t.Error("Expected inlet to read high. Value:", v, "Error:", err)
+	}
+
+	if err := ais.Create(AnalogInput{Name: "probe", Pin: 1, Driver: id}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Set(virtual.Input{Type: "analog-input", Pin: 1, Value: 8.2}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := ais.Read("1"); err != nil || v != 8.2 {
+		t.Error("Expected analog input to read 8.2. Value:", v, "Error:", err)
+	}
+}
@@ -23,6 +23,7 @@ type DeviceManager struct {
 	outlets   *connectors.Outlets
 	inlets    *connectors.Inlets
 	ais       *connectors.AnalogInputs
+	pins      *connectors.PinRegistry
 	drivers   *drivers.Drivers
 	telemetry telemetry.Telemetry
 }
@@ -60,6 +61,7 @@ func New(s settings.Settings, store storage.Store, t telemetry.Telemetry) *Devic
 		outlets:   connectors.NewOutlets(drvrs, store),
 		inlets:    connectors.NewInlets(drvrs, store),
 		ais:       connectors.NewAnalogInputs(drvrs, store),
+		pins:      connectors.NewPinRegistry(drvrs, store),
 		telemetry: t,
 	}
 }
@@ -77,6 +79,7 @@ func (dm *DeviceManager) Setup() error {
 	if err := dm.ais.Setup(); err != nil {
 		return err
 	}
+	go dm.drivers.Supervise()
 	return nil
 }
 
@@ -94,6 +97,10 @@ func (dm *DeviceManager) Jacks() *connectors.Jacks {
 	return dm.jacks
 }
 
+func (dm *DeviceManager) Pins() *connectors.PinRegistry {
+	return dm.pins
+}
+
 func (dm *DeviceManager) Drivers() *drivers.Drivers {
 	return dm.drivers
 }
@@ -104,9 +111,12 @@ func (dm *DeviceManager) LoadAPI(r *mux.Router) {
 	dm.jacks.LoadAPI(r)
 	dm.ais.LoadAPI(r)
 	dm.drivers.LoadAPI(r)
+	dm.pins.LoadAPI(r)
 }
 
 func (dm *DeviceManager) Close() error {
+	dm.inlets.Stop()
+	dm.jacks.Stop()
 	if err := dm.drivers.Close(); err != nil {
 		return err
 	}
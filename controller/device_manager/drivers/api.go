@@ -2,11 +2,14 @@ package drivers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
 	"github.com/reef-pi/reef-pi/controller/utils"
 )
 
@@ -52,6 +55,54 @@ func (d *Drivers) LoadAPI(r *mux.Router) {
 	//        description: The recommended default value
 	r.HandleFunc("/api/drivers/options", d.listOptions).Methods("GET")
 
+	// swagger:operation GET /api/drivers/schemas Driver driverSchemas
+	// List driver configuration schemas.
+	// List parameter names, types, defaults, ranges and descriptions of all driver types.
+	// ---
+	// responses:
+	//  200:
+	//   description: Map of driver types and their schema
+	//   schema:
+	//    type: object
+	//    additionalProperties:
+	//     $ref: '#/definitions/driverSchema'
+	r.HandleFunc("/api/drivers/schemas", d.schemas).Methods("GET")
+
+	// swagger:operation GET /api/drivers/schemas/{type} Driver driverSchema
+	// Get a driver configuration schema.
+	// Get parameter names, types, defaults, ranges and descriptions of a driver type.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: type
+	//    description: The driver type
+	//    required: true
+	//    schema:
+	//     type: string
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    $ref: '#/definitions/driverSchema'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/drivers/schemas/{type}", d.schema).Methods("GET")
+
+	// swagger:operation GET /api/drivers/i2c/scan Driver driverI2CScan
+	// Scan the i2c bus.
+	// List devices responding on the i2c bus with suggested driver entries. A simulated bus is scanned in dev mode.
+	// ---
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    type: array
+	//    items:
+	//     $ref: '#/definitions/i2cDevice'
+	//  500:
+	//   description: Scan failed
+	r.HandleFunc("/api/drivers/i2c/scan", d.scanI2C).Methods("GET")
+
 	// swagger:operation GET /api/drivers/{id} Driver driverGet
 	// Get a driver by id.
 	// Get an existing driver.
@@ -145,16 +196,68 @@ func (d *Drivers) LoadAPI(r *mux.Router) {
 	// 400:
 	//  description: Not Valid
 	r.HandleFunc("/api/drivers/validate", d.validate).Methods("POST")
+
+	// swagger:operation POST /api/drivers/{id}/virtual/inputs Driver driverVirtualInput
+	// Set a virtual driver input.
+	// Set the value of a digital or analog input of a virtual driver, or script it with a waveform.
+	//---
+	//parameters:
+	// - in: path
+	//   name: id
+	//   description: The Id of the virtual driver
+	//   required: true
+	//   schema:
+	//    type: integer
+	// - in: body
+	//   name: input
+	//   description: The input value or waveform
+	//   required: true
+	//   schema:
+	//    $ref: '#/definitions/virtualInput'
+	//responses:
+	// 200:
+	//  description: OK
+	// 500:
+	//  description: Not a virtual driver or invalid input
+	r.HandleFunc("/api/drivers/{id}/virtual/inputs", d.setVirtualInput).Methods("POST")
+
+	// swagger:operation GET /api/drivers/{id}/virtual/writes Driver driverVirtualWrites
+	// List virtual driver output writes.
+	// List digital output and pwm writes recorded by a virtual driver.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the virtual driver
+	//    required: true
+	//    schema:
+	//     type: integer
+	//  - in: query
+	//    name: since
+	//    description: Only list writes after this time (RFC3339)
+	//    type: string
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    type: array
+	//    items:
+	//     $ref: '#/definitions/virtualWrite'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/drivers/{id}/virtual/writes", d.virtualWrites).Methods("GET")
 }
 
 func (d *Drivers) list(w http.ResponseWriter, r *http.Request) {
 	fn := func() (interface{}, error) {
 		ds, err := d.List()
 		if err == nil {
-			dr, ok := d.drivers[_rpi]
+			dr, ok := d.driver(_rpi)
 			if ok {
-				piDriver.loadPinMap(dr)
-				ds = append(ds, piDriver)
+				pi := piDriver
+				pi.loadPinMap(dr)
+				pi.State, pi.LastError = d.State(_rpi)
+				ds = append(ds, pi)
 			}
 		}
 		return ds, err
@@ -170,6 +273,29 @@ func (d *Drivers) listOptions(w http.ResponseWriter, r *http.Request) {
 	utils.JSONListResponse(fn, w, r)
 }
 
+func (d *Drivers) schemas(w http.ResponseWriter, r *http.Request) {
+	fn := func() (interface{}, error) {
+		return DriverSchemas()
+	}
+	utils.JSONListResponse(fn, w, r)
+}
+
+func (d *Drivers) schema(w http.ResponseWriter, r *http.Request) {
+	s, err := DriverSchema(mux.Vars(r)["type"])
+	if err != nil {
+		utils.ErrorResponse(http.StatusNotFound, err.Error(), w)
+		return
+	}
+	utils.JSONResponse(s, w, r)
+}
+
+func (d *Drivers) scanI2C(w http.ResponseWriter, r *http.Request) {
+	fn := func() (interface{}, error) {
+		return d.ScanI2C()
+	}
+	utils.JSONListResponse(fn, w, r)
+}
+
 func (d *Drivers) validate(w http.ResponseWriter, r *http.Request) {
 	var d1 Driver
 
@@ -209,6 +335,7 @@ func (d *Drivers) get(w http.ResponseWriter, r *http.Request) {
 		if err := d.store.Get(DriverBucket, id, &dr); err != nil {
 			return nil, err
 		}
+		dr.State, dr.LastError = d.State(id)
 		return dr, nil
 	}
 	utils.JSONGetResponse(fn, w, r)
@@ -236,3 +363,44 @@ func (d *Drivers) delete(w http.ResponseWriter, r *http.Request) {
 	}
 	utils.JSONDeleteResponse(fn, w, r)
 }
+
+func (d *Drivers) virtual(id string) (*virtual.Driver, error) {
+	driver, ok := d.driver(id)
+	if !ok {
+		return nil, fmt.Errorf("driver by id %s not available", id)
+	}
+	v, ok := driver.(*virtual.Driver)
+	if !ok {
+		return nil, fmt.Errorf("driver %s is not a virtual driver", driver.Metadata().Name)
+	}
+	return v, nil
+}
+
+func (d *Drivers) setVirtualInput(w http.ResponseWriter, r *http.Request) {
+	var i virtual.Input
+	fn := func(id string) error {
+		v, err := d.virtual(id)
+		if err != nil {
+			return err
+		}
+		return v.Set(i)
+	}
+	utils.JSONUpdateResponse(&i, fn, w, r)
+}
+
+func (d *Drivers) virtualWrites(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		v, err := d.virtual(id)
+		if err != nil {
+			return nil, err
+		}
+		var since time.Time
+		if s := r.URL.Query().Get("since"); s != "" {
+			if since, err = time.Parse(time.RFC3339, s); err != nil {
+				return nil, err
+			}
+		}
+		return v.Writes(since), nil
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
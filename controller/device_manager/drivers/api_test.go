@@ -60,6 +60,18 @@ func TestDrivers_API(t *testing.T) {
 	json.NewEncoder(body).Encode(&Driver{
 		Type: "rpi",
 	})
+	if err := tr.Do("POST", "/api/drivers/1", body, nil); err == nil {
+		t.Error("Expected driver update with invalid parameters to fail")
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(&Driver{
+		Name: "bar",
+		Type: "pca9685",
+		Parameters: map[string]interface{}{
+			"Address":   0x40,
+			"Frequency": 1000,
+		},
+	})
 	if err := tr.Do("POST", "/api/drivers/1", body, nil); err != nil {
 		t.Error("Failed to update driver using api. Error:", err)
 	}
@@ -107,3 +119,41 @@ func TestDrivers_API(t *testing.T) {
 		t.Error("Failed to list options")
 	}
 }
+
+func TestVirtualDriverAPI(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	d := TestDrivers(store)
+	if err := d.Create(Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	tr := utils.NewTestRouter()
+	d.LoadAPI(tr.Router)
+	body := bytes.NewBufferString(`{"type":"digital-input","pin":1,"value":1}`)
+	if err := tr.Do("POST", "/api/drivers/1/virtual/inputs", body, nil); err != nil {
+		t.Error("Failed to set virtual input using api. Error:", err)
+	}
+	body = bytes.NewBufferString(`{"type":"pwm","pin":1,"value":1}`)
+	if err := tr.Do("POST", "/api/drivers/1/virtual/inputs", body, nil); err == nil {
+		t.Error("Expected setting a pwm channel to fail")
+	}
+	if err := tr.Do("POST", "/api/drivers/rpi/virtual/inputs", bytes.NewBufferString(`{}`), nil); err == nil {
+		t.Error("Expected non virtual driver to fail")
+	}
+	o, err := d.DigitalOutputDriver("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pin, _ := o.DigitalOutputPin(0)
+	pin.Write(true)
+	var writes []map[string]interface{}
+	if err := tr.Do("GET", "/api/drivers/1/virtual/writes", new(bytes.Buffer), &writes); err != nil {
+		t.Error("Failed to list virtual writes using api. Error:", err)
+	}
+	if len(writes) != 1 {
+		t.Error("Expected one recorded write, found:", len(writes))
+	}
+}
@@ -0,0 +1,204 @@
+package drivers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/reef-pi/hal"
+	"github.com/reef-pi/rpi/i2c"
+)
+
+// 7 bit addresses outside the reserved ranges
+const (
+	i2cFirstAddress = 0x03
+	i2cLastAddress  = 0x77
+)
+
+// I2CDevice is a device that responded on the i2c bus, with the drivers that could handle it
+//
+// swagger:model i2cDevice
+type I2CDevice struct {
+	Address int    `json:"address"`
+	Hex     string `json:"hex"`
+	// Suggestions are driver entries ready to be created, most likely first
+	Suggestions []Driver `json:"suggestions"`
+	// Existing lists the ids of configured drivers already using this address
+	Existing []string `json:"existing,omitempty"`
+}
+
+type i2cSignature struct {
+	driver    string
+	addresses []byte
+}
+
+func addressRange(first, last byte) []byte {
+	var addrs []byte
+	for a := first; a <= last; a++ {
+		addrs = append(addrs, a)
+	}
+	return addrs
+}
+
+// i2cSignatures lists the addresses each i2c driver type can be strapped to
+var i2cSignatures = []i2cSignature{
+	{driver: "pca9685", addresses: addressRange(0x40, 0x77)},
+	{driver: "ads1115", addresses: addressRange(0x48, 0x4B)},
+	{driver: "ads1015", addresses: addressRange(0x48, 0x4B)},
+	{driver: "sht31d", addresses: []byte{0x44, 0x45}},
+	{driver: "ph-board", addresses: append([]byte{0x45}, addressRange(0x48, 0x4B)...)},
+	{driver: "pico-board", addresses: []byte{0x45}},
+	{driver: "ph-ezo", addresses: []byte{0x44, 0x63}},
+}
+
+// simulatedBus answers on the default addresses of common reef-pi boards, so discovery can be tried in dev mode
+type simulatedBus struct {
+	devices map[byte]bool
+}
+
+// SimulatedI2CBus returns a bus with a pca9685, sht31d, ph-board, ads1115 and a pH EZO circuit attached
+func SimulatedI2CBus() i2c.Bus {
+	return &simulatedBus{devices: map[byte]bool{0x40: true, 0x44: true, 0x45: true, 0x48: true, 0x63: true}}
+}
+
+func (b *simulatedBus) probe(addr byte) error {
+	if !b.devices[addr] {
+		return fmt.Errorf("no device at address 0x%02x", addr)
+	}
+	return nil
+}
+
+func (b *simulatedBus) SetAddress(addr byte) error { return b.probe(addr) }
+func (b *simulatedBus) ReadBytes(addr byte, num int) ([]byte, error) {
+	return make([]byte, num), b.probe(addr)
+}
+func (b *simulatedBus) WriteBytes(addr byte, _ []byte) error     { return b.probe(addr) }
+func (b *simulatedBus) ReadFromReg(addr, _ byte, _ []byte) error { return b.probe(addr) }
+func (b *simulatedBus) WriteToReg(addr, _ byte, _ []byte) error  { return b.probe(addr) }
+func (b *simulatedBus) Close() error                             { return nil }
+
+// ScanI2C probes every address on the bus with a single byte read and suggests drivers for responding devices
+func ScanI2C(bus i2c.Bus) ([]I2CDevice, error) {
+	devices := []I2CDevice{}
+	for a := i2cFirstAddress; a <= i2cLastAddress; a++ {
+		addr := byte(a)
+		if _, err := bus.ReadBytes(addr, 1); err != nil {
+			continue
+		}
+		suggestions, err := suggest(addr)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, I2CDevice{
+			Address:     a,
+			Hex:         fmt.Sprintf("0x%02x", a),
+			Suggestions: suggestions,
+		})
+	}
+	return devices, nil
+}
+
+func suggest(addr byte) ([]Driver, error) {
+	type candidate struct {
+		d1        Driver
+		isDefault bool
+		choices   int
+	}
+	var candidates []candidate
+	for _, sig := range i2cSignatures {
+		if bytes.IndexByte(sig.addresses, addr) < 0 {
+			continue
+		}
+		s, err := DriverSchema(sig.driver)
+		if err != nil {
+			return nil, err
+		}
+		params := make(map[string]interface{})
+		isDefault := false
+		for _, p := range s.Parameters {
+			params[p.Name] = p.Default
+			if p.Name == "Address" {
+				if v, ok := hal.ConvertToInt(p.Default); ok && v == int(addr) {
+					isDefault = true
+				}
+				params[p.Name] = int(addr)
+			}
+		}
+		config, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate{
+			d1: Driver{
+				Name:   fmt.Sprintf("%s-0x%02x", sig.driver, addr),
+				Type:   sig.driver,
+				Config: config,
+			},
+			isDefault: isDefault,
+			choices:   len(sig.addresses),
+		})
+	}
+	// boards found on their factory default address are the most likely match, followed
+	// by drivers that can only be strapped to a few addresses
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if ci.isDefault != cj.isDefault {
+			return ci.isDefault
+		}
+		return !ci.isDefault && ci.choices < cj.choices
+	})
+	suggestions := []Driver{}
+	for _, c := range candidates {
+		suggestions = append(suggestions, c.d1)
+	}
+	return suggestions, nil
+}
+
+// ScanI2C scans the shared i2c bus, or a simulated bus in dev mode, and marks addresses
+// already used by configured drivers
+func (d *Drivers) ScanI2C() ([]I2CDevice, error) {
+	bus := d.bus
+	if d.devMode {
+		bus = SimulatedI2CBus()
+	}
+	devices, err := ScanI2C(bus)
+	if err != nil {
+		return nil, err
+	}
+	ds, err := d.List()
+	if err != nil {
+		return nil, err
+	}
+	for i, dev := range devices {
+		for _, d1 := range ds {
+			if !usesI2CAddress(d1, dev.Address) {
+				continue
+			}
+			devices[i].Existing = append(devices[i].Existing, d1.ID)
+		}
+	}
+	return devices, nil
+}
+
+func usesI2CAddress(d1 Driver, addr int) bool {
+	isI2C := false
+	for _, sig := range i2cSignatures {
+		if sig.driver == d1.Type {
+			isI2C = true
+		}
+	}
+	if !isI2C {
+		return false
+	}
+	params := d1.Parameters
+	if params == nil {
+		params = parseParams(d1.Config)
+	}
+	v, ok := lookupParam(params, "Address")
+	if !ok {
+		return false
+	}
+	a, ok := hal.ConvertToInt(v)
+	return ok && a == addr
+}
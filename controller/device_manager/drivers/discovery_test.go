@@ -0,0 +1,71 @@
+package drivers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestScanI2C(t *testing.T) {
+	devices, err := ScanI2C(SimulatedI2CBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 5 {
+		t.Fatal("expected 5 simulated devices, found", len(devices))
+	}
+	expected := map[string]string{
+		"0x40": "pca9685",
+		"0x44": "sht31d",
+		"0x45": "ph-board",
+		"0x48": "ads1115",
+		"0x63": "ph-ezo",
+	}
+	for _, dev := range devices {
+		if len(dev.Suggestions) == 0 {
+			t.Error("expected suggestions for", dev.Hex)
+			continue
+		}
+		if s := dev.Suggestions[0]; s.Type != expected[dev.Hex] {
+			t.Error("expected", expected[dev.Hex], "to be suggested first for", dev.Hex, "found", s.Type)
+		}
+		for _, s := range dev.Suggestions {
+			failures, err := Validate(s)
+			if err != nil || len(failures) > 0 {
+				t.Error("suggested driver", s.Name, "should be valid", failures, err)
+			}
+			if !usesI2CAddress(s, dev.Address) {
+				t.Error("suggested driver", s.Name, "should use address", dev.Hex)
+			}
+		}
+	}
+}
+
+func TestScanI2CAPI(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	d := TestDrivers(store)
+	if err := d.Create(Driver{Name: "pwm", Type: "pca9685", Config: []byte(`{"address":64,"frequency":150}`)}); err != nil {
+		t.Fatal(err)
+	}
+	tr := utils.NewTestRouter()
+	d.LoadAPI(tr.Router)
+	var devices []I2CDevice
+	if err := tr.Do("GET", "/api/drivers/i2c/scan", new(bytes.Buffer), &devices); err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) == 0 || devices[0].Hex != "0x40" {
+		t.Fatal("expected simulated pca9685 at 0x40", devices)
+	}
+	if len(devices[0].Existing) != 1 || devices[0].Existing[0] != "1" {
+		t.Error("expected existing pca9685 driver to be marked", devices[0].Existing)
+	}
+	if len(devices[1].Existing) != 0 {
+		t.Error("unexpected existing drivers for", devices[1].Hex, devices[1].Existing)
+	}
+}
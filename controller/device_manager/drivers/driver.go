@@ -21,6 +21,8 @@ type Driver struct {
 	Config     json.RawMessage        `json:"config"`
 	PinMap     map[string][]int       `json:"pinmap"`
 	Parameters map[string]interface{} `json:"parameters"`
+	State      string                 `json:"state,omitempty"`
+	LastError  string                 `json:"last_error,omitempty"`
 }
 
 func (dr *Driver) loadPinMap(d hal.Driver) {
@@ -38,3 +40,13 @@ func (dr *Driver) loadPinMap(d hal.Driver) {
 	}
 	dr.PinMap = pinmap
 }
+
+// Parameter returns a string configuration parameter of the driver, e.g. the address of a network device
+func (dr Driver) Parameter(name string) string {
+	params := dr.Parameters
+	if params == nil {
+		params = parseParams(dr.Config)
+	}
+	v, _ := params[name].(string)
+	return v
+}
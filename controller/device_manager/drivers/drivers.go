@@ -18,11 +18,15 @@ import (
 type Drivers struct {
 	sync.Mutex
 	drivers       map[string]hal.Driver
+	health        map[string]*driverHealth
+	stopCh        chan struct{}
+	closeOnce     sync.Once
 	store         storage.Store
 	pwm_freq      int
 	bus           i2c.Bus
 	t             telemetry.Telemetry
 	isRaspberryPi bool
+	devMode       bool
 }
 
 func NewDrivers(s settings.Settings, bus i2c.Bus, store storage.Store, t telemetry.Telemetry) (*Drivers, error) {
@@ -31,6 +35,8 @@ func NewDrivers(s settings.Settings, bus i2c.Bus, store storage.Store, t telemet
 	}
 	d := &Drivers{
 		drivers:  make(map[string]hal.Driver),
+		health:   make(map[string]*driverHealth),
+		stopCh:   make(chan struct{}),
 		store:    store,
 		pwm_freq: s.RPI_PWMFreq,
 		bus:      bus,
@@ -43,6 +49,7 @@ func NewDrivers(s settings.Settings, bus i2c.Bus, store storage.Store, t telemet
 	}
 	if s.Capabilities.DevMode {
 		d.isRaspberryPi = true
+		d.devMode = true
 	}
 	return d, d.loadAll()
 }
@@ -56,12 +63,29 @@ func isPiArch(a string) bool {
 	return false
 }
 
+func (d *Drivers) driver(id string) (hal.Driver, bool) {
+	d.Lock()
+	defer d.Unlock()
+	driver, ok := d.drivers[id]
+	return driver, ok
+}
+
+// HalDriver returns the loaded hal driver with the given id
+func (d *Drivers) HalDriver(id string) (hal.Driver, error) {
+	driver, ok := d.driver(id)
+	if !ok {
+		return nil, fmt.Errorf("driver by id %s not available", id)
+	}
+	return driver, nil
+}
+
 func (d *Drivers) Get(id string) (Driver, error) {
 	var dr Driver
 	if err := d.store.Get(DriverBucket, id, &dr); err != nil {
 		return dr, err
 	}
-	driver, ok := d.drivers[id]
+	dr.State, dr.LastError = d.State(id)
+	driver, ok := d.driver(id)
 	if !ok {
 		return dr, fmt.Errorf("driver by id %s not available", id)
 	}
@@ -70,7 +94,7 @@ func (d *Drivers) Get(id string) (Driver, error) {
 }
 
 func (d *Drivers) DigitalInputDriver(id string) (hal.DigitalInputDriver, error) {
-	driver, ok := d.drivers[id]
+	driver, ok := d.driver(id)
 	if !ok {
 		return nil, fmt.Errorf("driver by id %s not available", id)
 	}
@@ -82,7 +106,7 @@ func (d *Drivers) DigitalInputDriver(id string) (hal.DigitalInputDriver, error)
 }
 
 func (d *Drivers) DigitalOutputDriver(id string) (hal.DigitalOutputDriver, error) {
-	driver, ok := d.drivers[id]
+	driver, ok := d.driver(id)
 	if !ok {
 		return nil, fmt.Errorf("driver by id %s not available", id)
 	}
@@ -94,7 +118,7 @@ func (d *Drivers) DigitalOutputDriver(id string) (hal.DigitalOutputDriver, error
 }
 
 func (d *Drivers) PWMDriver(id string) (hal.PWMDriver, error) {
-	driver, ok := d.drivers[id]
+	driver, ok := d.driver(id)
 	if !ok {
 		return nil, fmt.Errorf("driver by id %s not available", id)
 	}
@@ -106,7 +130,7 @@ func (d *Drivers) PWMDriver(id string) (hal.PWMDriver, error) {
 }
 
 func (d *Drivers) AnalogInputDriver(id string) (hal.AnalogInputDriver, error) {
-	driver, ok := d.drivers[id]
+	driver, ok := d.driver(id)
 	if !ok {
 		return nil, fmt.Errorf("driver by id %s not available", id)
 	}
@@ -117,6 +141,17 @@ func (d *Drivers) AnalogInputDriver(id string) (hal.AnalogInputDriver, error) {
 	return p, nil
 }
 
+// ReportError records an I/O failure of the driver with the given id. Repeated failures make
+// the supervisor probe the driver and re-initialize it if it does not respond
+func (d *Drivers) ReportError(id string) {
+	d.t.DriverError(id)
+	d.Lock()
+	if h, ok := d.health[id]; ok {
+		h.errors++
+	}
+	d.Unlock()
+}
+
 func parseParams(data json.RawMessage) map[string]interface{} {
 	var objmap map[string]interface{}
 	json.Unmarshal(data, &objmap)
@@ -145,7 +180,13 @@ func (d *Drivers) Create(d1 Driver) error {
 	if d1.Parameters == nil {
 		d1.Parameters = parseParams(d1.Config)
 	}
-	factory.ValidateParameters(d1.Parameters)
+	failures, err := Validate(d1)
+	if err != nil {
+		return err
+	}
+	if err := FailureError(failures); err != nil {
+		return err
+	}
 
 	if err := d.store.Create(DriverBucket, fn); err != nil {
 		return err
@@ -156,6 +197,7 @@ func (d *Drivers) Create(d1 Driver) error {
 		_ = d.store.Delete(DriverBucket, d1.ID)
 		return err
 	}
+	d.markUp(d1.ID, d1.Name)
 	return nil
 }
 
@@ -163,6 +205,13 @@ func (d *Drivers) Update(id string, d1 Driver) error {
 	if id == _rpi {
 		return fmt.Errorf("rpi driver is readonly")
 	}
+	failures, err := Validate(d1)
+	if err != nil {
+		return err
+	}
+	if err := FailureError(failures); err != nil {
+		return err
+	}
 
 	d1.ID = id
 	return d.store.Update(DriverBucket, id, d1)
@@ -172,10 +221,15 @@ func (d *Drivers) Delete(id string) error {
 	if id == _rpi {
 		return fmt.Errorf("rpi driver is readonly")
 	}
+	d.Lock()
 	driver, ok := d.drivers[id]
 	if ok {
 		driver.Close()
+		delete(d.drivers, id)
 	}
+	delete(d.health, id)
+	d.Unlock()
+	d.t.DeleteEntityMetrics("drivers", id)
 	return d.store.Delete(DriverBucket, id)
 }
 
@@ -189,7 +243,8 @@ func (d *Drivers) List() ([]Driver, error) {
 		if err := json.Unmarshal(v, &d1); err != nil {
 			return err
 		}
-		dr, ok := d.drivers[d1.ID]
+		d1.State, d1.LastError = d.State(d1.ID)
+		dr, ok := d.driver(d1.ID)
 		if ok {
 			d1.loadPinMap(dr)
 		}
@@ -211,19 +266,13 @@ func (d *Drivers) ListOptions() (map[string][]hal.ConfigParameter, error) {
 }
 
 func (d *Drivers) ValidateParameters(d1 Driver) (map[string][]string, error) {
-	factory, err := AbstractFactory(d1.Type)
-	if err != nil {
-		return nil, err
-	}
-	if d1.Parameters == nil {
-		d1.Parameters = parseParams(d1.Config)
-	}
-
-	_, failures := factory.ValidateParameters(d1.Parameters)
-	return failures, nil
+	return Validate(d1)
 }
 
 func (d *Drivers) Close() error {
+	d.closeOnce.Do(func() { close(d.stopCh) })
+	d.Lock()
+	defer d.Unlock()
 	for _, d1 := range d.drivers {
 		if err := d1.Close(); err != nil {
 			log.Println("device-manager: Failed to close driver. Error:", err)
@@ -19,6 +19,11 @@ import (
 	"github.com/reef-pi/drivers/tplink"
 	"github.com/reef-pi/hal"
 	rpihal "github.com/reef-pi/rpi/hal"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/httpdriver"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/modbus"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/mqtt"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
 )
 
 var driversMap = map[string]hal.DriverFactory{
@@ -32,6 +37,9 @@ var driversMap = map[string]hal.DriverFactory{
 	"hs110":        tplink.HS110Factory(),
 	"hs300":        tplink.HS300Factory(),
 	"hs303":        tplink.HS303Factory(),
+	"http":         httpdriver.Factory(),
+	"modbus":       modbus.Factory(),
+	"mqtt":         mqtt.Factory(),
 	"pca9685":      pca9685.Factory(),
 	"ph-board":     ph_board.Factory(),
 	"ph-ezo":       ezo.Factory(),
@@ -41,6 +49,7 @@ var driversMap = map[string]hal.DriverFactory{
 	"shelly2.5":    shelly.Shelly25Adapter(false),
 	"sht31d":       sht3x.Factory(),
 	"tasmota-http": tasmota.HttpDriverFactory(),
+	"virtual":      virtual.Factory(),
 }
 
 func AbstractFactory(t string) (hal.DriverFactory, error) {
@@ -75,6 +84,9 @@ func (d *Drivers) loadAll() error {
 		if err := d.loadRpi(); err != nil {
 			d.t.LogError("driver-subsystem", "Failed load raspberry pi driver. Error:"+err.Error())
 			log.Println("driver-subsystem: Failed load raspberry pi driver. Error:", err.Error())
+			d.markDown(_rpi, piDriver.Name, err)
+		} else {
+			d.markUp(_rpi, piDriver.Name)
 		}
 	}
 
@@ -93,29 +105,33 @@ func (d *Drivers) loadAll() error {
 		}
 		if err := d.register(d1, f); err != nil {
 			log.Println("ERROR: Failed to initialize driver: ", d1.Name, " Error:", err)
-			d.t.LogError("driver-subsystem", "failed to initialize driver: "+d1.Name+" Error: "+err.Error())
+			d.markDown(d1.ID, d1.Name, err)
+			continue
 		}
+		d.markUp(d1.ID, d1.Name)
 	}
 	return nil
 }
 
 func (d *Drivers) register(d1 Driver, f hal.DriverFactory) error {
-	d.Lock()
-	defer d.Unlock()
-
 	if d1.Parameters == nil {
 		d1.Parameters = parseParams(d1.Config)
 	}
 
+	// building a driver may block on hardware or network, do it without holding the lock
 	r, err := f.NewDriver(d1.Parameters, d.bus)
 	if err != nil {
 		return err
 	}
 	meta := r.Metadata()
 	if d1.ID == "" {
+		r.Close()
 		return fmt.Errorf("Empty id, Name:%s", meta.Name)
 	}
+	d.Lock()
+	defer d.Unlock()
 	if alt, ok := d.drivers[d1.ID]; ok {
+		r.Close()
 		return fmt.Errorf("driver id already taken by %s", alt.Metadata().Name)
 	}
 	log.Println("driver-subsystem: registering driver id:", d1.ID, "Name:", d1.Name)
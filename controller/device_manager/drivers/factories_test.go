@@ -24,6 +24,10 @@ func TestFactory(t *testing.T) {
 		"hs110",
 		"hs300",
 		"tasmota-http",
+		"virtual",
+		"modbus",
+		"mqtt",
+		"http",
 	}
 	for _, p := range providers {
 		_, err := AbstractFactory(p)
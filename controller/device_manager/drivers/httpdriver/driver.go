@@ -0,0 +1,294 @@
+package httpdriver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/reef-pi/hal"
+)
+
+const maxBody = 1 << 20
+
+type driver struct {
+	meta    hal.Metadata
+	outputs []hal.DigitalOutputPin
+	inputs  []*input
+	analogs []*analog
+}
+
+// pin issues the templated request of a pin specification
+type pin struct {
+	client *http.Client
+	number int
+	s      spec
+}
+
+type output struct {
+	sync.Mutex
+	pin
+	lastState bool
+}
+
+// stateOutput is an output whose actual state can be read back with a separate request
+type stateOutput struct {
+	*output
+	state *input
+}
+
+type input struct {
+	pin
+}
+
+type analog struct {
+	sync.Mutex
+	pin
+	calibrator hal.Calibrator
+}
+
+func newDriver(meta hal.Metadata, c config, client *http.Client) (*driver, error) {
+	d := &driver{meta: meta}
+	for i, s := range c.outputs {
+		o := &output{pin: pin{client: client, number: i, s: s}}
+		if s.state == nil {
+			d.outputs = append(d.outputs, o)
+			continue
+		}
+		d.outputs = append(d.outputs, &stateOutput{output: o, state: &input{pin: pin{client: client, number: i, s: *s.state}}})
+	}
+	for i, s := range c.inputs {
+		d.inputs = append(d.inputs, &input{pin: pin{client: client, number: i, s: s}})
+	}
+	for i, s := range c.analogs {
+		cal, err := hal.CalibratorFactory([]hal.Measurement{})
+		if err != nil {
+			return nil, err
+		}
+		d.analogs = append(d.analogs, &analog{pin: pin{client: client, number: i, s: s}, calibrator: cal})
+	}
+	return d, nil
+}
+
+func (d *driver) Metadata() hal.Metadata {
+	return d.meta
+}
+
+func (d *driver) Close() error {
+	return nil
+}
+
+// Ping reads the first input or analog input to verify the device is reachable
+func (d *driver) Ping() error {
+	switch {
+	case len(d.inputs) > 0:
+		_, err := d.inputs[0].Read()
+		return err
+	case len(d.analogs) > 0:
+		_, err := d.analogs[0].Value()
+		return err
+	}
+	return nil
+}
+
+func (d *driver) Pins(cap hal.Capability) ([]hal.Pin, error) {
+	var pins []hal.Pin
+	switch cap {
+	case hal.DigitalOutput:
+		for _, p := range d.DigitalOutputPins() {
+			pins = append(pins, p)
+		}
+	case hal.DigitalInput:
+		for _, p := range d.DigitalInputPins() {
+			pins = append(pins, p)
+		}
+	case hal.AnalogInput:
+		for _, p := range d.AnalogInputPins() {
+			pins = append(pins, p)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported capability:%s", cap.String())
+	}
+	return pins, nil
+}
+
+func (d *driver) DigitalOutputPins() []hal.DigitalOutputPin {
+	return d.outputs
+}
+
+func (d *driver) DigitalOutputPin(n int) (hal.DigitalOutputPin, error) {
+	if n < 0 || n >= len(d.outputs) {
+		return nil, fmt.Errorf("output %d is not configured", n)
+	}
+	return d.outputs[n], nil
+}
+
+func (d *driver) DigitalInputPins() []hal.DigitalInputPin {
+	var pins []hal.DigitalInputPin
+	for _, in := range d.inputs {
+		pins = append(pins, in)
+	}
+	return pins
+}
+
+func (d *driver) DigitalInputPin(n int) (hal.DigitalInputPin, error) {
+	if n < 0 || n >= len(d.inputs) {
+		return nil, fmt.Errorf("input %d is not configured", n)
+	}
+	return d.inputs[n], nil
+}
+
+func (d *driver) AnalogInputPins() []hal.AnalogInputPin {
+	var pins []hal.AnalogInputPin
+	for _, a := range d.analogs {
+		pins = append(pins, a)
+	}
+	return pins
+}
+
+func (d *driver) AnalogInputPin(n int) (hal.AnalogInputPin, error) {
+	if n < 0 || n >= len(d.analogs) {
+		return nil, fmt.Errorf("analog input %d is not configured", n)
+	}
+	return d.analogs[n], nil
+}
+
+func (p *pin) Name() string {
+	if p.s.Name != "" {
+		return p.s.Name
+	}
+	return fmt.Sprintf("pin-%d", p.number)
+}
+
+func (p *pin) Number() int {
+	return p.number
+}
+
+func (p *pin) Close() error {
+	return nil
+}
+
+// do renders the request templates, executes the request and returns the response body
+func (p *pin) do(state bool) ([]byte, error) {
+	data := TemplateData{Number: p.number, Name: p.Name(), State: state}
+	var url, body bytes.Buffer
+	if err := p.s.url.Execute(&url, data); err != nil {
+		return nil, err
+	}
+	if err := p.s.body.Execute(&body, data); err != nil {
+		return nil, err
+	}
+	var reqBody io.Reader
+	if body.Len() > 0 {
+		reqBody = &body
+	}
+	req, err := http.NewRequest(p.s.Method, strings.TrimSpace(url.String()), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	for k, t := range p.s.headers {
+		var v bytes.Buffer
+		if err := t.Execute(&v, data); err != nil {
+			return nil, err
+		}
+		req.Header.Set(k, v.String())
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	msg, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("HTTP Code:%d. Body:%v", resp.StatusCode, string(msg))
+	}
+	return msg, nil
+}
+
+func (o *output) Write(state bool) error {
+	o.Lock()
+	defer o.Unlock()
+	if _, err := o.do(state); err != nil {
+		return err
+	}
+	o.lastState = state
+	return nil
+}
+
+func (o *output) LastState() bool {
+	o.Lock()
+	defer o.Unlock()
+	return o.lastState
+}
+
+// ReadState reads the actual output state using the state request
+func (o *stateOutput) ReadState() (bool, error) {
+	return o.state.Read()
+}
+
+func (in *input) Read() (bool, error) {
+	body, err := in.do(false)
+	if err != nil {
+		return false, err
+	}
+	v, err := in.s.extract(body)
+	if err != nil {
+		return false, err
+	}
+	if in.s.On != "" && strings.EqualFold(v, in.s.On) {
+		return true, nil
+	}
+	switch strings.ToLower(v) {
+	case "on", "true", "1":
+		return true, nil
+	case "off", "false", "0":
+		return false, nil
+	}
+	if in.s.On != "" {
+		return false, nil
+	}
+	return false, fmt.Errorf("unrecognized state '%s'", v)
+}
+
+// Value returns the number extracted from the response
+func (a *analog) Value() (float64, error) {
+	body, err := a.do(false)
+	if err != nil {
+		return 0, err
+	}
+	v, err := a.s.extract(body)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+func (a *analog) Measure() (float64, error) {
+	v, err := a.Value()
+	if err != nil {
+		return 0, err
+	}
+	a.Lock()
+	defer a.Unlock()
+	if a.calibrator == nil {
+		return 0, fmt.Errorf("Not calibrated")
+	}
+	return a.calibrator.Calibrate(v), nil
+}
+
+func (a *analog) Calibrate(points []hal.Measurement) error {
+	cal, err := hal.CalibratorFactory(points)
+	if err != nil {
+		return err
+	}
+	a.Lock()
+	a.calibrator = cal
+	a.Unlock()
+	return nil
+}
@@ -0,0 +1,200 @@
+package httpdriver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/reef-pi/hal"
+)
+
+// plug is a local stand-in for a REST controllable plug with a temperature sensor
+type plug struct {
+	sync.Mutex
+	on    bool
+	token string
+	body  string
+}
+
+func (p *plug) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.Lock()
+	defer p.Unlock()
+	switch r.URL.Path {
+	case "/relay":
+		if r.Header.Get("Authorization") != "Bearer "+p.token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		p.body = string(b)
+		p.on = r.URL.Query().Get("turn") == "on"
+	case "/status":
+		fmt.Fprintf(w, `{"relays":[{"ison":%t}],"meters":[{"power":12.5}]}`, p.on)
+	case "/metrics":
+		fmt.Fprint(w, "# HELP temp\ntemperature_celsius 25.75\n")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	body := []byte(`{"a":{"b c":[1,{"d":"x"}]},"e":true}`)
+	for path, expected := range map[string]string{
+		"$.a['b c'][1].d": "x",
+		"a[\"b c\"][0]":   "1",
+		"$.e":             "true",
+	} {
+		steps, err := parsePath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := lookup(body, steps)
+		if err != nil {
+			t.Error(path, err)
+		}
+		if v != expected {
+			t.Error(path, "expected", expected, "got", v)
+		}
+	}
+	for _, path := range []string{"$", "$.a[x]", "$.a[0"} {
+		if _, err := parsePath(path); err == nil {
+			t.Error("expected error for", path)
+		}
+	}
+	steps, _ := parsePath("$.a.missing")
+	if _, err := lookup(body, steps); err == nil {
+		t.Error("expected error for missing key")
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	f := Factory()
+	if ok, failures := f.ValidateParameters(map[string]interface{}{
+		"Outputs": `[{"url":"http://localhost/relay?turn={{if .State}}on{{else}}off{{end}}"}]`,
+	}); !ok {
+		t.Error(failures)
+	}
+	for _, params := range []map[string]interface{}{
+		{},
+		{"Outputs": `[{"url":""}]`},
+		{"Outputs": `[{"url":"http://localhost/{{.State"}]`},
+		{"Inputs": `[{"url":"http://localhost/status"}]`},
+		{"Inputs": `[{"url":"http://localhost/status","regex":"("}]`},
+		{"Outputs": `not json`},
+		{"Outputs": `[{"url":"http://localhost"}]`, "Timeout": 0},
+	} {
+		if ok, _ := f.ValidateParameters(params); ok {
+			t.Error("expected validation failure for", params)
+		}
+	}
+}
+
+func TestHTTPDriver(t *testing.T) {
+	p := &plug{token: "secret"}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	params := map[string]interface{}{
+		"Timeout": 1,
+		"Outputs": []interface{}{
+			map[string]interface{}{
+				"name":    "relay",
+				"method":  "post",
+				"url":     srv.URL + "/relay?turn={{if .State}}on{{else}}off{{end}}",
+				"headers": map[string]interface{}{"Authorization": "Bearer secret"},
+				"body":    `{"id":{{.Number}},"on":{{.State}}}`,
+				"state":   map[string]interface{}{"url": srv.URL + "/status", "json_path": "$.relays[0].ison"},
+			},
+		},
+		"Inputs":        `[{"url":"` + srv.URL + `/status","json_path":"$.relays[0].ison"}]`,
+		"Analog Inputs": `[{"url":"` + srv.URL + `/status","json_path":"$.meters[0].power"},{"url":"` + srv.URL + `/metrics","regex":"temperature_celsius ([0-9.]+)"}]`,
+	}
+	if ok, failures := Factory().ValidateParameters(params); !ok {
+		t.Fatal(failures)
+	}
+	hd, err := Factory().NewDriver(params, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := hd.(*driver)
+
+	out, err := d.DigitalOutputPin(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Name() != "relay" {
+		t.Error("unexpected pin name", out.Name())
+	}
+	if err := out.Write(true); err != nil {
+		t.Fatal(err)
+	}
+	if !p.on || !out.LastState() {
+		t.Error("expected relay to be on")
+	}
+	p.Lock()
+	p.on = false
+	p.Unlock()
+	if on, err := out.(*stateOutput).ReadState(); err != nil || on {
+		t.Error("expected relay to be read back as off", on, err)
+	}
+	p.Lock()
+	p.on = true
+	p.Unlock()
+	if p.body != `{"id":0,"on":true}` {
+		t.Error("unexpected request body", p.body)
+	}
+	in, err := d.DigitalInputPin(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := in.Read(); err != nil || !v {
+		t.Error("expected input to be on", v, err)
+	}
+	if err := out.Write(false); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := in.Read(); err != nil || v {
+		t.Error("expected input to be off", v, err)
+	}
+
+	power, err := d.AnalogInputPin(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := power.Value(); err != nil || v != 12.5 {
+		t.Error("expected 12.5", v, err)
+	}
+	temp, err := d.AnalogInputPin(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := temp.Value(); err != nil || v != 25.75 {
+		t.Error("expected 25.75", v, err)
+	}
+	if err := temp.Calibrate([]hal.Measurement{{Expected: 26.75, Observed: 25.75}}); err != nil {
+		t.Error(err)
+	}
+	if v, err := temp.Measure(); err != nil || v != 26.75 {
+		t.Error("expected calibrated value 26.75", v, err)
+	}
+	if err := d.Ping(); err != nil {
+		t.Error(err)
+	}
+
+	p.token = "rotated"
+	if err := out.Write(true); err == nil {
+		t.Error("expected error for unauthorized request")
+	}
+	if out.LastState() {
+		t.Error("failed write should not change last state")
+	}
+	if _, err := d.Pins(hal.PWM); err == nil {
+		t.Error("expected error for unsupported capability")
+	}
+	if err := d.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,108 @@
+package httpdriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathStep is either an object key or an array index
+type pathStep struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// parsePath parses the subset of JSONPath used to address a single value:
+// $.a.b[0]['c d']. The leading $ is optional
+func parsePath(p string) ([]pathStep, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(p), "$")
+	var steps []pathStep
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			continue
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid json path '%s': missing ]", p)
+			}
+			inner := s[1:end]
+			s = s[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, pathStep{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			i, err := strconv.Atoi(inner)
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid json path '%s': bad index '%s'", p, inner)
+			}
+			steps = append(steps, pathStep{index: i, isIdx: true})
+		default:
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			steps = append(steps, pathStep{key: s[:end]})
+			s = s[end:]
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("invalid json path '%s'", p)
+	}
+	return steps, nil
+}
+
+func lookup(body []byte, steps []pathStep) (string, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "", fmt.Errorf("invalid json response: %w", err)
+	}
+	for _, st := range steps {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			if st.isIdx {
+				return "", fmt.Errorf("cannot index object with [%d]", st.index)
+			}
+			val, ok := v[st.key]
+			if !ok {
+				return "", fmt.Errorf("key '%s' not found", st.key)
+			}
+			doc = val
+		case []interface{}:
+			if !st.isIdx || st.index >= len(v) {
+				return "", fmt.Errorf("invalid array access")
+			}
+			doc = v[st.index]
+		default:
+			return "", fmt.Errorf("cannot descend into %v", v)
+		}
+	}
+	switch v := doc.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("value is not a scalar")
+}
+
+// extract returns the value addressed by the pin's json path, or the first capture group
+// (whole match when the regex has no groups) of its regex
+func (s *spec) extract(body []byte) (string, error) {
+	if s.path != nil {
+		return lookup(body, s.path)
+	}
+	m := s.regex.FindSubmatch(body)
+	if m == nil {
+		return "", fmt.Errorf("regex '%s' did not match", s.Regex)
+	}
+	if len(m) > 1 {
+		return strings.TrimSpace(string(m[1])), nil
+	}
+	return strings.TrimSpace(string(m[0])), nil
+}
@@ -0,0 +1,232 @@
+package httpdriver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/reef-pi/hal"
+)
+
+const (
+	timeoutParam = "Timeout"
+	outputsParam = "Outputs"
+	inputsParam  = "Inputs"
+	analogParam  = "Analog Inputs"
+)
+
+type factory struct {
+	meta       hal.Metadata
+	parameters []hal.ConfigParameter
+}
+
+var httpFactory *factory
+var once sync.Once
+
+// Factory returns a singleton templated http driver factory
+func Factory() hal.DriverFactory {
+	once.Do(func() {
+		httpFactory = &factory{
+			meta: hal.Metadata{
+				Name:        "http",
+				Description: "REST controllable plugs and sensors. Every pin is a templated http request, inputs extract their value with a JSONPath or regex",
+				Capabilities: []hal.Capability{
+					hal.DigitalOutput, hal.DigitalInput, hal.AnalogInput,
+				},
+			},
+			parameters: []hal.ConfigParameter{
+				{Name: timeoutParam, Type: hal.Decimal, Order: 0, Default: 5.0},
+				// json arrays of pin specifications, see PinSpec
+				{Name: outputsParam, Type: hal.String, Order: 1, Default: `[{"name":"plug","method":"GET","url":"http://192.168.1.20/relay/0?turn={{if .State}}on{{else}}off{{end}}"}]`},
+				{Name: inputsParam, Type: hal.String, Order: 2, Default: "[]"},
+				{Name: analogParam, Type: hal.String, Order: 3, Default: "[]"},
+			},
+		}
+	})
+	return httpFactory
+}
+
+func (f *factory) Metadata() hal.Metadata {
+	return f.meta
+}
+
+func (f *factory) GetParameters() []hal.ConfigParameter {
+	return f.parameters
+}
+
+// PinSpec describes the http request backing a pin. URL, header values and body are
+// text/template templates executed with TemplateData. Input and analog input pins
+// extract their value from the response body using either JSONPath or Regex.
+type PinSpec struct {
+	Name     string            `json:"name"`
+	Method   string            `json:"method"`
+	URL      string            `json:"url"`
+	Headers  map[string]string `json:"headers"`
+	Body     string            `json:"body"`
+	JSONPath string            `json:"json_path"`
+	Regex    string            `json:"regex"`
+	// On is the extracted value treated as on by digital inputs, in addition to on/true/1
+	On string `json:"on"`
+	// State optionally reads back the actual state of a digital output
+	State *PinSpec `json:"state,omitempty"`
+}
+
+// TemplateData is available to request templates
+type TemplateData struct {
+	Number int
+	Name   string
+	// State is the requested state of a digital output
+	State bool
+}
+
+type spec struct {
+	PinSpec
+	url     *template.Template
+	body    *template.Template
+	headers map[string]*template.Template
+	path    []pathStep
+	regex   *regexp.Regexp
+	state   *spec
+}
+
+type config struct {
+	timeout time.Duration
+	outputs []spec
+	inputs  []spec
+	analogs []spec
+}
+
+func parseSpecs(v interface{}, extract bool) ([]spec, error) {
+	var raw []byte
+	switch val := v.(type) {
+	case string:
+		if strings.TrimSpace(val) == "" {
+			return nil, nil
+		}
+		raw = []byte(val)
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		raw = b
+	}
+	var pins []PinSpec
+	if err := json.Unmarshal(raw, &pins); err != nil {
+		return nil, fmt.Errorf("invalid pin specification: %w", err)
+	}
+	var specs []spec
+	for i, p := range pins {
+		s, err := compile(p, extract)
+		if err != nil {
+			return nil, fmt.Errorf("pin %d: %w", i, err)
+		}
+		specs = append(specs, s)
+	}
+	return specs, nil
+}
+
+func compile(p PinSpec, extract bool) (spec, error) {
+	s := spec{PinSpec: p, headers: make(map[string]*template.Template)}
+	if s.Method == "" {
+		s.Method = http.MethodGet
+	}
+	s.Method = strings.ToUpper(s.Method)
+	if s.URL == "" {
+		return s, errors.New("url is required")
+	}
+	var err error
+	if s.url, err = template.New("url").Parse(p.URL); err != nil {
+		return s, err
+	}
+	if s.body, err = template.New("body").Parse(p.Body); err != nil {
+		return s, err
+	}
+	for k, v := range p.Headers {
+		t, err := template.New(k).Parse(v)
+		if err != nil {
+			return s, err
+		}
+		s.headers[k] = t
+	}
+	if p.JSONPath != "" && p.Regex != "" {
+		return s, errors.New("only one of json_path and regex can be specified")
+	}
+	if p.JSONPath != "" {
+		if s.path, err = parsePath(p.JSONPath); err != nil {
+			return s, err
+		}
+	}
+	if p.Regex != "" {
+		if s.regex, err = regexp.Compile(p.Regex); err != nil {
+			return s, err
+		}
+	}
+	if extract && s.path == nil && s.regex == nil {
+		return s, errors.New("json_path or regex is required to read a value")
+	}
+	if p.State != nil {
+		st, err := compile(*p.State, true)
+		if err != nil {
+			return s, fmt.Errorf("state: %w", err)
+		}
+		s.state = &st
+	}
+	return s, nil
+}
+
+func (f *factory) parse(parameters map[string]interface{}) (config, map[string][]string) {
+	var failures = make(map[string][]string)
+	c := config{timeout: 5 * time.Second}
+	if v, ok := parameters[timeoutParam]; ok {
+		t, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+		if err != nil || t <= 0 {
+			failures[timeoutParam] = append(failures[timeoutParam], fmt.Sprint(timeoutParam, " should be a positive number of seconds. ", v, " was received."))
+		} else {
+			c.timeout = time.Duration(t * float64(time.Second))
+		}
+	}
+	for _, p := range []struct {
+		name    string
+		extract bool
+		specs   *[]spec
+	}{
+		{name: outputsParam, specs: &c.outputs},
+		{name: inputsParam, extract: true, specs: &c.inputs},
+		{name: analogParam, extract: true, specs: &c.analogs},
+	} {
+		v, ok := parameters[p.name]
+		if !ok {
+			continue
+		}
+		specs, err := parseSpecs(v, p.extract)
+		if err != nil {
+			failures[p.name] = append(failures[p.name], err.Error())
+		}
+		*p.specs = specs
+	}
+	if len(c.outputs)+len(c.inputs)+len(c.analogs) == 0 && len(failures) == 0 {
+		failures[outputsParam] = append(failures[outputsParam], "At least one output, input or analog input is required.")
+	}
+	return c, failures
+}
+
+func (f *factory) ValidateParameters(parameters map[string]interface{}) (bool, map[string][]string) {
+	_, failures := f.parse(parameters)
+	return len(failures) == 0, failures
+}
+
+func (f *factory) NewDriver(parameters map[string]interface{}, _ interface{}) (hal.Driver, error) {
+	c, failures := f.parse(parameters)
+	if len(failures) > 0 {
+		return nil, errors.New(hal.ToErrorString(failures))
+	}
+	return newDriver(f.meta, c, &http.Client{Timeout: c.timeout})
+}
@@ -0,0 +1,67 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/reef-pi/hal"
+
+	"github.com/reef-pi/reef-pi/controller/telemetry"
+)
+
+// Pinger is implemented by drivers that can verify the underlying hardware is reachable
+type Pinger interface {
+	Ping() error
+}
+
+// errNoProbe is returned by probe for drivers that do not implement Pinger
+var errNoProbe = errors.New("driver can not be probed")
+
+// Liveness pings every registered driver implementing Pinger. Drivers that return an error or do not
+// respond within timeout are reported as failed, drivers that can not be pinged are reported as unknown
+func (d *Drivers) Liveness(timeout time.Duration) []telemetry.DriverLiveness {
+	d.Lock()
+	registered := make(map[string]hal.Driver, len(d.drivers))
+	for id, dr := range d.drivers {
+		registered[id] = dr
+	}
+	d.Unlock()
+
+	var results []telemetry.DriverLiveness
+	for id, dr := range registered {
+		l := telemetry.DriverLiveness{ID: id, Name: id, Status: telemetry.HealthOK}
+		done := make(chan error, 1)
+		go func(dr hal.Driver) {
+			done <- probe(dr)
+		}(dr)
+		select {
+		case err := <-done:
+			l.Name = dr.Metadata().Name
+			switch {
+			case errors.Is(err, errNoProbe):
+				l.Status = telemetry.HealthUnknown
+			case err != nil:
+				l.Status = telemetry.HealthFailed
+				l.Error = err.Error()
+			}
+		case <-time.After(timeout):
+			l.Status = telemetry.HealthFailed
+			l.Error = fmt.Sprintf("no response within %s", timeout)
+		}
+		if l.Status == telemetry.HealthFailed {
+			d.ReportError(id)
+		}
+		results = append(results, l)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	return results
+}
+
+func probe(dr hal.Driver) error {
+	if p, ok := dr.(Pinger); ok {
+		return p.Ping()
+	}
+	return errNoProbe
+}
@@ -0,0 +1,25 @@
+package drivers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/telemetry"
+)
+
+func TestLiveness(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	d := TestDrivers(store)
+	l := d.Liveness(time.Second)
+	if len(l) != 1 {
+		t.Fatal("Expected liveness of rpi driver, found:", len(l))
+	}
+	if l[0].ID != "rpi" || l[0].Status != telemetry.HealthUnknown || l[0].Error != "" {
+		t.Error("Unexpected rpi liveness:", l[0])
+	}
+}
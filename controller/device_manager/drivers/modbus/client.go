@@ -0,0 +1,242 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// modbus function codes
+const (
+	readCoils            = 0x01
+	readDiscreteInputs   = 0x02
+	readHoldingRegisters = 0x03
+	readInputRegisters   = 0x04
+	writeSingleCoil      = 0x05
+)
+
+var exceptions = map[byte]string{
+	1: "illegal function",
+	2: "illegal data address",
+	3: "illegal data value",
+	4: "server device failure",
+	6: "server device busy",
+}
+
+// transport sends a request PDU (function code and data) to a unit and returns the response PDU
+type transport interface {
+	send(unit byte, pdu []byte) ([]byte, error)
+	Close() error
+}
+
+type client struct {
+	sync.Mutex
+	t    transport
+	unit byte
+}
+
+func (c *client) do(pdu []byte) ([]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+	resp, err := c.t.send(c.unit, pdu)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 2 {
+		return nil, fmt.Errorf("modbus: short response")
+	}
+	if resp[0] == pdu[0]|0x80 {
+		msg, ok := exceptions[resp[1]]
+		if !ok {
+			msg = fmt.Sprintf("code %d", resp[1])
+		}
+		return nil, fmt.Errorf("modbus: exception for function %d: %s", pdu[0], msg)
+	}
+	if resp[0] != pdu[0] {
+		return nil, fmt.Errorf("modbus: unexpected function code %d in response to %d", resp[0], pdu[0])
+	}
+	return resp, nil
+}
+
+func request(fc byte, addr, value uint16) []byte {
+	pdu := make([]byte, 5)
+	pdu[0] = fc
+	binary.BigEndian.PutUint16(pdu[1:], addr)
+	binary.BigEndian.PutUint16(pdu[3:], value)
+	return pdu
+}
+
+func (c *client) readBits(fc byte, addr, count uint16) ([]bool, error) {
+	resp, err := c.do(request(fc, addr, count))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 2 || int(resp[1]) != (int(count)+7)/8 || len(resp) != 2+int(resp[1]) {
+		return nil, fmt.Errorf("modbus: invalid response length")
+	}
+	bits := make([]bool, count)
+	for i := range bits {
+		bits[i] = resp[2+i/8]&(1<<uint(i%8)) != 0
+	}
+	return bits, nil
+}
+
+func (c *client) readRegisters(fc byte, addr, count uint16) ([]uint16, error) {
+	resp, err := c.do(request(fc, addr, count))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 2 || int(resp[1]) != 2*int(count) || len(resp) != 2+int(resp[1]) {
+		return nil, fmt.Errorf("modbus: invalid response length")
+	}
+	regs := make([]uint16, count)
+	for i := range regs {
+		regs[i] = binary.BigEndian.Uint16(resp[2+2*i:])
+	}
+	return regs, nil
+}
+
+func (c *client) writeCoil(addr uint16, on bool) error {
+	var v uint16
+	if on {
+		v = 0xFF00
+	}
+	_, err := c.do(request(writeSingleCoil, addr, v))
+	return err
+}
+
+func (c *client) Close() error {
+	c.Lock()
+	defer c.Unlock()
+	return c.t.Close()
+}
+
+// tcpTransport implements Modbus TCP (MBAP framing). The connection is re-established after failures
+type tcpTransport struct {
+	address string
+	timeout time.Duration
+	conn    net.Conn
+	tid     uint16
+}
+
+func (t *tcpTransport) send(unit byte, pdu []byte) ([]byte, error) {
+	if t.conn == nil {
+		conn, err := net.DialTimeout("tcp", t.address, t.timeout)
+		if err != nil {
+			return nil, err
+		}
+		t.conn = conn
+	}
+	resp, err := t.roundTrip(unit, pdu)
+	if err != nil {
+		t.conn.Close()
+		t.conn = nil
+	}
+	return resp, err
+}
+
+func (t *tcpTransport) roundTrip(unit byte, pdu []byte) ([]byte, error) {
+	t.tid++
+	frame := make([]byte, 7+len(pdu))
+	binary.BigEndian.PutUint16(frame[0:], t.tid)
+	binary.BigEndian.PutUint16(frame[4:], uint16(len(pdu)+1))
+	frame[6] = unit
+	copy(frame[7:], pdu)
+	if err := t.conn.SetDeadline(time.Now().Add(t.timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := t.conn.Write(frame); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(t.conn, header); err != nil {
+		return nil, err
+	}
+	if tid := binary.BigEndian.Uint16(header[0:]); tid != t.tid {
+		return nil, fmt.Errorf("modbus: transaction id mismatch, expected %d, received %d", t.tid, tid)
+	}
+	if protocol := binary.BigEndian.Uint16(header[2:]); protocol != 0 {
+		return nil, fmt.Errorf("modbus: unexpected protocol id %d", protocol)
+	}
+	if header[6] != unit {
+		return nil, fmt.Errorf("modbus: response from unexpected unit %d", header[6])
+	}
+	length := int(binary.BigEndian.Uint16(header[4:]))
+	if length < 2 || length > 254 {
+		return nil, fmt.Errorf("modbus: invalid frame length %d", length)
+	}
+	resp := make([]byte, length-1)
+	if _, err := io.ReadFull(t.conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *tcpTransport) Close() error {
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
+
+// rtuTransport implements Modbus RTU framing over a serial port
+type rtuTransport struct {
+	port io.ReadWriteCloser
+}
+
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+func (t *rtuTransport) send(unit byte, pdu []byte) ([]byte, error) {
+	frame := append([]byte{unit}, pdu...)
+	frame = binary.LittleEndian.AppendUint16(frame, crc16(frame))
+	if _, err := t.port.Write(frame); err != nil {
+		return nil, err
+	}
+	// unit, function code and either the byte count or first byte of an echoed address/exception code
+	head := make([]byte, 3)
+	if _, err := io.ReadFull(t.port, head); err != nil {
+		return nil, err
+	}
+	var rest int
+	switch {
+	case head[1]&0x80 != 0:
+		rest = 2
+	case head[1] == writeSingleCoil:
+		rest = 5
+	default:
+		rest = int(head[2]) + 2
+	}
+	tail := make([]byte, rest)
+	if _, err := io.ReadFull(t.port, tail); err != nil {
+		return nil, err
+	}
+	resp := append(head, tail...)
+	n := len(resp)
+	if crc16(resp[:n-2]) != binary.LittleEndian.Uint16(resp[n-2:]) {
+		return nil, fmt.Errorf("modbus: crc mismatch")
+	}
+	if resp[0] != unit {
+		return nil, fmt.Errorf("modbus: response from unexpected unit %d", resp[0])
+	}
+	return resp[1 : n-2], nil
+}
+
+func (t *rtuTransport) Close() error {
+	return t.port.Close()
+}
@@ -0,0 +1,260 @@
+package modbus
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/reef-pi/hal"
+)
+
+type driver struct {
+	meta      hal.Metadata
+	client    *client
+	coils     []*coil
+	discretes []*discrete
+	registers []*analog
+}
+
+type coil struct {
+	sync.Mutex
+	c         *client
+	address   int
+	lastState bool
+}
+
+type discrete struct {
+	c       *client
+	address int
+}
+
+type analog struct {
+	sync.Mutex
+	c          *client
+	number     int
+	reg        register
+	calibrator hal.Calibrator
+}
+
+func newDriver(meta hal.Metadata, c config, cl *client) (*driver, error) {
+	d := &driver{
+		meta:   meta,
+		client: cl,
+	}
+	for i := 0; i < c.coilCount; i++ {
+		d.coils = append(d.coils, &coil{c: cl, address: c.coilStart + i})
+	}
+	for i := 0; i < c.discreteCount; i++ {
+		d.discretes = append(d.discretes, &discrete{c: cl, address: c.discreteStart + i})
+	}
+	for i, r := range c.registers {
+		cal, err := hal.CalibratorFactory([]hal.Measurement{})
+		if err != nil {
+			return nil, err
+		}
+		d.registers = append(d.registers, &analog{c: cl, number: i, reg: r, calibrator: cal})
+	}
+	return d, nil
+}
+
+func (d *driver) Metadata() hal.Metadata {
+	return d.meta
+}
+
+func (d *driver) Close() error {
+	return d.client.Close()
+}
+
+// Ping reads the first configured coil, discrete input or register to verify the device is reachable
+func (d *driver) Ping() error {
+	switch {
+	case len(d.coils) > 0:
+		_, err := d.client.readBits(readCoils, uint16(d.coils[0].address), 1)
+		return err
+	case len(d.discretes) > 0:
+		_, err := d.discretes[0].Read()
+		return err
+	case len(d.registers) > 0:
+		_, err := d.registers[0].Value()
+		return err
+	}
+	return nil
+}
+
+func (d *driver) Pins(cap hal.Capability) ([]hal.Pin, error) {
+	var pins []hal.Pin
+	switch cap {
+	case hal.DigitalOutput:
+		for _, p := range d.DigitalOutputPins() {
+			pins = append(pins, p)
+		}
+	case hal.DigitalInput:
+		for _, p := range d.DigitalInputPins() {
+			pins = append(pins, p)
+		}
+	case hal.AnalogInput:
+		for _, p := range d.AnalogInputPins() {
+			pins = append(pins, p)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported capability:%s", cap.String())
+	}
+	return pins, nil
+}
+
+func (d *driver) DigitalOutputPins() []hal.DigitalOutputPin {
+	var pins []hal.DigitalOutputPin
+	for _, c := range d.coils {
+		pins = append(pins, c)
+	}
+	return pins
+}
+
+// DigitalOutputPin returns the coil at the given modbus address
+func (d *driver) DigitalOutputPin(n int) (hal.DigitalOutputPin, error) {
+	for _, c := range d.coils {
+		if c.address == n {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("coil %d is not configured", n)
+}
+
+func (d *driver) DigitalInputPins() []hal.DigitalInputPin {
+	var pins []hal.DigitalInputPin
+	for _, in := range d.discretes {
+		pins = append(pins, in)
+	}
+	return pins
+}
+
+// DigitalInputPin returns the discrete input at the given modbus address
+func (d *driver) DigitalInputPin(n int) (hal.DigitalInputPin, error) {
+	for _, in := range d.discretes {
+		if in.address == n {
+			return in, nil
+		}
+	}
+	return nil, fmt.Errorf("discrete input %d is not configured", n)
+}
+
+func (d *driver) AnalogInputPins() []hal.AnalogInputPin {
+	var pins []hal.AnalogInputPin
+	for _, r := range d.registers {
+		pins = append(pins, r)
+	}
+	return pins
+}
+
+// AnalogInputPin returns the n-th configured register
+func (d *driver) AnalogInputPin(n int) (hal.AnalogInputPin, error) {
+	if n < 0 || n >= len(d.registers) {
+		return nil, fmt.Errorf("register %d is not configured", n)
+	}
+	return d.registers[n], nil
+}
+
+func (c *coil) Name() string {
+	return fmt.Sprintf("coil-%d", c.address)
+}
+
+func (c *coil) Number() int {
+	return c.address
+}
+
+func (c *coil) Close() error {
+	return nil
+}
+
+func (c *coil) Write(state bool) error {
+	c.Lock()
+	defer c.Unlock()
+	if err := c.c.writeCoil(uint16(c.address), state); err != nil {
+		return err
+	}
+	c.lastState = state
+	return nil
+}
+
+// ReadState reads the coil back from the device
+func (c *coil) ReadState() (bool, error) {
+	bits, err := c.c.readBits(readCoils, uint16(c.address), 1)
+	if err != nil {
+		return false, err
+	}
+	return bits[0], nil
+}
+
+func (c *coil) LastState() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.lastState
+}
+
+func (in *discrete) Name() string {
+	return fmt.Sprintf("discrete-%d", in.address)
+}
+
+func (in *discrete) Number() int {
+	return in.address
+}
+
+func (in *discrete) Close() error {
+	return nil
+}
+
+func (in *discrete) Read() (bool, error) {
+	bits, err := in.c.readBits(readDiscreteInputs, uint16(in.address), 1)
+	if err != nil {
+		return false, err
+	}
+	return bits[0], nil
+}
+
+func (a *analog) Name() string {
+	t := "input"
+	if a.reg.fc == readHoldingRegisters {
+		t = "holding"
+	}
+	return fmt.Sprintf("%s-%d", t, a.reg.address)
+}
+
+func (a *analog) Number() int {
+	return a.number
+}
+
+func (a *analog) Close() error {
+	return nil
+}
+
+// Value returns the scaled register value
+func (a *analog) Value() (float64, error) {
+	regs, err := a.c.readRegisters(a.reg.fc, a.reg.address, 1)
+	if err != nil {
+		return 0, err
+	}
+	return float64(regs[0])*a.reg.scale + a.reg.offset, nil
+}
+
+func (a *analog) Measure() (float64, error) {
+	v, err := a.Value()
+	if err != nil {
+		return 0, err
+	}
+	a.Lock()
+	defer a.Unlock()
+	if a.calibrator == nil {
+		return 0, fmt.Errorf("Not calibrated")
+	}
+	return a.calibrator.Calibrate(v), nil
+}
+
+func (a *analog) Calibrate(points []hal.Measurement) error {
+	cal, err := hal.CalibratorFactory(points)
+	if err != nil {
+		return err
+	}
+	a.Lock()
+	a.calibrator = cal
+	a.Unlock()
+	return nil
+}
@@ -0,0 +1,266 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/hal"
+)
+
+// standIn is a minimal modbus server used to exercise the driver
+type standIn struct {
+	sync.Mutex
+	coils     [16]bool
+	discretes [16]bool
+	input     [16]uint16
+	holding   [16]uint16
+}
+
+func (s *standIn) handle(pdu []byte) []byte {
+	s.Lock()
+	defer s.Unlock()
+	fc := pdu[0]
+	addr := int(binary.BigEndian.Uint16(pdu[1:]))
+	v := binary.BigEndian.Uint16(pdu[3:])
+	exception := []byte{fc | 0x80, 2}
+	switch fc {
+	case readCoils, readDiscreteInputs:
+		bits := s.coils[:]
+		if fc == readDiscreteInputs {
+			bits = s.discretes[:]
+		}
+		if addr+int(v) > len(bits) {
+			return exception
+		}
+		resp := []byte{fc, byte((v + 7) / 8)}
+		resp = append(resp, make([]byte, resp[1])...)
+		for i := 0; i < int(v); i++ {
+			if bits[addr+i] {
+				resp[2+i/8] |= 1 << uint(i%8)
+			}
+		}
+		return resp
+	case readInputRegisters, readHoldingRegisters:
+		regs := s.input[:]
+		if fc == readHoldingRegisters {
+			regs = s.holding[:]
+		}
+		if addr+int(v) > len(regs) {
+			return exception
+		}
+		resp := []byte{fc, byte(2 * v)}
+		for i := 0; i < int(v); i++ {
+			resp = binary.BigEndian.AppendUint16(resp, regs[addr+i])
+		}
+		return resp
+	case writeSingleCoil:
+		if addr >= len(s.coils) {
+			return exception
+		}
+		s.coils[addr] = v == 0xFF00
+		return pdu
+	}
+	return []byte{fc | 0x80, 1}
+}
+
+func (s *standIn) serveTCP(conn net.Conn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, 7)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		pdu := make([]byte, binary.BigEndian.Uint16(header[4:])-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+		resp := s.handle(pdu)
+		binary.BigEndian.PutUint16(header[4:], uint16(len(resp)+1))
+		if _, err := conn.Write(append(header, resp...)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *standIn) serveRTU(conn net.Conn) {
+	defer conn.Close()
+	for {
+		frame := make([]byte, 8)
+		if _, err := io.ReadFull(conn, frame); err != nil {
+			return
+		}
+		resp := append([]byte{frame[0]}, s.handle(frame[1:6])...)
+		resp = binary.LittleEndian.AppendUint16(resp, crc16(resp))
+		if _, err := conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+func TestModbusTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	s := new(standIn)
+	s.discretes[3] = true
+	s.input[2] = 825
+	s.holding[5] = 1000
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveTCP(conn)
+		}
+	}()
+
+	f := Factory()
+	params := map[string]interface{}{
+		"Transport":      "tcp",
+		"Address":        l.Addr().String(),
+		"Coil Start":     4,
+		"Coil Count":     4,
+		"Discrete Count": 8,
+		"Registers":      "input:2:0.01, holding:5:0.1:-50",
+	}
+	if valid, failures := f.ValidateParameters(params); !valid {
+		t.Fatal(failures)
+	}
+	if valid, _ := f.ValidateParameters(map[string]interface{}{"Address": "x", "Registers": "coil:1"}); valid {
+		t.Error("Expected invalid register type to fail validation")
+	}
+	hd, err := f.NewDriver(params, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hd.Close()
+	d := hd.(*driver)
+	if err := d.Ping(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := d.DigitalOutputPin(2); err == nil {
+		t.Error("Expected coil outside configured range to fail")
+	}
+	out, err := d.DigitalOutputPin(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Write(true); err != nil {
+		t.Fatal(err)
+	}
+	if !s.coils[6] || !out.LastState() {
+		t.Error("Expected coil 6 to be on")
+	}
+	s.Lock()
+	s.coils[6] = false
+	s.Unlock()
+	if on, err := out.(*coil).ReadState(); err != nil || on {
+		t.Error("Expected coil 6 to be read back as off", on, err)
+	}
+
+	in, err := d.DigitalInputPin(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := in.Read(); err != nil || !v {
+		t.Error("Expected discrete input 3 to be high. Error:", err)
+	}
+
+	if len(d.AnalogInputPins()) != 2 {
+		t.Error("Expected two analog input pins")
+	}
+	for i, expected := range []float64{8.25, 50} {
+		ai, err := d.AnalogInputPin(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, err := ai.Value(); err != nil || v != expected {
+			t.Error("Expected:", expected, "found:", v, "Error:", err)
+		}
+	}
+
+	params["Registers"] = "input:20"
+	hd2, err := f.NewDriver(params, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ai, _ := hd2.(hal.AnalogInputDriver).AnalogInputPin(0)
+	if _, err := ai.Value(); err == nil {
+		t.Error("Expected modbus exception for invalid register address")
+	}
+}
+
+func TestModbusRTU(t *testing.T) {
+	port, server := net.Pipe()
+	s := new(standIn)
+	s.holding[1] = 42
+	go s.serveRTU(server)
+	c := &client{t: &rtuTransport{port: port}, unit: 7}
+	defer c.Close()
+	regs, err := c.readRegisters(readHoldingRegisters, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if regs[0] != 42 {
+		t.Error("Expected 42, found:", regs[0])
+	}
+	if err := c.writeCoil(2, true); err != nil {
+		t.Error(err)
+	}
+	if !s.coils[2] {
+		t.Error("Expected coil 2 to be on")
+	}
+	if _, err := c.readBits(readCoils, 15, 4); err == nil {
+		t.Error("Expected exception for invalid coil range")
+	}
+	if crc16([]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x01}) != 0x0A84 {
+		t.Error("Unexpected crc")
+	}
+}
+
+func TestModbusTCPMismatch(t *testing.T) {
+	s := new(standIn)
+	s.holding[1] = 42
+	tcs := map[string]func([]byte){
+		"valid":       func([]byte) {},
+		"transaction": func(h []byte) { h[1]++ },
+		"protocol":    func(h []byte) { h[3] = 1 },
+		"unit":        func(h []byte) { h[6] = 9 },
+	}
+	for name, corrupt := range tcs {
+		port, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			header := make([]byte, 7)
+			if _, err := io.ReadFull(server, header); err != nil {
+				return
+			}
+			pdu := make([]byte, binary.BigEndian.Uint16(header[4:])-1)
+			if _, err := io.ReadFull(server, pdu); err != nil {
+				return
+			}
+			resp := s.handle(pdu)
+			binary.BigEndian.PutUint16(header[4:], uint16(len(resp)+1))
+			corrupt(header)
+			server.Write(append(header, resp...))
+		}()
+		c := &client{t: &tcpTransport{conn: port, timeout: time.Second}, unit: 7}
+		regs, err := c.readRegisters(readHoldingRegisters, 1, 1)
+		if name == "valid" {
+			if err != nil || regs[0] != 42 {
+				t.Error("Expected 42, found:", regs, "Error:", err)
+			}
+		} else if err == nil {
+			t.Error("Expected response with mismatched", name, "id to be rejected")
+		}
+		c.Close()
+	}
+}
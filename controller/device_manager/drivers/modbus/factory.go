@@ -0,0 +1,216 @@
+package modbus
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reef-pi/hal"
+)
+
+const (
+	transportParam     = "Transport"
+	addressParam       = "Address"
+	baudParam          = "Baud Rate"
+	unitParam          = "Unit"
+	timeoutParam       = "Timeout"
+	coilStartParam     = "Coil Start"
+	coilCountParam     = "Coil Count"
+	discreteStartParam = "Discrete Start"
+	discreteCountParam = "Discrete Count"
+	registersParam     = "Registers"
+
+	TCP = "tcp"
+	RTU = "rtu"
+)
+
+type factory struct {
+	meta       hal.Metadata
+	parameters []hal.ConfigParameter
+}
+
+var modbusFactory *factory
+var once sync.Once
+
+// Factory returns a singleton modbus driver factory
+func Factory() hal.DriverFactory {
+	once.Do(func() {
+		modbusFactory = &factory{
+			meta: hal.Metadata{
+				Name:        "modbus",
+				Description: "Modbus TCP or RTU device. Coils are digital outputs, discrete inputs are digital inputs and input/holding registers are analog inputs",
+				Capabilities: []hal.Capability{
+					hal.DigitalOutput, hal.DigitalInput, hal.AnalogInput,
+				},
+			},
+			parameters: []hal.ConfigParameter{
+				{Name: transportParam, Type: hal.String, Order: 0, Default: TCP},
+				{Name: addressParam, Type: hal.String, Order: 1, Default: "192.168.1.10:502"},
+				{Name: baudParam, Type: hal.Integer, Order: 2, Default: 9600},
+				{Name: unitParam, Type: hal.Integer, Order: 3, Default: 1},
+				{Name: timeoutParam, Type: hal.Decimal, Order: 4, Default: 1.0},
+				{Name: coilStartParam, Type: hal.Integer, Order: 5, Default: 0},
+				{Name: coilCountParam, Type: hal.Integer, Order: 6, Default: 8},
+				{Name: discreteStartParam, Type: hal.Integer, Order: 7, Default: 0},
+				{Name: discreteCountParam, Type: hal.Integer, Order: 8, Default: 0},
+				// comma separated <input|holding>:<address>[:<scale>[:<offset>]], one analog input pin per register
+				{Name: registersParam, Type: hal.String, Order: 9, Default: ""},
+			},
+		}
+	})
+	return modbusFactory
+}
+
+func (f *factory) Metadata() hal.Metadata {
+	return f.meta
+}
+
+func (f *factory) GetParameters() []hal.ConfigParameter {
+	return f.parameters
+}
+
+// register maps an analog input pin to an input or holding register. Value is raw * scale + offset
+type register struct {
+	fc      byte
+	address uint16
+	scale   float64
+	offset  float64
+}
+
+func parseRegisters(spec string) ([]register, error) {
+	var regs []register
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		parts := strings.Split(s, ":")
+		if len(parts) < 2 || len(parts) > 4 {
+			return nil, fmt.Errorf("invalid register '%s', expected <input|holding>:<address>[:<scale>[:<offset>]]", s)
+		}
+		r := register{scale: 1}
+		switch parts[0] {
+		case "input":
+			r.fc = readInputRegisters
+		case "holding":
+			r.fc = readHoldingRegisters
+		default:
+			return nil, fmt.Errorf("invalid register type '%s', expected input or holding", parts[0])
+		}
+		a, err := strconv.ParseUint(parts[1], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid register address '%s'", parts[1])
+		}
+		r.address = uint16(a)
+		if len(parts) > 2 {
+			if r.scale, err = strconv.ParseFloat(parts[2], 64); err != nil {
+				return nil, fmt.Errorf("invalid register scale '%s'", parts[2])
+			}
+		}
+		if len(parts) > 3 {
+			if r.offset, err = strconv.ParseFloat(parts[3], 64); err != nil {
+				return nil, fmt.Errorf("invalid register offset '%s'", parts[3])
+			}
+		}
+		regs = append(regs, r)
+	}
+	return regs, nil
+}
+
+func intParam(parameters map[string]interface{}, name string, def, min, max int, failures map[string][]string) int {
+	v, ok := parameters[name]
+	if !ok {
+		return def
+	}
+	val, ok := hal.ConvertToInt(v)
+	if !ok {
+		failures[name] = append(failures[name], fmt.Sprint(name, " is not a number. ", v, " was received."))
+		return def
+	}
+	if val < min || val > max {
+		failures[name] = append(failures[name], fmt.Sprint(name, " is out of range (", min, " - ", max, "). ", v, " was received."))
+	}
+	return val
+}
+
+type config struct {
+	transport     string
+	address       string
+	baud          int
+	unit          int
+	timeout       time.Duration
+	coilStart     int
+	coilCount     int
+	discreteStart int
+	discreteCount int
+	registers     []register
+}
+
+func (f *factory) parse(parameters map[string]interface{}) (config, map[string][]string) {
+	var failures = make(map[string][]string)
+	c := config{transport: TCP}
+	if v, ok := parameters[transportParam]; ok {
+		s, _ := v.(string)
+		c.transport = strings.ToLower(s)
+		if c.transport != TCP && c.transport != RTU {
+			failures[transportParam] = append(failures[transportParam], fmt.Sprint(transportParam, " should be tcp or rtu. ", v, " was received."))
+		}
+	}
+	if v, ok := parameters[addressParam]; ok {
+		c.address, _ = v.(string)
+	}
+	if c.address == "" {
+		failures[addressParam] = append(failures[addressParam], fmt.Sprint(addressParam, " is required parameter, but was not received."))
+	}
+	c.baud = intParam(parameters, baudParam, 9600, 1200, 115200, failures)
+	c.unit = intParam(parameters, unitParam, 1, 0, 247, failures)
+	c.coilStart = intParam(parameters, coilStartParam, 0, 0, 65535, failures)
+	c.coilCount = intParam(parameters, coilCountParam, 8, 0, 2000, failures)
+	c.discreteStart = intParam(parameters, discreteStartParam, 0, 0, 65535, failures)
+	c.discreteCount = intParam(parameters, discreteCountParam, 0, 0, 2000, failures)
+	c.timeout = time.Second
+	if v, ok := parameters[timeoutParam]; ok {
+		t, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+		if err != nil || t <= 0 {
+			failures[timeoutParam] = append(failures[timeoutParam], fmt.Sprint(timeoutParam, " should be a positive number of seconds. ", v, " was received."))
+		} else {
+			c.timeout = time.Duration(t * float64(time.Second))
+		}
+	}
+	if v, ok := parameters[registersParam]; ok {
+		s, _ := v.(string)
+		regs, err := parseRegisters(s)
+		if err != nil {
+			failures[registersParam] = append(failures[registersParam], err.Error())
+		}
+		c.registers = regs
+	}
+	return c, failures
+}
+
+func (f *factory) ValidateParameters(parameters map[string]interface{}) (bool, map[string][]string) {
+	_, failures := f.parse(parameters)
+	return len(failures) == 0, failures
+}
+
+func (f *factory) NewDriver(parameters map[string]interface{}, _ interface{}) (hal.Driver, error) {
+	c, failures := f.parse(parameters)
+	if len(failures) > 0 {
+		return nil, errors.New(hal.ToErrorString(failures))
+	}
+	var t transport
+	switch c.transport {
+	case RTU:
+		port, err := openSerial(c.address, c.baud, c.timeout)
+		if err != nil {
+			return nil, err
+		}
+		t = &rtuTransport{port: port}
+	default:
+		t = &tcpTransport{address: c.address, timeout: c.timeout}
+	}
+	return newDriver(f.meta, c, &client{t: t, unit: byte(c.unit)})
+}
@@ -0,0 +1,78 @@
+//go:build linux
+// +build linux
+
+package modbus
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+var baudRates = map[int]uint32{
+	1200:   unix.B1200,
+	2400:   unix.B2400,
+	4800:   unix.B4800,
+	9600:   unix.B9600,
+	19200:  unix.B19200,
+	38400:  unix.B38400,
+	57600:  unix.B57600,
+	115200: unix.B115200,
+}
+
+// openSerial opens a serial device in raw mode, 8 data bits, no parity, one stop bit
+func openSerial(device string, baud int, timeout time.Duration) (io.ReadWriteCloser, error) {
+	rate, ok := baudRates[baud]
+	if !ok {
+		return nil, fmt.Errorf("unsupported baud rate:%d", baud)
+	}
+	f, err := os.OpenFile(device, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+	t := unix.Termios{
+		Iflag:  unix.IGNPAR,
+		Cflag:  unix.CS8 | unix.CREAD | unix.CLOCAL | rate,
+		Ispeed: rate,
+		Ospeed: rate,
+	}
+	// read returns after the inter byte timeout (in deciseconds) expires
+	deciseconds := timeout.Milliseconds() / 100
+	if deciseconds < 1 {
+		deciseconds = 1
+	}
+	if deciseconds > 255 {
+		deciseconds = 255
+	}
+	t.Cc[unix.VMIN] = 0
+	t.Cc[unix.VTIME] = uint8(deciseconds)
+	if err := unix.IoctlSetTermios(int(f.Fd()), unix.TCSETS, &t); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &serialPort{f: f}, nil
+}
+
+type serialPort struct {
+	f *os.File
+}
+
+func (s *serialPort) Read(b []byte) (int, error) {
+	n, err := s.f.Read(b)
+	// an expired read timeout is reported as a zero length read
+	if n == 0 && (err == nil || err == io.EOF) {
+		return 0, fmt.Errorf("modbus: serial read timeout")
+	}
+	return n, err
+}
+
+func (s *serialPort) Write(b []byte) (int, error) {
+	return s.f.Write(b)
+}
+
+func (s *serialPort) Close() error {
+	return s.f.Close()
+}
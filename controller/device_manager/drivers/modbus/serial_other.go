@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package modbus
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+func openSerial(_ string, _ int, _ time.Duration) (io.ReadWriteCloser, error) {
+	return nil, fmt.Errorf("modbus RTU is only supported on linux")
+}
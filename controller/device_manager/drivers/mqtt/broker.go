@@ -0,0 +1,89 @@
+package mqtt
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const timeout = 5 * time.Second
+
+// broker is the subset of an mqtt client used by the driver
+type broker interface {
+	Publish(topic, payload string) error
+	Subscribe(topic string, fn func(payload []byte)) error
+	Close()
+}
+
+type pahoBroker struct {
+	sync.Mutex
+	client   paho.Client
+	qos      byte
+	retained bool
+	subs     map[string]paho.MessageHandler
+}
+
+func newPahoBroker(c config) (*pahoBroker, error) {
+	opts := paho.NewClientOptions().
+		AddBroker(c.server).
+		SetClientID(c.clientID).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true)
+	if c.username != "" {
+		opts.SetUsername(c.username)
+		if c.password != "" {
+			opts.SetPassword(c.password)
+		}
+	}
+	b := &pahoBroker{
+		qos:      c.qos,
+		retained: c.retained,
+		subs:     make(map[string]paho.MessageHandler),
+	}
+	// sessions are not persisted, subscriptions are restored after every (re)connection
+	opts.SetOnConnectHandler(func(client paho.Client) {
+		b.Lock()
+		defer b.Unlock()
+		for topic, h := range b.subs {
+			client.Subscribe(topic, b.qos, h)
+		}
+	})
+	b.client = paho.NewClient(opts)
+	t := b.client.Connect()
+	if !t.WaitTimeout(timeout) {
+		// connection is retried in background, subscriptions are restored on connect
+		return b, nil
+	}
+	return b, t.Error()
+}
+
+func wait(t paho.Token) error {
+	if !t.WaitTimeout(timeout) {
+		return fmt.Errorf("mqtt: timed out waiting for broker")
+	}
+	return t.Error()
+}
+
+func (b *pahoBroker) Publish(topic, payload string) error {
+	return wait(b.client.Publish(topic, b.qos, b.retained, payload))
+}
+
+func (b *pahoBroker) Subscribe(topic string, fn func([]byte)) error {
+	h := func(_ paho.Client, m paho.Message) {
+		fn(m.Payload())
+	}
+	b.Lock()
+	b.subs[topic] = h
+	b.Unlock()
+	if !b.client.IsConnectionOpen() {
+		return nil
+	}
+	return wait(b.client.Subscribe(topic, b.qos, h))
+}
+
+func (b *pahoBroker) Close() {
+	b.client.Disconnect(250)
+}
@@ -0,0 +1,350 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reef-pi/hal"
+)
+
+type driver struct {
+	meta    hal.Metadata
+	b       broker
+	outputs []hal.DigitalOutputPin
+	inputs  []*input
+	analogs []*analog
+}
+
+// state tracks the last payload received on a state topic
+type state struct {
+	sync.Mutex
+	topic   string
+	stale   time.Duration
+	now     func() time.Time
+	payload []byte
+	updated time.Time
+}
+
+type output struct {
+	sync.Mutex
+	b         broker
+	number    int
+	topic     string
+	on, off   string
+	lastState bool
+}
+
+// stateOutput is an output whose actual state is published on a state topic
+type stateOutput struct {
+	*output
+	state *input
+}
+
+type input struct {
+	number int
+	on     string
+	s      *state
+}
+
+type analog struct {
+	sync.Mutex
+	number     int
+	field      string
+	s          *state
+	calibrator hal.Calibrator
+}
+
+func newDriver(meta hal.Metadata, c config, b broker) (*driver, error) {
+	return newDriverWithClock(meta, c, b, time.Now)
+}
+
+func newDriverWithClock(meta hal.Metadata, c config, b broker, now func() time.Time) (*driver, error) {
+	d := &driver{
+		meta: meta,
+		b:    b,
+	}
+	for i, t := range c.outputs {
+		o := &output{b: b, number: i, topic: t, on: c.on, off: c.off}
+		if i >= len(c.states) || c.states[i] == "" {
+			d.outputs = append(d.outputs, o)
+			continue
+		}
+		s := &state{topic: c.states[i], stale: c.stale, now: now}
+		if err := b.Subscribe(s.topic, s.update); err != nil {
+			b.Close()
+			return nil, err
+		}
+		d.outputs = append(d.outputs, &stateOutput{output: o, state: &input{number: i, on: c.on, s: s}})
+	}
+	for i, t := range c.inputs {
+		s := &state{topic: t, stale: c.stale, now: now}
+		if err := b.Subscribe(t, s.update); err != nil {
+			b.Close()
+			return nil, err
+		}
+		d.inputs = append(d.inputs, &input{number: i, on: c.on, s: s})
+	}
+	for i, t := range c.analogs {
+		cal, err := hal.CalibratorFactory([]hal.Measurement{})
+		if err != nil {
+			b.Close()
+			return nil, err
+		}
+		topic, field := t, ""
+		if n := strings.Index(t, "#"); n >= 0 {
+			topic, field = t[:n], t[n+1:]
+		}
+		s := &state{topic: topic, stale: c.stale, now: now}
+		if err := b.Subscribe(topic, s.update); err != nil {
+			b.Close()
+			return nil, err
+		}
+		d.analogs = append(d.analogs, &analog{number: i, field: field, s: s, calibrator: cal})
+	}
+	return d, nil
+}
+
+func (d *driver) Metadata() hal.Metadata {
+	return d.meta
+}
+
+func (d *driver) Close() error {
+	d.b.Close()
+	return nil
+}
+
+// Ping reports an error when any subscribed state topic has gone stale
+func (d *driver) Ping() error {
+	for _, in := range d.inputs {
+		if _, err := in.s.get(); err != nil {
+			return err
+		}
+	}
+	for _, a := range d.analogs {
+		if _, err := a.s.get(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *driver) Pins(cap hal.Capability) ([]hal.Pin, error) {
+	var pins []hal.Pin
+	switch cap {
+	case hal.DigitalOutput:
+		for _, p := range d.DigitalOutputPins() {
+			pins = append(pins, p)
+		}
+	case hal.DigitalInput:
+		for _, p := range d.DigitalInputPins() {
+			pins = append(pins, p)
+		}
+	case hal.AnalogInput:
+		for _, p := range d.AnalogInputPins() {
+			pins = append(pins, p)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported capability:%s", cap.String())
+	}
+	return pins, nil
+}
+
+func (d *driver) DigitalOutputPins() []hal.DigitalOutputPin {
+	return d.outputs
+}
+
+func (d *driver) DigitalOutputPin(n int) (hal.DigitalOutputPin, error) {
+	if n < 0 || n >= len(d.outputs) {
+		return nil, fmt.Errorf("output %d is not configured", n)
+	}
+	return d.outputs[n], nil
+}
+
+func (d *driver) DigitalInputPins() []hal.DigitalInputPin {
+	var pins []hal.DigitalInputPin
+	for _, in := range d.inputs {
+		pins = append(pins, in)
+	}
+	return pins
+}
+
+func (d *driver) DigitalInputPin(n int) (hal.DigitalInputPin, error) {
+	if n < 0 || n >= len(d.inputs) {
+		return nil, fmt.Errorf("input %d is not configured", n)
+	}
+	return d.inputs[n], nil
+}
+
+func (d *driver) AnalogInputPins() []hal.AnalogInputPin {
+	var pins []hal.AnalogInputPin
+	for _, a := range d.analogs {
+		pins = append(pins, a)
+	}
+	return pins
+}
+
+func (d *driver) AnalogInputPin(n int) (hal.AnalogInputPin, error) {
+	if n < 0 || n >= len(d.analogs) {
+		return nil, fmt.Errorf("analog input %d is not configured", n)
+	}
+	return d.analogs[n], nil
+}
+
+func (s *state) update(payload []byte) {
+	s.Lock()
+	defer s.Unlock()
+	s.payload = append([]byte(nil), payload...)
+	s.updated = s.now()
+}
+
+func (s *state) get() (string, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.updated.IsZero() {
+		return "", fmt.Errorf("no message received on %s", s.topic)
+	}
+	if s.stale > 0 && s.now().Sub(s.updated) > s.stale {
+		return "", fmt.Errorf("state on %s is stale, last update at %s", s.topic, s.updated.Format(time.RFC3339))
+	}
+	return strings.TrimSpace(string(s.payload)), nil
+}
+
+func (o *output) Name() string {
+	return o.topic
+}
+
+func (o *output) Number() int {
+	return o.number
+}
+
+func (o *output) Close() error {
+	return nil
+}
+
+func (o *output) Write(state bool) error {
+	o.Lock()
+	defer o.Unlock()
+	payload := o.off
+	if state {
+		payload = o.on
+	}
+	if err := o.b.Publish(o.topic, payload); err != nil {
+		return err
+	}
+	o.lastState = state
+	return nil
+}
+
+func (o *output) LastState() bool {
+	o.Lock()
+	defer o.Unlock()
+	return o.lastState
+}
+
+// ReadState returns the last state reported on the output's state topic
+func (o *stateOutput) ReadState() (bool, error) {
+	return o.state.Read()
+}
+
+func (in *input) Name() string {
+	return in.s.topic
+}
+
+func (in *input) Number() int {
+	return in.number
+}
+
+func (in *input) Close() error {
+	return nil
+}
+
+func (in *input) Read() (bool, error) {
+	p, err := in.s.get()
+	if err != nil {
+		return false, err
+	}
+	if strings.EqualFold(p, in.on) {
+		return true, nil
+	}
+	switch strings.ToLower(p) {
+	case "on", "true", "1":
+		return true, nil
+	case "off", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("unrecognized state '%s' on %s", p, in.s.topic)
+}
+
+func (a *analog) Name() string {
+	if a.field != "" {
+		return a.s.topic + "#" + a.field
+	}
+	return a.s.topic
+}
+
+func (a *analog) Number() int {
+	return a.number
+}
+
+func (a *analog) Close() error {
+	return nil
+}
+
+// Value returns the last received reading, extracting the configured field from json payloads
+func (a *analog) Value() (float64, error) {
+	p, err := a.s.get()
+	if err != nil {
+		return 0, err
+	}
+	if a.field == "" {
+		return strconv.ParseFloat(p, 64)
+	}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(p), &doc); err != nil {
+		return 0, fmt.Errorf("invalid json on %s: %w", a.s.topic, err)
+	}
+	for _, k := range strings.Split(a.field, ".") {
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("field %s not found on %s", a.field, a.s.topic)
+		}
+		if doc, ok = m[k]; !ok {
+			return 0, fmt.Errorf("field %s not found on %s", a.field, a.s.topic)
+		}
+	}
+	switch v := doc.(type) {
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("field %s on %s is not a number", a.field, a.s.topic)
+}
+
+func (a *analog) Measure() (float64, error) {
+	v, err := a.Value()
+	if err != nil {
+		return 0, err
+	}
+	a.Lock()
+	defer a.Unlock()
+	if a.calibrator == nil {
+		return 0, fmt.Errorf("Not calibrated")
+	}
+	return a.calibrator.Calibrate(v), nil
+}
+
+func (a *analog) Calibrate(points []hal.Measurement) error {
+	cal, err := hal.CalibratorFactory(points)
+	if err != nil {
+		return err
+	}
+	a.Lock()
+	a.calibrator = cal
+	a.Unlock()
+	return nil
+}
@@ -0,0 +1,199 @@
+package mqtt
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/hal"
+)
+
+type fakeBroker struct {
+	sync.Mutex
+	published map[string]string
+	subs      map[string]func([]byte)
+	closed    bool
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{
+		published: make(map[string]string),
+		subs:      make(map[string]func([]byte)),
+	}
+}
+
+func (b *fakeBroker) Publish(topic, payload string) error {
+	b.Lock()
+	defer b.Unlock()
+	b.published[topic] = payload
+	return nil
+}
+
+func (b *fakeBroker) Subscribe(topic string, fn func([]byte)) error {
+	b.Lock()
+	defer b.Unlock()
+	b.subs[topic] = fn
+	return nil
+}
+
+func (b *fakeBroker) Close() {
+	b.closed = true
+}
+
+func (b *fakeBroker) send(topic, payload string) {
+	b.Lock()
+	fn := b.subs[topic]
+	b.Unlock()
+	if fn != nil {
+		fn([]byte(payload))
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	f := Factory()
+	params := map[string]interface{}{
+		"Server":  "tcp://127.0.0.1:1883",
+		"Outputs": "cmnd/a/POWER, cmnd/b/POWER",
+		"QoS":     1,
+	}
+	if ok, failures := f.ValidateParameters(params); !ok {
+		t.Error(failures)
+	}
+	params["QoS"] = 3
+	if ok, _ := f.ValidateParameters(params); ok {
+		t.Error("qos 3 should be rejected")
+	}
+	if ok, _ := f.ValidateParameters(map[string]interface{}{"Server": "tcp://127.0.0.1:1883"}); ok {
+		t.Error("driver without topics should be rejected")
+	}
+}
+
+func TestMQTTDriver(t *testing.T) {
+	f := Factory().(*factory)
+	c, failures := f.parse(map[string]interface{}{
+		"Server":        "tcp://127.0.0.1:1883",
+		"Outputs":       "cmnd/plug/POWER",
+		"Output States": "stat/plug/POWER",
+		"Inputs":        "stat/float/STATE",
+		"Analog Inputs": "tele/probe/TEMP, tele/sensor/SENSOR#DS18B20.Temperature",
+		"Stale After":   60,
+	})
+	if len(failures) > 0 {
+		t.Fatal(failures)
+	}
+	c2, _ := f.parse(map[string]interface{}{"Server": "tcp://127.0.0.1:1883", "Outputs": "cmnd/plug/POWER"})
+	if !strings.HasPrefix(c.clientID, "reef-pi-") || c.clientID == c2.clientID {
+		t.Error("Expected drivers to default to unique client ids. Found:", c.clientID, c2.clientID)
+	}
+	b := newFakeBroker()
+	now := time.Now()
+	d, err := newDriverWithClock(f.meta, c, b, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := d.DigitalOutputPin(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Write(true); err != nil {
+		t.Error(err)
+	}
+	if b.published["cmnd/plug/POWER"] != "ON" || !out.LastState() {
+		t.Error("expected ON to be published", b.published)
+	}
+	if err := out.Write(false); err != nil {
+		t.Error(err)
+	}
+	if b.published["cmnd/plug/POWER"] != "OFF" || out.LastState() {
+		t.Error("expected OFF to be published", b.published)
+	}
+	if _, err := out.(*stateOutput).ReadState(); err == nil {
+		t.Error("expected error before the plug reported state")
+	}
+	b.send("stat/plug/POWER", "ON")
+	if on, err := out.(*stateOutput).ReadState(); err != nil || !on {
+		t.Error("expected plug to be read back as on", on, err)
+	}
+	if _, err := d.DigitalOutputPin(1); err == nil {
+		t.Error("expected error for unconfigured output")
+	}
+
+	in, err := d.DigitalInputPin(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Read(); err == nil {
+		t.Error("expected error before any state message")
+	}
+	if err := d.Ping(); err == nil {
+		t.Error("expected ping to fail before any state message")
+	}
+	b.send("stat/float/STATE", "on")
+	if v, err := in.Read(); err != nil || !v {
+		t.Error("expected input to be on", v, err)
+	}
+	b.send("stat/float/STATE", "0")
+	if v, err := in.Read(); err != nil || v {
+		t.Error("expected input to be off", v, err)
+	}
+	b.send("stat/float/STATE", "maybe")
+	if _, err := in.Read(); err == nil {
+		t.Error("expected error for unrecognized payload")
+	}
+
+	temp, err := d.AnalogInputPin(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.send("tele/probe/TEMP", "25.5")
+	if v, err := temp.Value(); err != nil || v != 25.5 {
+		t.Error("expected 25.5", v, err)
+	}
+	if err := temp.Calibrate([]hal.Measurement{{Expected: 26.5, Observed: 25.5}}); err != nil {
+		t.Error(err)
+	}
+	if v, err := temp.Measure(); err != nil || v != 26.5 {
+		t.Error("expected calibrated value 26.5", v, err)
+	}
+
+	json, err := d.AnalogInputPin(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.send("tele/sensor/SENSOR", `{"Time":"2024-01-01T00:00:00","DS18B20":{"Temperature":24.25}}`)
+	if v, err := json.Value(); err != nil || v != 24.25 {
+		t.Error("expected 24.25", v, err)
+	}
+	b.send("tele/sensor/SENSOR", `{"DS18B20":{}}`)
+	if _, err := json.Value(); err == nil {
+		t.Error("expected error for missing field")
+	}
+	b.send("tele/sensor/SENSOR", `{"DS18B20":{"Temperature":"23.5"}}`)
+	if err := d.Ping(); err != nil {
+		t.Error(err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := in.Read(); err == nil {
+		t.Error("expected stale input to return error")
+	}
+	if _, err := temp.Value(); err == nil {
+		t.Error("expected stale analog input to return error")
+	}
+	if err := d.Ping(); err == nil {
+		t.Error("expected ping to fail for stale state")
+	}
+
+	pins, err := d.Pins(hal.AnalogInput)
+	if err != nil || len(pins) != 2 {
+		t.Error("expected 2 analog pins", pins, err)
+	}
+	if _, err := d.Pins(hal.PWM); err == nil {
+		t.Error("expected error for unsupported capability")
+	}
+	if err := d.Close(); err != nil || !b.closed {
+		t.Error("expected broker to be closed")
+	}
+}
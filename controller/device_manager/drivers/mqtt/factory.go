@@ -0,0 +1,208 @@
+package mqtt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reef-pi/hal"
+)
+
+const (
+	serverParam   = "Server"
+	usernameParam = "Username"
+	passwordParam = "Password"
+	clientIDParam = "Client ID"
+	qosParam      = "QoS"
+	retainedParam = "Retained"
+	outputsParam  = "Outputs"
+	inputsParam   = "Inputs"
+	analogParam   = "Analog Inputs"
+	onParam       = "On Payload"
+	offParam      = "Off Payload"
+	staleParam    = "Stale After"
+	statesParam   = "Output States"
+)
+
+type factory struct {
+	meta       hal.Metadata
+	parameters []hal.ConfigParameter
+}
+
+var mqttFactory *factory
+var once sync.Once
+
+// Factory returns a singleton mqtt driver factory
+func Factory() hal.DriverFactory {
+	once.Do(func() {
+		mqttFactory = &factory{
+			meta: hal.Metadata{
+				Name:        "mqtt",
+				Description: "Devices controlled through an MQTT broker. Outputs publish to command topics, inputs track state topics",
+				Capabilities: []hal.Capability{
+					hal.DigitalOutput, hal.DigitalInput, hal.AnalogInput,
+				},
+			},
+			parameters: []hal.ConfigParameter{
+				{Name: serverParam, Type: hal.String, Order: 0, Default: "tcp://127.0.0.1:1883"},
+				{Name: usernameParam, Type: hal.String, Order: 1, Default: ""},
+				{Name: passwordParam, Type: hal.String, Order: 2, Default: ""},
+				// defaults to an id derived from the hostname, brokers disconnect clients sharing an id
+				{Name: clientIDParam, Type: hal.String, Order: 3, Default: ""},
+				{Name: qosParam, Type: hal.Integer, Order: 4, Default: 0},
+				{Name: retainedParam, Type: hal.Boolean, Order: 5, Default: false},
+				// comma separated command topics, one digital output pin per topic
+				{Name: outputsParam, Type: hal.String, Order: 6, Default: "cmnd/plug/POWER"},
+				// comma separated state topics, one digital input pin per topic
+				{Name: inputsParam, Type: hal.String, Order: 7, Default: ""},
+				// comma separated state topics, one analog input pin per topic. topic#field.path extracts a value from json payloads
+				{Name: analogParam, Type: hal.String, Order: 8, Default: ""},
+				{Name: onParam, Type: hal.String, Order: 9, Default: "ON"},
+				{Name: offParam, Type: hal.String, Order: 10, Default: "OFF"},
+				// seconds after which an input without a state update is considered stale
+				{Name: staleParam, Type: hal.Decimal, Order: 11, Default: 300.0},
+				// comma separated state topics reporting the actual state of each output, in the order of outputs. Empty entries disable read-back
+				{Name: statesParam, Type: hal.String, Order: 12, Default: ""},
+			},
+		}
+	})
+	return mqttFactory
+}
+
+func (f *factory) Metadata() hal.Metadata {
+	return f.meta
+}
+
+func (f *factory) GetParameters() []hal.ConfigParameter {
+	return f.parameters
+}
+
+type config struct {
+	server   string
+	username string
+	password string
+	clientID string
+	qos      byte
+	retained bool
+	outputs  []string
+	states   []string
+	inputs   []string
+	analogs  []string
+	on       string
+	off      string
+	stale    time.Duration
+}
+
+func topics(v interface{}) []string {
+	s, _ := v.(string)
+	var ts []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			ts = append(ts, t)
+		}
+	}
+	return ts
+}
+
+func stringParam(parameters map[string]interface{}, name, def string) string {
+	if v, ok := parameters[name]; ok {
+		if s, ok := v.(string); ok && s != "" {
+			return s
+		}
+	}
+	return def
+}
+
+func (f *factory) parse(parameters map[string]interface{}) (config, map[string][]string) {
+	var failures = make(map[string][]string)
+	c := config{
+		server:   stringParam(parameters, serverParam, ""),
+		username: stringParam(parameters, usernameParam, ""),
+		password: stringParam(parameters, passwordParam, ""),
+		clientID: stringParam(parameters, clientIDParam, ""),
+		outputs:  topics(parameters[outputsParam]),
+		inputs:   topics(parameters[inputsParam]),
+		analogs:  topics(parameters[analogParam]),
+		on:       stringParam(parameters, onParam, "ON"),
+		off:      stringParam(parameters, offParam, "OFF"),
+		stale:    5 * time.Minute,
+	}
+	if c.clientID == "" {
+		c.clientID = defaultClientID()
+	}
+	if c.server == "" {
+		failures[serverParam] = append(failures[serverParam], fmt.Sprint(serverParam, " is required parameter, but was not received."))
+	}
+	if v, ok := parameters[qosParam]; ok {
+		q, ok := hal.ConvertToInt(v)
+		if !ok || q < 0 || q > 2 {
+			failures[qosParam] = append(failures[qosParam], fmt.Sprint(qosParam, " should be 0, 1 or 2. ", v, " was received."))
+		}
+		c.qos = byte(q)
+	}
+	if v, ok := parameters[retainedParam]; ok {
+		b, err := strconv.ParseBool(fmt.Sprint(v))
+		if err != nil {
+			failures[retainedParam] = append(failures[retainedParam], fmt.Sprint(retainedParam, " is not a boolean. ", v, " was received."))
+		}
+		c.retained = b
+	}
+	if v, ok := parameters[staleParam]; ok {
+		s, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+		if err != nil || s < 0 {
+			failures[staleParam] = append(failures[staleParam], fmt.Sprint(staleParam, " should be a positive number of seconds. ", v, " was received."))
+		} else {
+			c.stale = time.Duration(s * float64(time.Second))
+		}
+	}
+	if v, ok := parameters[statesParam]; ok {
+		st, _ := v.(string)
+		if strings.TrimSpace(st) != "" {
+			for _, t := range strings.Split(st, ",") {
+				c.states = append(c.states, strings.TrimSpace(t))
+			}
+		}
+		if len(c.states) > len(c.outputs) {
+			failures[statesParam] = append(failures[statesParam], fmt.Sprint(statesParam, " has more topics than ", outputsParam, "."))
+		}
+	}
+	if len(c.outputs)+len(c.inputs)+len(c.analogs) == 0 {
+		failures[outputsParam] = append(failures[outputsParam], "At least one output, input or analog input topic is required.")
+	}
+	return c, failures
+}
+
+func (f *factory) ValidateParameters(parameters map[string]interface{}) (bool, map[string][]string) {
+	_, failures := f.parse(parameters)
+	return len(failures) == 0, failures
+}
+
+func (f *factory) NewDriver(parameters map[string]interface{}, _ interface{}) (hal.Driver, error) {
+	c, failures := f.parse(parameters)
+	if len(failures) > 0 {
+		return nil, errors.New(hal.ToErrorString(failures))
+	}
+	b, err := newPahoBroker(c)
+	if err != nil {
+		return nil, err
+	}
+	return newDriver(f.meta, c, b)
+}
+
+// defaultClientID returns a client id unique to this host and driver, so multiple mqtt drivers or
+// reef-pi installations can share a broker
+func defaultClientID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "reef-pi"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return "reef-pi-" + host + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,379 @@
+package drivers
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/reef-pi/hal"
+)
+
+// Parameter describes a single driver configuration parameter
+//
+// swagger:model driverParameter
+type Parameter struct {
+	Name string `json:"name"`
+	// Type is one of string, integer, decimal or boolean
+	Type        string      `json:"type"`
+	Order       int         `json:"order"`
+	Default     interface{} `json:"default"`
+	Required    bool        `json:"required"`
+	Min         *float64    `json:"min,omitempty"`
+	Max         *float64    `json:"max,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+	Description string      `json:"description,omitempty"`
+	// JSON is true for string parameters that also accept a json array or object
+	JSON bool `json:"json,omitempty"`
+}
+
+// Schema describes the configuration of a driver type
+//
+// swagger:model driverSchema
+type Schema struct {
+	Type         string      `json:"type"`
+	Description  string      `json:"description"`
+	Capabilities []string    `json:"capabilities"`
+	Parameters   []Parameter `json:"parameters"`
+}
+
+// hint adds the details hal.ConfigParameter does not carry
+type hint struct {
+	description string
+	required    bool
+	min, max    *float64
+	enum        []string
+	json        bool
+}
+
+func between(min, max float64) (*float64, *float64) {
+	return &min, &max
+}
+
+func i2cAddress(description string) hint {
+	h := hint{description: description, required: true}
+	h.min, h.max = between(0x03, 0x77)
+	return h
+}
+
+func atLeast(description string, min float64, required bool) hint {
+	return hint{description: description, min: &min, required: required}
+}
+
+func ranged(description string, min, max float64, required bool) hint {
+	h := hint{description: description, required: required}
+	h.min, h.max = between(min, max)
+	return h
+}
+
+var adsGains = []string{"2/3", "1", "2", "4", "8", "16"}
+
+var adsHints = map[string]hint{
+	"Gain 1": {description: "Programmable gain of channel 1", required: true, enum: adsGains},
+	"Gain 2": {description: "Programmable gain of channel 2", required: true, enum: adsGains},
+	"Gain 3": {description: "Programmable gain of channel 3", required: true, enum: adsGains},
+	"Gain 4": {description: "Programmable gain of channel 4", required: true, enum: adsGains},
+}
+
+// parameterHints override the hints derived from a driver's parameters, keyed by driver type and
+// parameter name. Only details that can not be derived from hal.ConfigParameter belong here
+var parameterHints = map[string]map[string]hint{
+	"ads1015": adsHints,
+	"ads1115": adsHints,
+	"dli-wpsp": {
+		"Address":  {description: "Host of the web power switch", required: true},
+		"Username": {description: "Web power switch login", required: true},
+		"Password": {description: "Web power switch password", required: true},
+	},
+	"esp32": {
+		"Address":        {description: "Host of the esp32 board", required: true},
+		"Digital-Output": atLeast("Number of digital outputs", 0, false),
+		"Digital-Input":  atLeast("Number of digital inputs", 0, false),
+		"Pwm":            atLeast("Number of pwm channels", 0, false),
+		"Analog-Input":   atLeast("Number of analog inputs", 0, false),
+	},
+	"http": {
+		"Timeout":       ranged("Request timeout in seconds", 0.1, 60, false),
+		"Outputs":       {description: "Digital output request templates", json: true},
+		"Inputs":        {description: "Digital input requests with json_path or regex", json: true},
+		"Analog Inputs": {description: "Analog input requests with json_path or regex", json: true},
+	},
+	"modbus": {
+		"Transport":      {description: "tcp or rtu", required: true, enum: []string{"tcp", "rtu"}},
+		"Address":        {description: "host:port for tcp, serial device for rtu", required: true},
+		"Baud Rate":      atLeast("Serial baud rate, rtu only", 1, false),
+		"Unit":           ranged("Modbus unit (slave) id", 0, 247, false),
+		"Timeout":        ranged("Request timeout in seconds", 0.1, 60, false),
+		"Coil Start":     ranged("Address of the first coil", 0, 65535, false),
+		"Coil Count":     ranged("Number of coils exposed as digital outputs", 0, 2000, false),
+		"Discrete Start": ranged("Address of the first discrete input", 0, 65535, false),
+		"Discrete Count": ranged("Number of discrete inputs exposed as digital inputs", 0, 2000, false),
+		"Registers":      {description: "Comma separated register specs exposed as analog inputs"},
+	},
+	"mqtt": {
+		"Server":        {description: "Broker url, e.g. tcp://host:1883", required: true},
+		"Username":      {description: "Broker username"},
+		"Password":      {description: "Broker password"},
+		"Client ID":     {description: "MQTT client id, must be unique per broker"},
+		"QoS":           ranged("Quality of service of published and subscribed messages", 0, 2, false),
+		"Retained":      {description: "Publish commands as retained messages"},
+		"Outputs":       {description: "Comma separated command topics"},
+		"Inputs":        {description: "Comma separated state topics"},
+		"Analog Inputs": {description: "Comma separated topics, topic#field.path extracts a json field"},
+		"On Payload":    {description: "Payload published to turn outputs on"},
+		"Off Payload":   {description: "Payload published to turn outputs off"},
+		"Stale After":   atLeast("Seconds after which state is considered stale, 0 disables", 0, false),
+		"Output States": {description: "Comma separated state topics of outputs, in the same order"},
+	},
+	"pca9685": {
+		"Frequency": ranged("PWM frequency in Hz", 24, 1526, true),
+	},
+	"rpi": {
+		"Frequency": atLeast("PWM frequency in Hz", 1, false),
+		"Dev Mode":  {description: "Simulate GPIO and PWM peripherals"},
+	},
+	"tasmota-http": {
+		"Address": {description: "Host of the tasmota device", required: true},
+		"Output":  atLeast("Relay number", 0, true),
+	},
+	"virtual": {
+		"Digital Outputs": ranged("Number of simulated digital outputs", 0, 64, false),
+		"Digital Inputs":  ranged("Number of simulated digital inputs", 0, 64, false),
+		"Channels":        ranged("Number of simulated pwm channels", 0, 64, false),
+		"Analog Inputs":   ranged("Number of simulated analog inputs", 0, 64, false),
+	},
+}
+
+// parameterHint returns the hint of a driver parameter. Addresses and paths locate the device and are
+// required, integer addresses are i2c addresses
+func parameterHint(t string, p hal.ConfigParameter) hint {
+	if h, ok := parameterHints[t][p.Name]; ok {
+		return h
+	}
+	switch p.Name {
+	case "Address":
+		if p.Type == hal.Integer {
+			return i2cAddress("I2C address of the device")
+		}
+		return hint{description: "Network address of the device", required: true}
+	case "Path":
+		return hint{description: "Path of the device file", required: true}
+	}
+	return hint{}
+}
+
+func parameterType(t hal.ConfigParameterType) string {
+	switch t {
+	case hal.Integer:
+		return "integer"
+	case hal.Decimal:
+		return "decimal"
+	case hal.Boolean:
+		return "boolean"
+	}
+	return "string"
+}
+
+// DriverSchema returns the configuration schema of a driver type
+func DriverSchema(t string) (Schema, error) {
+	f, err := AbstractFactory(t)
+	if err != nil {
+		return Schema{}, err
+	}
+	meta := f.Metadata()
+	s := Schema{
+		Type:         t,
+		Description:  meta.Description,
+		Capabilities: []string{},
+		Parameters:   []Parameter{},
+	}
+	for _, c := range meta.Capabilities {
+		s.Capabilities = append(s.Capabilities, c.String())
+	}
+	for _, p := range f.GetParameters() {
+		h := parameterHint(t, p)
+		s.Parameters = append(s.Parameters, Parameter{
+			Name:        p.Name,
+			Type:        parameterType(p.Type),
+			Order:       p.Order,
+			Default:     p.Default,
+			Required:    h.required,
+			Min:         h.min,
+			Max:         h.max,
+			Enum:        h.enum,
+			Description: h.description,
+			JSON:        h.json,
+		})
+	}
+	sort.Slice(s.Parameters, func(i, j int) bool { return s.Parameters[i].Order < s.Parameters[j].Order })
+	return s, nil
+}
+
+// DriverSchemas returns the configuration schemas of all driver types, except the built-in raspberry pi driver
+func DriverSchemas() (map[string]Schema, error) {
+	schemas := make(map[string]Schema)
+	for t := range driversMap {
+		if t == _rpi {
+			continue
+		}
+		s, err := DriverSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		schemas[t] = s
+	}
+	return schemas, nil
+}
+
+// Validate checks parameters against the schema, returning failures keyed by parameter name. Unknown
+// parameters, e.g. left over in older configurations, are logged and ignored
+func (s Schema) Validate(params map[string]interface{}) map[string][]string {
+	failures := make(map[string][]string)
+	known := make(map[string]bool)
+	for _, p := range s.Parameters {
+		known[strings.ToLower(p.Name)] = true
+		v, ok := lookupParam(params, p.Name)
+		if !ok {
+			if p.Required {
+				failures[p.Name] = append(failures[p.Name], p.Name+" is required")
+			}
+			continue
+		}
+		if err := p.check(v); err != nil {
+			failures[p.Name] = append(failures[p.Name], err.Error())
+		}
+	}
+	for k := range params {
+		if !known[strings.ToLower(k)] {
+			log.Println("driver-subsystem: ignoring unknown parameter", k, "of", s.Type, "driver")
+		}
+	}
+	return failures
+}
+
+// lookupParam matches names case insensitively, parseParams title cases user supplied keys
+func lookupParam(params map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := params[name]; ok {
+		return v, true
+	}
+	for k, v := range params {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (p Parameter) check(v interface{}) error {
+	var n float64
+	switch p.Type {
+	case "integer":
+		i, ok := hal.ConvertToInt(v)
+		if !ok {
+			return fmt.Errorf("%s should be an integer", p.Name)
+		}
+		n = float64(i)
+	case "decimal":
+		f, ok := toFloat(v)
+		if !ok {
+			return fmt.Errorf("%s should be a number", p.Name)
+		}
+		n = f
+	case "boolean":
+		switch b := v.(type) {
+		case bool:
+		case string:
+			if _, err := strconv.ParseBool(b); err != nil {
+				return fmt.Errorf("%s should be true or false", p.Name)
+			}
+		default:
+			return fmt.Errorf("%s should be true or false", p.Name)
+		}
+		return nil
+	default:
+		s, ok := v.(string)
+		if !ok {
+			switch v.(type) {
+			case []interface{}, map[string]interface{}:
+				if p.JSON {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s should be a string", p.Name)
+		}
+		if len(p.Enum) > 0 && !contains(p.Enum, s) {
+			return fmt.Errorf("%s should be one of %s", p.Name, strings.Join(p.Enum, ", "))
+		}
+		return nil
+	}
+	if p.Min != nil && n < *p.Min {
+		return fmt.Errorf("%s should be at least %v", p.Name, *p.Min)
+	}
+	if p.Max != nil && n > *p.Max {
+		return fmt.Errorf("%s should be at most %v", p.Name, *p.Max)
+	}
+	return nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch f := v.(type) {
+	case float64:
+		return f, true
+	case float32:
+		return float64(f), true
+	case string:
+		n, err := strconv.ParseFloat(f, 64)
+		return n, err == nil
+	}
+	i, ok := hal.ConvertToInt(v)
+	return float64(i), ok
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a driver configuration against its type's schema and the driver's own
+// validation, without instantiating it. Failures are keyed by parameter name
+func Validate(d1 Driver) (map[string][]string, error) {
+	factory, err := AbstractFactory(d1.Type)
+	if err != nil {
+		return nil, err
+	}
+	s, err := DriverSchema(d1.Type)
+	if err != nil {
+		return nil, err
+	}
+	params := d1.Parameters
+	if params == nil {
+		params = parseParams(d1.Config)
+	}
+	failures := s.Validate(params)
+	_, errs := factory.ValidateParameters(params)
+	for k, v := range errs {
+		// schema failures are more descriptive, avoid reporting the same parameter twice
+		if _, ok := failures[k]; !ok {
+			failures[k] = v
+		}
+	}
+	return failures, nil
+}
+
+// FailureError flattens validation failures into a single error, nil when there are none
+func FailureError(failures map[string][]string) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	var msgs []string
+	for _, v := range failures {
+		msgs = append(msgs, v...)
+	}
+	sort.Strings(msgs)
+	return fmt.Errorf("invalid driver parameters: %s", strings.Join(msgs, "; "))
+}
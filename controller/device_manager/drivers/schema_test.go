@@ -0,0 +1,128 @@
+package drivers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestDriverSchema(t *testing.T) {
+	schemas, err := DriverSchemas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schemas[_rpi]; ok {
+		t.Error("rpi driver should not be listed")
+	}
+	for typ, s := range schemas {
+		if len(s.Capabilities) == 0 {
+			t.Error("expected capabilities for", typ)
+		}
+		for _, p := range s.Parameters {
+			if p.Description == "" {
+				t.Error("expected description for parameter", p.Name, "of", typ)
+			}
+		}
+	}
+	// hints only refine parameters the factories declare
+	for typ, hints := range parameterHints {
+		f, err := AbstractFactory(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		declared := make(map[string]bool)
+		for _, p := range f.GetParameters() {
+			declared[p.Name] = true
+		}
+		for name := range hints {
+			if !declared[name] {
+				t.Error("hint for undeclared parameter", name, "of", typ)
+			}
+		}
+	}
+	if p := schemas["sht31d"].Parameters[0]; !p.Required || p.Min == nil || *p.Min != 0x03 {
+		t.Error("expected integer address to be derived as a required i2c address", p)
+	}
+	s := schemas["pca9685"]
+	if len(s.Parameters) != 2 || s.Parameters[0].Name != "Address" || !s.Parameters[0].Required || s.Parameters[0].Type != "integer" {
+		t.Error("unexpected pca9685 schema", s.Parameters)
+	}
+	if _, err := DriverSchema("foo"); err == nil {
+		t.Error("expected error for unknown driver type")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, d1 := range []Driver{
+		{Type: "pca9685", Parameters: map[string]interface{}{"Address": 64, "Frequency": 1500}},
+		{Type: "sht31d", Config: []byte(`{"address":68}`)},
+		{Type: "modbus", Config: []byte(`{"transport":"rtu","address":"/dev/ttyUSB0","baud rate":19200}`)},
+		{Type: "http", Parameters: map[string]interface{}{"Outputs": []interface{}{map[string]interface{}{"url": "http://localhost/on"}}}},
+		// unknown parameters of older configurations are ignored
+		{Type: "sht31d", Config: []byte(`{"address":68,"adress":68}`)},
+		{Type: _rpi, Config: []byte(`{"frequency":150,"Dev Mode":true,"pwm_freq":150}`)},
+	} {
+		failures, err := Validate(d1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(failures) > 0 {
+			t.Error("expected", d1.Type, "to be valid", failures)
+		}
+	}
+	for _, tc := range []struct {
+		d1    Driver
+		param string
+	}{
+		{Driver{Type: "pca9685", Parameters: map[string]interface{}{"Address": 64}}, "Frequency"},
+		{Driver{Type: "pca9685", Parameters: map[string]interface{}{"Address": 200, "Frequency": 150}}, "Address"},
+		{Driver{Type: "pca9685", Parameters: map[string]interface{}{"Address": "0x40", "Frequency": 150}}, "Address"},
+		{Driver{Type: "modbus", Config: []byte(`{"transport":"udp","address":"host:502"}`)}, "Transport"},
+		{Driver{Type: "mqtt", Config: []byte(`{"server":"tcp://host:1883","outputs":"a","retained":"maybe"}`)}, "Retained"},
+		{Driver{Type: "virtual", Config: []byte(`{"channels":1.5}`)}, "Channels"},
+	} {
+		failures, err := Validate(tc.d1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := failures[tc.param]; !ok {
+			t.Error("expected failure for", tc.param, "of", tc.d1.Type, "found", failures)
+		}
+	}
+	if _, err := Validate(Driver{Type: "foo"}); err == nil {
+		t.Error("expected error for unknown driver type")
+	}
+}
+
+func TestDriverSchemaAPI(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	d := TestDrivers(store)
+	tr := utils.NewTestRouter()
+	d.LoadAPI(tr.Router)
+
+	if err := tr.Do("GET", "/api/drivers/schemas", new(bytes.Buffer), nil); err != nil {
+		t.Error(err)
+	}
+	var s Schema
+	if err := tr.Do("GET", "/api/drivers/schemas/mqtt", new(bytes.Buffer), &s); err != nil {
+		t.Error(err)
+	}
+	if s.Type != "mqtt" || len(s.Parameters) == 0 {
+		t.Error("unexpected schema", s)
+	}
+	if err := tr.Do("GET", "/api/drivers/schemas/foo", new(bytes.Buffer), nil); err == nil {
+		t.Error("expected unknown driver type to fail")
+	}
+	if err := d.Create(Driver{Name: "pwm", Type: "pca9685", Config: []byte(`{"address":64,"frequency":5000}`)}); err == nil {
+		t.Error("expected out of range frequency to be rejected before saving")
+	}
+	if ds, _ := d.List(); len(ds) != 0 {
+		t.Error("invalid driver should not be stored", ds)
+	}
+}
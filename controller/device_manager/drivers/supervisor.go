@@ -0,0 +1,194 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/telemetry"
+)
+
+const (
+	DriverUp   = "up"
+	DriverDown = "down"
+
+	// number of I/O errors reported by connectors, between two supervisor ticks, that triggers a driver probe
+	errorThreshold = 3
+	probeTimeout   = 5 * time.Second
+	minBackoff     = 10 * time.Second
+	maxBackoff     = 10 * time.Minute
+)
+
+var supervisorInterval = 10 * time.Second
+
+// driverHealth tracks state of a driver as observed by the supervisor
+type driverHealth struct {
+	name      string
+	state     string
+	lastError string
+	since     time.Time
+	failures  int
+	errors    int
+	retryAt   time.Time
+}
+
+func backoff(failures int) time.Duration {
+	b := minBackoff
+	for i := 1; i < failures && b < maxBackoff; i++ {
+		b *= 2
+	}
+	if b > maxBackoff {
+		return maxBackoff
+	}
+	return b
+}
+
+// State returns the supervisor state and last error of a driver
+func (d *Drivers) State(id string) (string, string) {
+	d.Lock()
+	defer d.Unlock()
+	h, ok := d.health[id]
+	if !ok {
+		if _, registered := d.drivers[id]; registered {
+			return DriverUp, ""
+		}
+		return "", ""
+	}
+	return h.state, h.lastError
+}
+
+func (d *Drivers) markUp(id, name string) {
+	d.Lock()
+	h, ok := d.health[id]
+	d.health[id] = &driverHealth{name: name, state: DriverUp, since: time.Now()}
+	d.Unlock()
+	d.emitState(id, name, true)
+	if ok && h.state == DriverDown {
+		log.Println("driver-subsystem: driver", name, "is back up")
+		d.t.Alert("Driver recovered: "+name, fmt.Sprintf("driver %s (id: %s) is back up after %s", name, id, time.Since(h.since).Round(time.Second)))
+	}
+}
+
+// markDown closes and unregisters a failed driver, and schedules its re-initialization
+func (d *Drivers) markDown(id, name string, err error) {
+	d.Lock()
+	if dr, ok := d.drivers[id]; ok {
+		if cErr := dr.Close(); cErr != nil {
+			log.Println("driver-subsystem: Failed to close driver:", name, "Error:", cErr)
+		}
+		delete(d.drivers, id)
+	}
+	h, ok := d.health[id]
+	wasDown := ok && h.state == DriverDown
+	if !wasDown {
+		h = &driverHealth{name: name, state: DriverDown, since: time.Now()}
+		d.health[id] = h
+	}
+	h.failures++
+	h.errors = 0
+	h.lastError = err.Error()
+	h.retryAt = time.Now().Add(backoff(h.failures))
+	d.Unlock()
+
+	d.t.DriverError(id)
+	if wasDown {
+		return
+	}
+	d.emitState(id, name, false)
+	log.Println("ERROR: driver-subsystem: driver", name, "is down. Error:", err)
+	d.t.LogError("driver-subsystem", "driver "+name+" is down. Error: "+err.Error())
+	d.t.Alert("Driver down: "+name, fmt.Sprintf("driver %s (id: %s) is down. Error: %s", name, id, err.Error()))
+}
+
+func (d *Drivers) emitState(id, name string, up bool) {
+	v := 0.0
+	if up {
+		v = 1
+	}
+	d.t.EmitEntityMetric(telemetry.EntityMetric{Module: "drivers", ID: id, Name: name, Metric: "up"}, v)
+}
+
+// Supervise periodically re-initializes failed drivers with exponential backoff, and probes drivers
+// that reported I/O errors, until Close is called. Drivers that can not be probed are re-initialized
+// once they report errorThreshold errors
+func (d *Drivers) Supervise() {
+	ticker := time.NewTicker(supervisorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case now := <-ticker.C:
+			d.supervise(now)
+		}
+	}
+}
+
+func (d *Drivers) supervise(now time.Time) {
+	var suspect []string
+	retry := make(map[string]string)
+	d.Lock()
+	for id, h := range d.health {
+		switch {
+		case h.state == DriverDown && !now.Before(h.retryAt):
+			retry[id] = h.name
+		case h.state == DriverUp && h.errors >= errorThreshold:
+			suspect = append(suspect, id)
+		case h.state == DriverUp:
+			h.errors = 0
+		}
+	}
+	d.Unlock()
+
+	for id, name := range retry {
+		if err := d.reconnect(id); err != nil {
+			d.markDown(id, name, err)
+			continue
+		}
+		d.markUp(id, name)
+	}
+	for _, id := range suspect {
+		d.Lock()
+		dr, ok := d.drivers[id]
+		h := d.health[id]
+		d.Unlock()
+		if !ok {
+			continue
+		}
+		done := make(chan error, 1)
+		go func() { done <- probe(dr) }()
+		var err error
+		select {
+		case err = <-done:
+		case <-time.After(probeTimeout):
+			err = fmt.Errorf("no response within %s", probeTimeout)
+		}
+		if errors.Is(err, errNoProbe) {
+			// without a probe, repeated I/O errors are the only signal, re-initialize the driver
+			err = fmt.Errorf("%d I/O errors reported", errorThreshold)
+		}
+		if err != nil {
+			d.markDown(id, h.name, err)
+			continue
+		}
+		d.Lock()
+		h.errors = 0
+		d.Unlock()
+	}
+}
+
+func (d *Drivers) reconnect(id string) error {
+	if id == _rpi {
+		return d.loadRpi()
+	}
+	var d1 Driver
+	if err := d.store.Get(DriverBucket, id, &d1); err != nil {
+		return err
+	}
+	f, err := AbstractFactory(d1.Type)
+	if err != nil {
+		return err
+	}
+	return d.register(d1, f)
+}
@@ -0,0 +1,161 @@
+package drivers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/hal"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/telemetry"
+)
+
+type flakyDriver struct {
+	f *flakyFactory
+}
+
+func (d *flakyDriver) Close() error { return nil }
+func (d *flakyDriver) Metadata() hal.Metadata {
+	return hal.Metadata{Name: "flaky", Capabilities: []hal.Capability{hal.DigitalOutput}}
+}
+func (d *flakyDriver) Pins(hal.Capability) ([]hal.Pin, error) { return nil, nil }
+func (d *flakyDriver) Ping() error {
+	if d.f.fail {
+		return fmt.Errorf("unreachable")
+	}
+	return nil
+}
+
+type flakyFactory struct {
+	fail bool
+}
+
+func (f *flakyFactory) Metadata() hal.Metadata               { return hal.Metadata{Name: "flaky"} }
+func (f *flakyFactory) GetParameters() []hal.ConfigParameter { return nil }
+func (f *flakyFactory) ValidateParameters(map[string]interface{}) (bool, map[string][]string) {
+	return true, nil
+}
+func (f *flakyFactory) NewDriver(map[string]interface{}, interface{}) (hal.Driver, error) {
+	if f.fail {
+		return nil, fmt.Errorf("unreachable")
+	}
+	return &flakyDriver{f: f}, nil
+}
+
+func TestSupervisor(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	f := &flakyFactory{fail: true}
+	driversMap["flaky"] = f
+	defer delete(driversMap, "flaky")
+
+	if err := store.CreateBucket(DriverBucket); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateWithID(DriverBucket, "1", Driver{ID: "1", Name: "flaky", Type: "flaky", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	d := TestDrivers(store)
+	if state, lastErr := d.State("1"); state != DriverDown || lastErr != "unreachable" {
+		t.Error("Expected driver to be down. State:", state, "Error:", lastErr)
+	}
+	f.fail = false
+	d.supervise(time.Now())
+	if state, _ := d.State("1"); state != DriverDown {
+		t.Error("Driver should not be retried before backoff. State:", state)
+	}
+	d.supervise(time.Now().Add(minBackoff))
+	if state, _ := d.State("1"); state != DriverUp {
+		t.Error("Expected driver to be back up. State:", state)
+	}
+	dr, err := d.Get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dr.State != DriverUp {
+		t.Error("Expected driver api to report state. State:", dr.State)
+	}
+
+	f.fail = true
+	for i := 0; i < errorThreshold; i++ {
+		d.ReportError("1")
+	}
+	d.supervise(time.Now())
+	if state, _ := d.State("1"); state != DriverDown {
+		t.Error("Expected driver to be down after repeated errors. State:", state)
+	}
+	if _, err := d.DigitalOutputDriver("1"); err == nil {
+		t.Error("Failed driver should be unregistered")
+	}
+	if backoff(1) != minBackoff || backoff(2) != 2*minBackoff || backoff(100) != maxBackoff {
+		t.Error("Unexpected backoff")
+	}
+}
+
+// quietDriver can not be pinged
+type quietDriver struct{}
+
+func (d *quietDriver) Close() error { return nil }
+func (d *quietDriver) Metadata() hal.Metadata {
+	return hal.Metadata{Name: "quiet", Capabilities: []hal.Capability{hal.DigitalOutput}}
+}
+func (d *quietDriver) Pins(hal.Capability) ([]hal.Pin, error) { return nil, nil }
+
+type quietFactory struct {
+	flakyFactory
+}
+
+func (f *quietFactory) NewDriver(map[string]interface{}, interface{}) (hal.Driver, error) {
+	return &quietDriver{}, nil
+}
+
+func TestSupervisorWithoutProbe(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	driversMap["quiet"] = &quietFactory{}
+	defer delete(driversMap, "quiet")
+	if err := store.CreateBucket(DriverBucket); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateWithID(DriverBucket, "1", Driver{ID: "1", Name: "quiet", Type: "quiet", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	d := TestDrivers(store)
+	if err := d.register(Driver{ID: "1", Name: "quiet"}, &quietFactory{}); err == nil {
+		t.Error("Expected registering a taken driver id to fail")
+	}
+	for _, l := range d.Liveness(time.Second) {
+		if l.ID == "1" && l.Status != telemetry.HealthUnknown {
+			t.Error("Expected driver without probe to be reported as unknown. Status:", l.Status)
+		}
+	}
+	d.ReportError("1")
+	d.supervise(time.Now())
+	if state, _ := d.State("1"); state != DriverUp {
+		t.Error("Expected driver to stay up below error threshold. State:", state)
+	}
+	for i := 0; i < errorThreshold; i++ {
+		d.ReportError("1")
+	}
+	d.supervise(time.Now())
+	if state, _ := d.State("1"); state != DriverDown {
+		t.Error("Expected driver without probe to be re-initialized after repeated errors. State:", state)
+	}
+	d.supervise(time.Now().Add(minBackoff))
+	if state, _ := d.State("1"); state != DriverUp {
+		t.Error("Expected driver to be back up. State:", state)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Error("Expected closing drivers twice not to fail", err)
+	}
+}
@@ -0,0 +1,346 @@
+package virtual
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/reef-pi/hal"
+)
+
+// number of output writes retained by a driver
+const writesLimit = 1000
+
+// input type simulating the power drawn through a digital output
+const powerInput = "power"
+
+// Write records a value written to a digital output or pwm channel
+//
+// swagger:model virtualWrite
+type Write struct {
+	Time  time.Time `json:"time"`
+	Type  string    `json:"type"`
+	Pin   int       `json:"pin"`
+	Value float64   `json:"value"`
+}
+
+// Input sets the value of a digital or analog input, or scripts it with a waveform. Setting a
+// digital output simulates the device being switched outside of reef-pi, setting power simulates
+// the watts drawn by the device attached to a digital output while it is on
+//
+// swagger:model virtualInput
+type Input struct {
+	Type     string    `json:"type"` // digital-input, analog-input, digital-output or power
+	Pin      int       `json:"pin"`
+	Value    float64   `json:"value"`
+	Waveform *Waveform `json:"waveform,omitempty"`
+}
+
+// Driver is a simulated hal driver. Inputs are set through Set, outputs are recorded and returned by Writes
+type Driver struct {
+	sync.Mutex
+	meta     hal.Metadata
+	outputs  []*pin
+	inputs   []*pin
+	channels []*pin
+	analogs  []*pin
+	writes   []Write
+	now      func() time.Time
+}
+
+type pin struct {
+	d          *Driver
+	name       string
+	number     int
+	cap        hal.Capability
+	value      float64
+	waveform   *Waveform
+	since      time.Time
+	calibrator hal.Calibrator
+	watts      float64
+}
+
+func newDriver(meta hal.Metadata, outputs, inputs, channels, analogs int) (*Driver, error) {
+	d := &Driver{
+		meta: meta,
+		now:  time.Now,
+	}
+	cal, err := hal.CalibratorFactory([]hal.Measurement{})
+	if err != nil {
+		return nil, err
+	}
+	d.outputs = d.newPins(hal.DigitalOutput, "out", outputs, nil)
+	d.inputs = d.newPins(hal.DigitalInput, "in", inputs, nil)
+	d.channels = d.newPins(hal.PWM, "pwm", channels, nil)
+	d.analogs = d.newPins(hal.AnalogInput, "ai", analogs, cal)
+	return d, nil
+}
+
+func (d *Driver) newPins(cap hal.Capability, prefix string, n int, cal hal.Calibrator) []*pin {
+	pins := make([]*pin, n)
+	for i := range pins {
+		pins[i] = &pin{
+			d:          d,
+			name:       fmt.Sprintf("%s-%d", prefix, i),
+			number:     i,
+			cap:        cap,
+			calibrator: cal,
+		}
+	}
+	return pins
+}
+
+func (d *Driver) Metadata() hal.Metadata {
+	return d.meta
+}
+
+func (d *Driver) Close() error {
+	return nil
+}
+
+func (d *Driver) Pins(cap hal.Capability) ([]hal.Pin, error) {
+	var pins []*pin
+	switch cap {
+	case hal.DigitalOutput:
+		pins = d.outputs
+	case hal.DigitalInput:
+		pins = d.inputs
+	case hal.PWM:
+		pins = d.channels
+	case hal.AnalogInput:
+		pins = d.analogs
+	default:
+		return nil, fmt.Errorf("unsupported capability:%s", cap.String())
+	}
+	var hp []hal.Pin
+	for _, p := range pins {
+		hp = append(hp, p)
+	}
+	return hp, nil
+}
+
+func lookup(pins []*pin, n int, cap hal.Capability) (*pin, error) {
+	if n < 0 || n >= len(pins) {
+		return nil, fmt.Errorf("invalid %s pin number:%d", cap.String(), n)
+	}
+	return pins[n], nil
+}
+
+func (d *Driver) DigitalOutputPins() []hal.DigitalOutputPin {
+	var pins []hal.DigitalOutputPin
+	for _, p := range d.outputs {
+		pins = append(pins, p)
+	}
+	return pins
+}
+
+func (d *Driver) DigitalOutputPin(n int) (hal.DigitalOutputPin, error) {
+	return lookup(d.outputs, n, hal.DigitalOutput)
+}
+
+func (d *Driver) DigitalInputPins() []hal.DigitalInputPin {
+	var pins []hal.DigitalInputPin
+	for _, p := range d.inputs {
+		pins = append(pins, p)
+	}
+	return pins
+}
+
+func (d *Driver) DigitalInputPin(n int) (hal.DigitalInputPin, error) {
+	return lookup(d.inputs, n, hal.DigitalInput)
+}
+
+func (d *Driver) PWMChannels() []hal.PWMChannel {
+	var pins []hal.PWMChannel
+	for _, p := range d.channels {
+		pins = append(pins, p)
+	}
+	return pins
+}
+
+func (d *Driver) PWMChannel(n int) (hal.PWMChannel, error) {
+	return lookup(d.channels, n, hal.PWM)
+}
+
+func (d *Driver) AnalogInputPins() []hal.AnalogInputPin {
+	var pins []hal.AnalogInputPin
+	for _, p := range d.analogs {
+		pins = append(pins, p)
+	}
+	return pins
+}
+
+func (d *Driver) AnalogInputPin(n int) (hal.AnalogInputPin, error) {
+	return lookup(d.analogs, n, hal.AnalogInput)
+}
+
+// Set updates the value or waveform of an input pin
+func (d *Driver) Set(i Input) error {
+	var pins []*pin
+	var cap hal.Capability
+	switch i.Type {
+	case hal.DigitalInput.String():
+		pins, cap = d.inputs, hal.DigitalInput
+	case hal.AnalogInput.String():
+		pins, cap = d.analogs, hal.AnalogInput
+	case hal.DigitalOutput.String():
+		pins, cap = d.outputs, hal.DigitalOutput
+		if i.Waveform != nil {
+			return fmt.Errorf("digital outputs can not be scripted")
+		}
+	case powerInput:
+		if i.Waveform != nil {
+			return fmt.Errorf("power can not be scripted")
+		}
+		if i.Value < 0 {
+			return fmt.Errorf("invalid power:%f, must not be negative", i.Value)
+		}
+		p, err := lookup(d.outputs, i.Pin, hal.DigitalOutput)
+		if err != nil {
+			return err
+		}
+		d.Lock()
+		defer d.Unlock()
+		p.watts = i.Value
+		return nil
+	default:
+		return fmt.Errorf("inputs of type '%s' can not be set", i.Type)
+	}
+	p, err := lookup(pins, i.Pin, cap)
+	if err != nil {
+		return err
+	}
+	if i.Waveform != nil {
+		if err := i.Waveform.Validate(); err != nil {
+			return err
+		}
+	}
+	d.Lock()
+	defer d.Unlock()
+	p.value = i.Value
+	p.waveform = i.Waveform
+	p.since = d.now()
+	return nil
+}
+
+// Writes returns output writes recorded since the given time, oldest first
+func (d *Driver) Writes(since time.Time) []Write {
+	d.Lock()
+	defer d.Unlock()
+	writes := []Write{}
+	for _, w := range d.writes {
+		if !w.Time.Before(since) {
+			writes = append(writes, w)
+		}
+	}
+	return writes
+}
+
+func (d *Driver) record(p *pin, v float64) {
+	d.writes = append(d.writes, Write{
+		Time:  d.now(),
+		Type:  p.cap.String(),
+		Pin:   p.number,
+		Value: v,
+	})
+	if len(d.writes) > writesLimit {
+		d.writes = d.writes[len(d.writes)-writesLimit:]
+	}
+}
+
+func (p *pin) Name() string {
+	return p.name
+}
+
+func (p *pin) Number() int {
+	return p.number
+}
+
+func (p *pin) Close() error {
+	return nil
+}
+
+// current returns the pin value, evaluating its waveform if any. Caller must hold driver lock
+func (p *pin) current() float64 {
+	if p.waveform == nil {
+		return p.value
+	}
+	return p.waveform.Value(p.d.now().Sub(p.since))
+}
+
+func (p *pin) Read() (bool, error) {
+	p.d.Lock()
+	defer p.d.Unlock()
+	return p.current() >= 0.5, nil
+}
+
+func (p *pin) Write(state bool) error {
+	v := 0.0
+	if state {
+		v = 1
+		if p.cap == hal.PWM {
+			v = 100
+		}
+	}
+	return p.Set(v)
+}
+
+// ReadState reports the simulated device state of a digital output
+func (p *pin) ReadState() (bool, error) {
+	p.d.Lock()
+	defer p.d.Unlock()
+	return p.value > 0, nil
+}
+
+// ReadPower reports the simulated watts drawn through a digital output, zero while it is off
+func (p *pin) ReadPower() (float64, error) {
+	p.d.Lock()
+	defer p.d.Unlock()
+	if p.value > 0 {
+		return p.watts, nil
+	}
+	return 0, nil
+}
+
+func (p *pin) LastState() bool {
+	p.d.Lock()
+	defer p.d.Unlock()
+	return p.value > 0
+}
+
+func (p *pin) Set(v float64) error {
+	if p.cap == hal.PWM && (v < 0 || v > 100) {
+		return fmt.Errorf("invalid pwm value:%f, must be between 0 and 100", v)
+	}
+	p.d.Lock()
+	defer p.d.Unlock()
+	p.value = v
+	p.d.record(p, v)
+	return nil
+}
+
+func (p *pin) Value() (float64, error) {
+	p.d.Lock()
+	defer p.d.Unlock()
+	return p.current(), nil
+}
+
+func (p *pin) Measure() (float64, error) {
+	p.d.Lock()
+	defer p.d.Unlock()
+	if p.calibrator == nil {
+		return 0, fmt.Errorf("Not calibrated")
+	}
+	return p.calibrator.Calibrate(p.current()), nil
+}
+
+func (p *pin) Calibrate(points []hal.Measurement) error {
+	cal, err := hal.CalibratorFactory(points)
+	if err != nil {
+		return err
+	}
+	p.d.Lock()
+	p.calibrator = cal
+	p.d.Unlock()
+	return nil
+}
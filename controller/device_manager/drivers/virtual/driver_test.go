@@ -0,0 +1,103 @@
+package virtual
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reef-pi/hal"
+)
+
+func TestVirtualDriver(t *testing.T) {
+	f := Factory()
+	params := map[string]interface{}{
+		"Digital Outputs": 2,
+		"Digital Inputs":  1,
+		"Channels":        1,
+	}
+	if valid, _ := f.ValidateParameters(map[string]interface{}{"Channels": 100}); valid {
+		t.Error("Expected out of range pin count to fail validation")
+	}
+	hd, err := f.NewDriver(params, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := hd.(*Driver)
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	if len(d.DigitalOutputPins()) != 2 || len(d.AnalogInputPins()) != 4 {
+		t.Error("Unexpected pin counts")
+	}
+
+	out, err := d.DigitalOutputPin(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Write(true); err != nil {
+		t.Error(err)
+	}
+	ch, err := d.PWMChannel(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.Set(42.5); err != nil {
+		t.Error(err)
+	}
+	if err := ch.Set(142); err == nil {
+		t.Error("Expected invalid pwm value to fail")
+	}
+	writes := d.Writes(time.Time{})
+	if len(writes) != 2 {
+		t.Fatal("Expected two recorded writes, found:", len(writes))
+	}
+	if writes[0].Type != hal.DigitalOutput.String() || writes[0].Pin != 1 || writes[0].Value != 1 {
+		t.Error("Unexpected digital write:", writes[0])
+	}
+	if writes[1].Type != hal.PWM.String() || writes[1].Value != 42.5 {
+		t.Error("Unexpected pwm write:", writes[1])
+	}
+
+	in, err := d.DigitalInputPin(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set(Input{Type: "digital-input", Pin: 0, Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := in.Read(); !v {
+		t.Error("Expected digital input to be high")
+	}
+	if err := d.Set(Input{Type: "digital-input", Pin: 3}); err == nil {
+		t.Error("Expected invalid pin to fail")
+	}
+
+	ai, err := d.AnalogInputPin(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Waveform{Type: Ramp, Min: 20, Max: 30, Period: 100}
+	if err := d.Set(Input{Type: "analog-input", Pin: 2, Waveform: w}); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(50 * time.Second)
+	if v, _ := ai.Value(); v != 25 {
+		t.Error("Expected ramp to be half way, found:", v)
+	}
+	if err := d.Set(Input{Type: "analog-input", Pin: 2, Waveform: &Waveform{Type: "triangle"}}); err == nil {
+		t.Error("Expected unknown waveform to fail")
+	}
+}
+
+func TestWaveform(t *testing.T) {
+	sq := Waveform{Type: Square, Min: 0, Max: 1, Period: 10}
+	if sq.Value(2*time.Second) != 1 || sq.Value(7*time.Second) != 0 {
+		t.Error("Unexpected square waveform values")
+	}
+	seq := Waveform{Type: Sequence, Period: 1, Values: []float64{7.9, 8.1, 8.3}}
+	if seq.Value(4*time.Second) != 8.1 {
+		t.Error("Unexpected sequence value:", seq.Value(4*time.Second))
+	}
+	sine := Waveform{Type: Sine, Min: 0, Max: 2, Period: 4}
+	if v := sine.Value(time.Second); v != 2 {
+		t.Error("Expected sine peak, found:", v)
+	}
+}
@@ -0,0 +1,124 @@
+package virtual
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/reef-pi/hal"
+)
+
+const (
+	outputsParam  = "Digital Outputs"
+	inputsParam   = "Digital Inputs"
+	channelsParam = "Channels"
+	analogParam   = "Analog Inputs"
+
+	maxPins = 64
+)
+
+type factory struct {
+	meta       hal.Metadata
+	parameters []hal.ConfigParameter
+}
+
+var virtualFactory *factory
+var once sync.Once
+
+// Factory returns a singleton virtual driver factory
+func Factory() hal.DriverFactory {
+	once.Do(func() {
+		virtualFactory = &factory{
+			meta: hal.Metadata{
+				Name:        "virtual",
+				Description: "Simulated driver with settable or scripted inputs, and recorded outputs",
+				Capabilities: []hal.Capability{
+					hal.DigitalOutput, hal.DigitalInput, hal.PWM, hal.AnalogInput,
+				},
+			},
+			parameters: []hal.ConfigParameter{
+				{
+					Name:    outputsParam,
+					Type:    hal.Integer,
+					Order:   0,
+					Default: 8,
+				},
+				{
+					Name:    inputsParam,
+					Type:    hal.Integer,
+					Order:   1,
+					Default: 4,
+				},
+				{
+					Name:    channelsParam,
+					Type:    hal.Integer,
+					Order:   2,
+					Default: 4,
+				},
+				{
+					Name:    analogParam,
+					Type:    hal.Integer,
+					Order:   3,
+					Default: 4,
+				},
+			},
+		}
+	})
+	return virtualFactory
+}
+
+func (f *factory) Metadata() hal.Metadata {
+	return f.meta
+}
+
+func (f *factory) GetParameters() []hal.ConfigParameter {
+	return f.parameters
+}
+
+func (f *factory) ValidateParameters(parameters map[string]interface{}) (bool, map[string][]string) {
+	var failures = make(map[string][]string)
+	for _, p := range f.parameters {
+		v, ok := parameters[p.Name]
+		if !ok {
+			continue
+		}
+		val, ok := hal.ConvertToInt(v)
+		if !ok {
+			failure := fmt.Sprint(p.Name, " is not a number. ", v, " was received.")
+			failures[p.Name] = append(failures[p.Name], failure)
+			continue
+		}
+		if val < 0 || val > maxPins {
+			failure := fmt.Sprint(p.Name, " is out of range (0 - ", maxPins, "). ", v, " was received.")
+			failures[p.Name] = append(failures[p.Name], failure)
+		}
+	}
+	return len(failures) == 0, failures
+}
+
+// count returns the number of pins configured for a parameter, falling back on its default
+func (f *factory) count(parameters map[string]interface{}, name string) int {
+	if v, ok := parameters[name]; ok {
+		n, _ := hal.ConvertToInt(v)
+		return n
+	}
+	for _, p := range f.parameters {
+		if p.Name == name {
+			return p.Default.(int)
+		}
+	}
+	return 0
+}
+
+func (f *factory) NewDriver(parameters map[string]interface{}, _ interface{}) (hal.Driver, error) {
+	if valid, failures := f.ValidateParameters(parameters); !valid {
+		return nil, errors.New(hal.ToErrorString(failures))
+	}
+	return newDriver(
+		f.meta,
+		f.count(parameters, outputsParam),
+		f.count(parameters, inputsParam),
+		f.count(parameters, channelsParam),
+		f.count(parameters, analogParam),
+	)
+}
@@ -0,0 +1,76 @@
+package virtual
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	Sine     = "sine"
+	Square   = "square"
+	Ramp     = "ramp"
+	Sequence = "sequence"
+	Noise    = "noise"
+)
+
+// Waveform scripts the value of an input over time. Period is in seconds.
+// Digital inputs read high when the waveform value is 0.5 or more.
+//
+// swagger:model virtualWaveform
+type Waveform struct {
+	Type   string    `json:"type"`
+	Min    float64   `json:"min"`
+	Max    float64   `json:"max"`
+	Period float64   `json:"period"`
+	Values []float64 `json:"values"` // sequence steps, each lasting one period
+}
+
+func (w Waveform) Validate() error {
+	switch w.Type {
+	case Noise:
+		return nil
+	case Sine, Square, Ramp:
+		if w.Period <= 0 {
+			return fmt.Errorf("period of %s waveform must be positive", w.Type)
+		}
+	case Sequence:
+		if w.Period <= 0 {
+			return fmt.Errorf("period of sequence waveform must be positive")
+		}
+		if len(w.Values) == 0 {
+			return fmt.Errorf("sequence waveform requires at least one value")
+		}
+	default:
+		return fmt.Errorf("unknown waveform type: '%s'", w.Type)
+	}
+	return nil
+}
+
+// Value returns the waveform value after elapsed time since the waveform was set
+func (w Waveform) Value(elapsed time.Duration) float64 {
+	amplitude := w.Max - w.Min
+	var phase float64
+	if w.Period > 0 {
+		phase = math.Mod(elapsed.Seconds(), w.Period) / w.Period
+	}
+	switch w.Type {
+	case Sine:
+		return w.Min + amplitude*(1+math.Sin(2*math.Pi*phase))/2
+	case Square:
+		if phase < 0.5 {
+			return w.Max
+		}
+		return w.Min
+	case Ramp:
+		return w.Min + amplitude*phase
+	case Sequence:
+		step := int(elapsed.Seconds()/w.Period) % len(w.Values)
+		return w.Values[step]
+	case Noise:
+		return w.Min + amplitude*rand.Float64()
+	default:
+		return w.Min
+	}
+}
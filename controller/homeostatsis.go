@@ -63,6 +63,8 @@ func (o1 Observation) Before(o2 telemetry.Metric) bool {
 
 type HomeoStasisConfig struct {
 	Name       string
+	Module     string
+	ID         string
 	IsMacro    bool
 	Period     int
 	Upper      string
@@ -113,7 +115,13 @@ func (h *Homeostasis) Sub() Subsystem {
 }
 
 func (h *Homeostasis) EmitMetric(m string, v float64) {
-	h.t.EmitMetric(h.config.Name, m, v)
+	h.t.EmitEntityMetric(telemetry.EntityMetric{
+		Module: h.config.Module,
+		ID:     h.config.ID,
+		Name:   h.config.Name,
+		Metric: m,
+		Feed:   h.config.Name + "-" + m,
+	}, v)
 }
 
 func (h *Homeostasis) Sync(o *Observation) error {
@@ -123,6 +131,7 @@ func (h *Homeostasis) Sync(o *Observation) error {
 		if err := h.down(); err != nil {
 			return err
 		}
+		h.t.ControlAction(h.config.Module, h.config.ID, "down")
 		o.Downer += h.config.Period
 		h.pastTarget = downerTarget
 	case (o.Value < h.config.Min) && (h.config.Upper != ""):
@@ -130,6 +139,7 @@ func (h *Homeostasis) Sync(o *Observation) error {
 		if err := h.up(); err != nil {
 			return err
 		}
+		h.t.ControlAction(h.config.Module, h.config.ID, "up")
 		o.Upper += int(h.config.Period)
 		h.pastTarget = upperTarget
 	case h.pastTarget == downerTarget && math.Abs(o.Value-h.config.Max) < h.config.Hysteresis:
@@ -27,6 +27,14 @@ type ATO struct {
 	Name           string        `json:"name"`
 	DisableOnAlert bool          `json:"disable_on_alert"`
 	OneShot        bool          `json:"one_shot"`
+	// Interlock is set while a safety shutdown holds the ato disabled
+	Interlock *Interlock `json:"interlock,omitempty"`
+}
+
+// swagger:model atoInterlock
+type Interlock struct {
+	// Resume is whether the ato is enabled again once the interlock is released
+	Resume bool `json:"resume"`
 }
 
 func (a ATO) EName() string                { return a.Name }
@@ -37,6 +45,9 @@ func (c *Controller) On(id string, b bool) error {
 	if err != nil {
 		return err
 	}
+	if b && a.Interlock != nil {
+		return fmt.Errorf("ato '%s' is disabled by a safety interlock", a.Name)
+	}
 	a.Enable = b
 	if b && a.OneShot {
 		q := make(chan struct{})
@@ -69,6 +80,7 @@ func (c *Controller) Create(a ATO) error {
 	if a.Period <= 0 {
 		return fmt.Errorf("Check period for ato controller must be greater than zero")
 	}
+	a.Interlock = nil
 	fn := func(id string) interface{} {
 		a.ID = id
 		return &a
@@ -85,7 +97,19 @@ func (c *Controller) Create(a ATO) error {
 	return nil
 }
 
+// Update changes an ato, an interlocked ato can not be enabled until the interlock is released
 func (c *Controller) Update(id string, a ATO) error {
+	a.Interlock = nil
+	if cur, err := c.Get(id); err == nil && cur.Interlock != nil {
+		if a.Enable {
+			return fmt.Errorf("ato '%s' is disabled by a safety interlock", cur.Name)
+		}
+		a.Interlock = cur.Interlock
+	}
+	return c.save(id, a)
+}
+
+func (c *Controller) save(id string, a ATO) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	a.ID = id
@@ -107,6 +131,37 @@ func (c *Controller) Update(id string, a ATO) error {
 	}
 	return nil
 }
+
+// Interlock disables an ato until released, e.g. after a leak is detected
+func (c *Controller) Interlock(id string) error {
+	a, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if a.Interlock != nil {
+		return nil
+	}
+	log.Println("ato-subsystem: interlocking ato", a.Name)
+	a.Interlock = &Interlock{Resume: a.Enable}
+	a.Enable = false
+	return c.save(id, a)
+}
+
+// Release ends an interlock, enabling the ato again if it was enabled before
+func (c *Controller) Release(id string) error {
+	a, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if a.Interlock == nil {
+		return nil
+	}
+	log.Println("ato-subsystem: releasing interlock of ato", a.Name)
+	a.Enable = a.Interlock.Resume
+	a.Interlock = nil
+	return c.save(id, a)
+}
+
 func (c *Controller) Reset(id string) error {
 	a, err := c.Get(id)
 	if err != nil {
@@ -141,6 +196,7 @@ func (c *Controller) Delete(id string) error {
 	if err := c.c.Store().Delete(UsageBucket, id); err != nil {
 		log.Println("ERROR:  ato-subsystem: Failed to deleted usage details for ato:", id)
 	}
+	c.c.Telemetry().DeleteEntityMetrics(Bucket, id)
 	quit, ok := c.quitters[id]
 	if ok {
 		close(quit)
@@ -156,13 +212,15 @@ func (c *Controller) Check(a ATO) (int, error) {
 	usage := Usage{
 		Time: telemetry.TeleTime(time.Now()),
 	}
+	start := time.Now()
 	reading, err := c.Read(a)
+	c.c.Telemetry().ObserveSensorRead(Bucket, a.ID, start, err)
 	if err != nil {
 		log.Println("ERROR: ato-subsystem. Failed to read ato sensor. Error:", err)
 		c.c.LogError("ato-"+a.ID, "Failed to read ato sensor. Name:"+a.Name+". Error:"+err.Error())
 		return 0, err
 	}
-	c.c.Telemetry().EmitMetric("ato", a.Name+"-state", float64(reading))
+	c.c.Telemetry().EmitEntityMetric(a.metric("state"), float64(reading))
 	log.Println("ato-subsystem: sensor:", a.Name, "state:", reading)
 	if a.Control {
 		if err := c.Control(a, reading); err != nil {
@@ -210,6 +268,16 @@ func (c *Controller) Read(a ATO) (int, error) {
 	return c.inlets.Read(a.Inlet)
 }
 
+func (a ATO) metric(m string) telemetry.EntityMetric {
+	return telemetry.EntityMetric{
+		Module: Bucket,
+		ID:     a.ID,
+		Name:   a.Name,
+		Metric: m,
+		Feed:   "ato-" + a.Name + "-" + m,
+	}
+}
+
 func (a ATO) CreateFeed(t telemetry.Telemetry) {
 	if a.Enable {
 		t.CreateFeedIfNotExist("ato-" + a.Name + "-state")
@@ -101,8 +101,10 @@ func (c *Controller) Control(a ATO, reading int) error {
 	}
 	switch reading {
 	case 1:
+		c.c.Telemetry().ControlAction(Bucket, a.ID, "pump-off")
 		return sub.On(a.Pump, false)
 	default:
+		c.c.Telemetry().ControlAction(Bucket, a.ID, "pump-on")
 		return sub.On(a.Pump, true)
 	}
 }
@@ -117,6 +117,25 @@ func TestController(t *testing.T) {
 	if err := c.Update("1", a1); err == nil {
 		t.Error("ATO update should fail if period is set to zero")
 	}
+	if err := c.Interlock("1"); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := c.Get("1"); a.Enable || a.Interlock == nil || !a.Interlock.Resume {
+		t.Error("expected interlock to disable the ato", a)
+	}
+	if err := c.On("1", true); err == nil {
+		t.Error("expected interlocked ato to stay disabled")
+	}
+	a.Enable = true
+	if err := c.Update("1", a); err == nil {
+		t.Error("expected interlocked ato to reject being enabled")
+	}
+	if err := c.Release("1"); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := c.Get("1"); !a.Enable || a.Interlock != nil {
+		t.Error("expected releasing the interlock to enable the ato again", a)
+	}
 	if err := tr.Do("GET", "/api/atos/1/usage", new(bytes.Buffer), nil); err != nil {
 		t.Error("Failed to get ato usage using api. Error:", err)
 	}
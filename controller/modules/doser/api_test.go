@@ -10,6 +10,7 @@ import (
 
 	"github.com/reef-pi/reef-pi/controller"
 	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/storage"
 	"github.com/reef-pi/reef-pi/controller/utils"
 )
 
@@ -114,6 +115,26 @@ func TestDoserAPI(t *testing.T) {
 	if err := tr.Do("POST", "/api/doser/pumps/1", body, nil); err != nil {
 		t.Fatal("Failed to update dosing pump using api. Error:", err)
 	}
+	if err := con.Store().CreateBucket(storage.EquipmentBucket); err != nil {
+		t.Fatal(err)
+	}
+	returnPump := func(id string) interface{} {
+		return map[string]interface{}{"id": id, "name": "Return pump", "jack": "1", "pin": 0}
+	}
+	if err := con.Store().Create(storage.EquipmentBucket, returnPump); err != nil {
+		t.Fatal(err)
+	}
+	schedule := DosingRegiment{Schedule: Schedule{"*", "*", "*", "*", "*", "*"}}
+	if err := c.Create(Pump{Name: "bad", Jack: "1", Pin: 0, Regiment: schedule}); err == nil {
+		t.Error("expected jack pin driven by an equipment to be rejected")
+	}
+	if err := c.Update("1", Pump{Name: "Bar", Jack: "1", Pin: 0, Regiment: schedule}); err == nil {
+		t.Error("expected pump moving to a jack pin driven by an equipment to be rejected")
+	}
+	owner := connectors.PinOwner{Type: connectors.EquipmentPin, Name: "Skimmer"}
+	if err := con.DM().Pins().CheckJack(owner, "1", 1); err == nil {
+		t.Error("expected jack pin driven by a doser to be rejected for other entities")
+	}
 
 	if err := c.On("1", true); err != nil {
 		t.Error(err)
@@ -27,20 +27,26 @@ type Controller struct {
 	mu       *sync.Mutex
 	runner   *cron.Cron
 	cronIDs  map[string]cron.EntryID
+	// interlocks holds pumps shut down by safety sensors, they are re-interlocked on start
+	interlocks map[string]bool
+	// stops are closed by Interlock, to abort stepper doses in progress
+	stops map[string]chan struct{}
 }
 
 func New(devMode bool, c controller.Controller) (*Controller, error) {
 	return &Controller{
-		DevMode:  devMode,
-		cronIDs:  make(map[string]cron.EntryID),
-		mu:       &sync.Mutex{},
-		runner:   cron.New(cron.WithParser(cron.NewParser(_cronParserSpec))),
-		statsMgr: c.Telemetry().NewStatsManager(UsageBucket),
-		c:        c,
+		DevMode:    devMode,
+		cronIDs:    make(map[string]cron.EntryID),
+		interlocks: make(map[string]bool),
+		stops:      make(map[string]chan struct{}),
+		mu:         &sync.Mutex{},
+		runner:     cron.New(cron.WithParser(cron.NewParser(_cronParserSpec))),
+		statsMgr:   c.Telemetry().NewStatsManager(UsageBucket),
+		c:          c,
 	}, nil
 }
 func (c *Controller) GetEntity(id string) (controller.Entity, error) {
-	return nil, fmt.Errorf("doser subsystem does not support 'GetEntity' interface")
+	return c.Get(id)
 }
 
 func (c *Controller) Stop() {
@@ -82,10 +88,18 @@ func (c *Controller) Start() {
 func (c *Controller) addToCron(p Pump) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	cronID, err := c.runner.AddJob(
-		p.Regiment.Schedule.CronSpec(),
-		p.Runner(c.c.DM(), c.c.Telemetry(), c.statsMgr),
-	)
+	if c.interlocks[p.ID] {
+		log.Println("doser-subsystem: not scheduling interlocked pump:", p.Name)
+		return nil
+	}
+	r := &Runner{
+		dm:       c.c.DM(),
+		statsMgr: c.statsMgr,
+		t:        c.c.Telemetry(),
+		pump:     &p,
+		stop:     c.stopLocked(p.ID),
+	}
+	cronID, err := c.runner.AddJob(p.Regiment.Schedule.CronSpec(), r)
 	if err != nil {
 		return err
 	}
@@ -102,13 +116,87 @@ func (c *Controller) On(id string, b bool) error {
 	if p.Regiment.Enable {
 		return errors.New("enabled doser can not be On/Off -ed")
 	}
+	if b && c.interlocked(id) {
+		return fmt.Errorf("doser '%s' is interlocked", p.Name)
+	}
 	log.Println("doser-subsystem: Switching doser :", p.Name, "to", b)
 	v := make(map[int]float64)
 	v[p.Pin] = 0
 	if b {
 		v[p.Pin] = p.Regiment.Speed
 	}
-	return c.c.DM().Jacks().Control(p.Jack, v)
+	return c.c.DM().Jacks().ControlInstantly(p.Jack, v)
+}
+
+func (c *Controller) interlocked(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.interlocks[id]
+}
+
+// stopLocked returns the channel closed when the pump gets interlocked, c.mu must be held
+func (c *Controller) stopLocked(id string) <-chan struct{} {
+	s, ok := c.stops[id]
+	if !ok {
+		s = make(chan struct{})
+		c.stops[id] = s
+	}
+	return s
+}
+
+// dosing returns the channel closed when the pump gets interlocked, or an error if it already is
+func (c *Controller) dosing(p Pump) (<-chan struct{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.interlocks[p.ID] {
+		return nil, fmt.Errorf("doser '%s' is interlocked", p.Name)
+	}
+	return c.stopLocked(p.ID), nil
+}
+
+// Interlock stops the scheduled doses of a pump and switches it off, until released. Stepper doses
+// in progress are aborted
+func (c *Controller) Interlock(id string) error {
+	p, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	log.Println("doser-subsystem: interlocking doser", p.Name)
+	c.mu.Lock()
+	c.interlocks[id] = true
+	if cID, ok := c.cronIDs[id]; ok {
+		c.runner.Remove(cID)
+		delete(c.cronIDs, id)
+	}
+	if s, ok := c.stops[id]; ok {
+		close(s)
+		delete(c.stops, id)
+	}
+	c.mu.Unlock()
+	if p.Type == "stepper" {
+		return nil
+	}
+	return c.c.DM().Jacks().ControlInstantly(p.Jack, map[int]float64{p.Pin: 0})
+}
+
+// Release ends an interlock, scheduling the doses of an enabled pump again
+func (c *Controller) Release(id string) error {
+	p, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	locked := c.interlocks[id]
+	delete(c.interlocks, id)
+	c.mu.Unlock()
+	if !locked {
+		return nil
+	}
+	log.Println("doser-subsystem: releasing interlock of doser", p.Name)
+	if p.Regiment.Enable {
+		return c.addToCron(p)
+	}
+	return nil
 }
 
 func (c *Controller) InUse(depType, id string) ([]string, error) {
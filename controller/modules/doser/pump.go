@@ -5,10 +5,7 @@ import (
 	"fmt"
 	"log"
 
-	cron "github.com/robfig/cron/v3"
-
-	"github.com/reef-pi/reef-pi/controller/device_manager"
-	"github.com/reef-pi/reef-pi/controller/telemetry"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
 )
 
 //swagger:model dosingRegiment
@@ -31,6 +28,9 @@ type Pump struct {
 	Type     string         `json:"type"`
 }
 
+func (p Pump) EName() string                { return p.Name }
+func (p Pump) Status() (interface{}, error) { return Pump{}, nil }
+
 func (p *Pump) IsValid() error {
 	if p.Name == "" {
 		return fmt.Errorf("name can not be empty")
@@ -62,10 +62,22 @@ func (c *Controller) Get(id string) (Pump, error) {
 	return p, c.c.Store().Get(Bucket, id, &p)
 }
 
-func (c *Controller) Create(p Pump) error {
+func (c *Controller) validate(p Pump) error {
 	if err := p.IsValid(); err != nil {
 		return err
 	}
+	if p.Type == "stepper" {
+		return nil
+	}
+	owner := connectors.PinOwner{Type: connectors.DoserPin, ID: p.ID, Name: p.Name}
+	return c.c.DM().Pins().CheckJack(owner, p.Jack, p.Pin)
+}
+
+func (c *Controller) Create(p Pump) error {
+	p.ID = ""
+	if err := c.validate(p); err != nil {
+		return err
+	}
 	fn := func(id string) interface{} {
 		p.ID = id
 		return &p
@@ -105,24 +117,50 @@ func (c *Controller) Calibrate(id string, cal CalibrationDetails) error {
 	if err != nil {
 		return err
 	}
+	stop, err := c.dosing(p)
+	if err != nil {
+		return err
+	}
 	r := &Runner{
 		pump: &p,
 		dm:   c.c.DM(),
 	}
 	log.Println("doser subsystem: calibration run for:", p.Name)
 	if p.Type == "stepper" && p.Stepper != nil {
-		go p.Stepper.Dose(c.c.DM().Outlets(), cal.Volume)
+		go p.Stepper.Dose(c.c.DM().Outlets(), cal.Volume, stop)
 	} else {
 		go r.PWMDose(cal.Speed, cal.Duration)
 	}
 	return nil
 }
 
-func (c *Controller) Update(id string, p Pump) error {
-	if err := p.IsValid(); err != nil {
+// Dose runs a pump outside of its schedule, at its regiment speed for duration seconds or for its
+// regiment volume if it is a stepper. Like calibration runs, manual doses are not recorded as usage
+func (c *Controller) Dose(id string, duration float64) error {
+	p, err := c.Get(id)
+	if err != nil {
 		return err
 	}
+	stop, err := c.dosing(p)
+	if err != nil {
+		return err
+	}
+	log.Println("doser subsystem: manual dose for:", p.Name)
+	if p.Type == "stepper" && p.Stepper != nil {
+		return p.Stepper.Dose(c.c.DM().Outlets(), p.Regiment.Volume, stop)
+	}
+	r := &Runner{
+		pump: &p,
+		dm:   c.c.DM(),
+	}
+	return r.PWMDose(p.Regiment.Speed, duration)
+}
+
+func (c *Controller) Update(id string, p Pump) error {
 	p.ID = id
+	if err := c.validate(p); err != nil {
+		return err
+	}
 	if err := c.c.Store().Update(Bucket, id, p); err != nil {
 		return err
 	}
@@ -166,14 +204,8 @@ func (c *Controller) Delete(id string) error {
 		log.Printf("doser sub-system. Removing cron entry %d for pump id: %s.\n", cID, id)
 		c.runner.Remove(cID)
 	}
+	delete(c.interlocks, id)
+	delete(c.stops, id)
+	c.c.Telemetry().DeleteEntityMetrics(Bucket, id)
 	return c.c.Store().Delete(Bucket, id)
 }
-
-func (p *Pump) Runner(dm *device_manager.DeviceManager, t telemetry.Telemetry, sm telemetry.StatsManager) cron.Job {
-	return &Runner{
-		dm:       dm,
-		statsMgr: sm,
-		t:        t,
-		pump:     p,
-	}
-}
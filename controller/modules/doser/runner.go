@@ -13,6 +13,8 @@ type Runner struct {
 	dm       *device_manager.DeviceManager
 	statsMgr telemetry.StatsManager
 	t        telemetry.Telemetry
+	// stop is closed when the pump is interlocked, aborting stepper doses
+	stop <-chan struct{}
 }
 
 func (r *Runner) Run() {
@@ -21,7 +23,7 @@ func (r *Runner) Run() {
 	}
 	if r.pump.Type == "stepper" && r.pump.Stepper != nil {
 		log.Println("doser sub system: running doser(stepper)", r.pump.Name, "for", r.pump.Regiment.Volume, "(ml)")
-		if err := r.pump.Stepper.Dose(r.dm.Outlets(), r.pump.Regiment.Volume); err != nil {
+		if err := r.pump.Stepper.Dose(r.dm.Outlets(), r.pump.Regiment.Volume, r.stop); err != nil {
 			log.Println("ERROR: dosing sub-system. Failed to run stepper. Error:", err)
 			return
 		}
@@ -36,19 +38,28 @@ func (r *Runner) Run() {
 	}
 	r.statsMgr.Update(r.pump.ID, usage)
 	r.statsMgr.Save(r.pump.ID)
-	r.t.EmitMetric("doser", r.pump.Name+"-usage", float64(usage.Pump))
+	r.t.ControlAction(Bucket, r.pump.ID, "dose")
+	r.t.EmitEntityMetric(telemetry.EntityMetric{
+		Module: Bucket,
+		ID:     r.pump.ID,
+		Name:   r.pump.Name,
+		Metric: "usage",
+		Feed:   "doser-" + r.pump.Name + "-usage",
+	}, float64(usage.Pump))
 	log.Println("dosing sub system: finished scheduled run for:", r.pump.Name)
 }
 
+// PWMDose runs the pump at speed for duration seconds. Jack ramp rates are ignored, since ramping
+// would change the dosed volume
 func (r *Runner) PWMDose(speed float64, duration float64) error {
 	v := make(map[int]float64)
 	v[r.pump.Pin] = speed
-	if err := r.dm.Jacks().Control(r.pump.Jack, v); err != nil {
+	if err := r.dm.Jacks().ControlInstantly(r.pump.Jack, v); err != nil {
 		return err
 	}
 	select {
 	case <-time.After(time.Duration(duration * float64(time.Second))):
 		v[r.pump.Pin] = 0
-		return r.dm.Jacks().Control(r.pump.Jack, v)
+		return r.dm.Jacks().ControlInstantly(r.pump.Jack, v)
 	}
 }
@@ -54,8 +54,8 @@ func (d *DRV8825) IsValid() error {
 	return nil
 }
 
-// go r.Dose(cal.Speed, cal.Duration)
-func (d *DRV8825) Step(outlets *connectors.Outlets, count int) error {
+// Step drives count steps, it stops early with an error once stop is closed
+func (d *DRV8825) Step(outlets *connectors.Outlets, count int, stop <-chan struct{}) error {
 	delay := d.Delay
 	if delay == 0 {
 		delay = _defaultDelay
@@ -112,6 +112,11 @@ func (d *DRV8825) Step(outlets *connectors.Outlets, count int) error {
 	}
 
 	for i := 0; i < count; i++ {
+		select {
+		case <-stop:
+			return fmt.Errorf("stepper stopped after %d of %d steps", i, count)
+		default:
+		}
 		if err := sPin.Write(true); err != nil {
 			return err
 		}
@@ -149,8 +154,8 @@ func (d *DRV8825) Microstep(outlets *connectors.Outlets, a, b, c bool) error {
 	return nil
 }
 
-func (d *DRV8825) Dose(outlets *connectors.Outlets, volume float64) error {
+func (d *DRV8825) Dose(outlets *connectors.Outlets, volume float64, stop <-chan struct{}) error {
 	steps := (volume / d.VPR) * float64(d.SPR) * 4
 	log.Println("doser sub system: Executing stepper driver. Volume: ", volume, "SPR:", d.SPR, "vpr:", d.VPR, "steps:", steps)
-	return d.Step(outlets, int(steps))
+	return d.Step(outlets, int(steps), stop)
 }
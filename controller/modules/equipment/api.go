@@ -1,6 +1,7 @@
 package equipment
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -115,6 +116,339 @@ func (e *Controller) LoadAPI(r *mux.Router) {
 	//  200:
 	//   description: OK
 	r.HandleFunc("/api/equipment/{id}/control", e.control).Methods("POST")
+
+	// swagger:operation POST /api/equipment/{id}/speed Equipment equipmentSpeed
+	// Set the speed of an equipment.
+	// Run a variable speed equipment, driven by a jack pin, at a percentage. A speed of 0 turns it off.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the equipment
+	//    required: true
+	//    schema:
+	//     type: integer
+	//  - in: body
+	//    name: speed
+	//    description: The speed to run at
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/equipmentSpeed'
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/equipment/{id}/speed", e.speed).Methods("POST")
+
+	// swagger:operation GET /api/equipment/{id}/energy Equipment equipmentEnergy
+	// Get energy usage of an equipment.
+	// Get the latest power sample, and the daily and monthly energy usage and cost of an equipment.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the equipment
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    $ref: '#/definitions/energyReport'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/equipment/{id}/energy", e.energy).Methods("GET")
+
+	// swagger:route GET /api/equipment/energy/tariff Equipment equipmentTariffGet
+	// Get the electricity tariff.
+	// Get the electricity tariff used to estimate equipment running costs.
+	// responses:
+	// 	200: body:tariff
+	r.HandleFunc("/api/equipment/energy/tariff", e.getTariff).Methods("GET")
+
+	// swagger:operation POST /api/equipment/energy/tariff Equipment equipmentTariffUpdate
+	// Update the electricity tariff.
+	// Update the electricity tariff used to estimate equipment running costs. Costs already recorded are not changed.
+	// ---
+	// parameters:
+	//  - in: body
+	//    name: tariff
+	//    description: The electricity tariff
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/tariff'
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/equipment/energy/tariff", e.updateTariff).Methods("POST")
+
+	// swagger:operation GET /api/equipment/{id}/runtime Equipment equipmentRuntime
+	// Get runtime of an equipment.
+	// Get the cumulative on time, switch cycles and last state change of an equipment.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the equipment
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    $ref: '#/definitions/equipmentRuntime'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/equipment/{id}/runtime", e.runtime).Methods("GET")
+
+	// swagger:operation POST /api/equipment/{id}/override Equipment equipmentOverride
+	// Override an equipment.
+	// Hold an equipment in a forced state for a duration, or until the override is cleared. Timers, macros and controllers can not change it meanwhile.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the equipment to override
+	//    required: true
+	//    schema:
+	//     type: integer
+	//  - in: body
+	//    name: override
+	//    description: The forced state and duration
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/equipmentOverride'
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/equipment/{id}/override", e.override).Methods("POST")
+
+	// swagger:operation DELETE /api/equipment/{id}/override Equipment equipmentOverrideClear
+	// Clear an equipment override.
+	// Clear the override of an equipment, restoring the state it had before the override.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the equipment
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/equipment/{id}/override", e.clearOverride).Methods("DELETE")
+
+	// swagger:operation GET /api/maintenance/{id} Maintenance maintenanceGet
+	// Get a maintenance task by id.
+	// Get an existing maintenance task, with the equipment usage since it was last reset.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the maintenance task
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    $ref: '#/definitions/maintenance'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/maintenance/{id}", e.getMaintenance).Methods("GET")
+
+	// swagger:route GET /api/maintenance Maintenance maintenanceList
+	// List all maintenance tasks.
+	// List all equipment maintenance tasks, with the equipment usage since they were last reset.
+	// responses:
+	// 	200: body:[]maintenance
+	r.HandleFunc("/api/maintenance", e.listMaintenance).Methods("GET")
+
+	// swagger:operation PUT /api/maintenance Maintenance maintenanceCreate
+	// Create a maintenance task.
+	// Create a new maintenance task. Its period starts from the current equipment runtime.
+	// ---
+	// parameters:
+	//  - in: body
+	//    name: maintenance
+	//    description: The maintenance task to create
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/maintenance'
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/maintenance", e.createMaintenance).Methods("PUT")
+
+	// swagger:operation POST /api/maintenance/{id} Maintenance maintenanceUpdate
+	// Update a maintenance task.
+	// Update the name or thresholds of an existing maintenance task.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the maintenance task to update
+	//    required: true
+	//    schema:
+	//     type: integer
+	//  - in: body
+	//    name: maintenance
+	//    description: The maintenance task to update
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/maintenance'
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/maintenance/{id}", e.updateMaintenance).Methods("POST")
+
+	// swagger:operation DELETE /api/maintenance/{id} Maintenance maintenanceDelete
+	// Delete a maintenance task.
+	// Delete an existing maintenance task.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the maintenance task to delete
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/maintenance/{id}", e.deleteMaintenance).Methods("DELETE")
+
+	// swagger:operation POST /api/maintenance/{id}/reset Maintenance maintenanceReset
+	// Reset a maintenance task.
+	// Mark a maintenance task as done, restarting its period from the current equipment runtime.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the maintenance task to reset
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/maintenance/{id}/reset", e.resetMaintenance).Methods("POST")
+
+	// swagger:route GET /api/equipment_groups EquipmentGroups equipmentGroupList
+	// List all equipment groups.
+	// List all equipment groups in reef-pi. Timers, macros and controllers address a group as equipment 'group-<id>'.
+	// responses:
+	// 	200: body:[]equipmentGroup
+	r.HandleFunc("/api/equipment_groups", e.listGroups).Methods("GET")
+
+	// swagger:operation PUT /api/equipment_groups EquipmentGroups equipmentGroupCreate
+	// Create an equipment group.
+	// Create a new equipment group.
+	// ---
+	// parameters:
+	//  - in: body
+	//    name: group
+	//    description: The equipment group to create
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/equipmentGroup'
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/equipment_groups", e.createGroup).Methods("PUT")
+
+	// swagger:operation GET /api/equipment_groups/{id} EquipmentGroups equipmentGroupGet
+	// Get an equipment group by id.
+	// Get an existing equipment group by id.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the equipment group
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    $ref: '#/definitions/equipmentGroup'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/equipment_groups/{id}", e.getGroup).Methods("GET")
+
+	// swagger:operation POST /api/equipment_groups/{id} EquipmentGroups equipmentGroupUpdate
+	// Update an equipment group.
+	// Update an existing equipment group. Members are switched the next time the group is controlled.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the equipment group to update
+	//    required: true
+	//    schema:
+	//     type: integer
+	//  - in: body
+	//    name: group
+	//    description: The equipment group to update
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/equipmentGroup'
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/equipment_groups/{id}", e.updateGroup).Methods("POST")
+
+	// swagger:operation DELETE /api/equipment_groups/{id} EquipmentGroups equipmentGroupDelete
+	// Delete an equipment group.
+	// Delete an existing equipment group. Member equipment is not changed.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the equipment group to delete
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/equipment_groups/{id}", e.deleteGroup).Methods("DELETE")
+
+	// swagger:operation POST /api/equipment_groups/{id}/control EquipmentGroups equipmentGroupControl
+	// Control an equipment group.
+	// Switch all members of an equipment group. Inverted members are switched to the opposite state, overridden members are left alone.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the equipment group to control
+	//    required: true
+	//    schema:
+	//     type: integer
+	//  - in: body
+	//    name: action
+	//    description: The action to take
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/equipmentAction'
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/equipment_groups/{id}/control", e.controlGroup).Methods("POST")
 }
 
 //swagger:model equipmentAction
@@ -127,6 +461,9 @@ func (c *Controller) Control(id string, on bool) error {
 	if err != nil {
 		return nil
 	}
+	if e.Override != nil {
+		return fmt.Errorf("equipment '%s' is overridden, clear the override to control it", e.Name)
+	}
 	e.On = on
 	return c.Update(e.ID, e)
 }
@@ -138,6 +475,21 @@ func (c *Controller) control(w http.ResponseWriter, r *http.Request) {
 	}
 	utils.JSONUpdateResponse(&action, fn, w, r)
 }
+func (c *Controller) speed(w http.ResponseWriter, r *http.Request) {
+	var s EquipmentSpeed
+	fn := func(id string) error {
+		eq, err := c.Get(id)
+		if err != nil {
+			return err
+		}
+		if eq.Override != nil {
+			return fmt.Errorf("equipment '%s' is overridden, clear the override to control it", eq.Name)
+		}
+		return c.SetSpeed(id, s.Speed)
+	}
+	utils.JSONUpdateResponse(&s, fn, w, r)
+}
+
 func (c *Controller) GetEquipment(w http.ResponseWriter, r *http.Request) {
 	fn := func(id string) (interface{}, error) {
 		return c.Get(id)
@@ -145,7 +497,7 @@ func (c *Controller) GetEquipment(w http.ResponseWriter, r *http.Request) {
 	utils.JSONGetResponse(fn, w, r)
 }
 
-func (c Controller) ListEquipment(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) ListEquipment(w http.ResponseWriter, r *http.Request) {
 	fn := func() (interface{}, error) {
 		return c.List()
 	}
@@ -174,3 +526,138 @@ func (c *Controller) DeleteEquipment(w http.ResponseWriter, r *http.Request) {
 	}
 	utils.JSONDeleteResponse(fn, w, r)
 }
+
+func (c *Controller) energy(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return c.Energy(id)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (c *Controller) getTariff(w http.ResponseWriter, r *http.Request) {
+	fn := func(_ string) (interface{}, error) {
+		return c.Tariff()
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (c *Controller) updateTariff(w http.ResponseWriter, r *http.Request) {
+	var t Tariff
+	fn := func(_ string) error {
+		return c.SetTariff(t)
+	}
+	utils.JSONUpdateResponse(&t, fn, w, r)
+}
+
+func (c *Controller) runtime(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return c.Runtime(id)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (c *Controller) getMaintenance(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return c.GetMaintenance(id)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (c *Controller) listMaintenance(w http.ResponseWriter, r *http.Request) {
+	fn := func() (interface{}, error) {
+		return c.ListMaintenance()
+	}
+	utils.JSONListResponse(fn, w, r)
+}
+
+func (c *Controller) createMaintenance(w http.ResponseWriter, r *http.Request) {
+	var m Maintenance
+	fn := func() error {
+		return c.CreateMaintenance(m)
+	}
+	utils.JSONCreateResponse(&m, fn, w, r)
+}
+
+func (c *Controller) updateMaintenance(w http.ResponseWriter, r *http.Request) {
+	var m Maintenance
+	fn := func(id string) error {
+		return c.UpdateMaintenance(id, m)
+	}
+	utils.JSONUpdateResponse(&m, fn, w, r)
+}
+
+func (c *Controller) deleteMaintenance(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.DeleteMaintenance(id)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
+
+func (c *Controller) resetMaintenance(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.ResetMaintenance(id)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
+
+func (c *Controller) override(w http.ResponseWriter, r *http.Request) {
+	var o Override
+	fn := func(id string) error {
+		o.Paused = false
+		o.Interlock = false
+		return c.SetOverride(id, o)
+	}
+	utils.JSONUpdateResponse(&o, fn, w, r)
+}
+
+func (c *Controller) clearOverride(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.ClearOverride(id)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
+
+func (c *Controller) listGroups(w http.ResponseWriter, r *http.Request) {
+	fn := func() (interface{}, error) {
+		return c.ListGroups()
+	}
+	utils.JSONListResponse(fn, w, r)
+}
+
+func (c *Controller) getGroup(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return c.GetGroup(id)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (c *Controller) createGroup(w http.ResponseWriter, r *http.Request) {
+	var g Group
+	fn := func() error {
+		return c.CreateGroup(g)
+	}
+	utils.JSONCreateResponse(&g, fn, w, r)
+}
+
+func (c *Controller) updateGroup(w http.ResponseWriter, r *http.Request) {
+	var g Group
+	fn := func(id string) error {
+		return c.UpdateGroup(id, g)
+	}
+	utils.JSONUpdateResponse(&g, fn, w, r)
+}
+
+func (c *Controller) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.DeleteGroup(id)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
+
+func (c *Controller) controlGroup(w http.ResponseWriter, r *http.Request) {
+	var action EquipmentAction
+	fn := func(id string) error {
+		return c.GroupOn(id, action.On)
+	}
+	utils.JSONUpdateResponse(&action, fn, w, r)
+}
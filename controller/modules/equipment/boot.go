@@ -0,0 +1,166 @@
+package equipment
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// default interval between inlet reads while an equipment waits for its boot condition
+const bootPollInterval = time.Second
+
+// BootSequence controls how an equipment that was on is restored when reef-pi starts.
+// Equipment without a sequence is restored immediately
+//
+// swagger:model bootSequence
+type BootSequence struct {
+	// Priority orders restoration, lower priorities are restored first
+	Priority int `json:"priority"`
+	// Delay is the number of seconds to wait after the previous equipment was restored
+	Delay int `json:"delay"`
+	// Inlet optionally gates restoration, e.g. on a sump float switch
+	Inlet string `json:"inlet"`
+	// Value is the inlet reading required before the equipment is turned on
+	Value int `json:"value"`
+	// Timeout is the number of seconds to wait for the inlet condition before giving up and
+	// turning the equipment off. 0 waits indefinitely
+	Timeout int `json:"timeout"`
+}
+
+func (b BootSequence) IsValid() error {
+	if b.Delay < 0 || b.Timeout < 0 {
+		return fmt.Errorf("boot delay and timeout can not be negative")
+	}
+	return nil
+}
+
+func (b BootSequence) sequenced() bool {
+	return b.Priority != 0 || b.Delay != 0 || b.Inlet != ""
+}
+
+// hold keeps an equipment off until its turn in the boot sequence
+func (c *Controller) hold(eq Equipment) {
+	c.Lock()
+	c.pending[eq.ID] = true
+	c.Unlock()
+	if err := c.drive(eq, false); err != nil {
+		log.Println("ERROR: equipment subsystem: failed to hold", eq.Name, "off during boot. Error:", err)
+	}
+	off := eq
+	off.On = false
+	c.recordState(off)
+}
+
+func (c *Controller) isPending(id string) bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.pending[id]
+}
+
+// release removes an equipment from the boot sequence, returns false if it already left it,
+// e.g. because it was controlled in the meantime
+func (c *Controller) release(id string) bool {
+	c.Lock()
+	defer c.Unlock()
+	if !c.pending[id] {
+		return false
+	}
+	delete(c.pending, id)
+	return true
+}
+
+// boot restores held equipment in priority order, waiting for each delay. Equipment gated on
+// an inlet waits for its condition without holding up the rest of the sequence
+func (c *Controller) boot(eqs []Equipment, quit chan struct{}) {
+	sort.SliceStable(eqs, func(i, j int) bool {
+		return eqs[i].Boot.Priority < eqs[j].Boot.Priority
+	})
+	for _, eq := range eqs {
+		select {
+		case <-time.After(time.Duration(eq.Boot.Delay) * time.Second):
+		case <-quit:
+			return
+		}
+		if eq.Boot.Inlet != "" && !c.bootConditionMet(eq) {
+			go c.awaitBootCondition(eq, quit)
+			continue
+		}
+		c.restore(eq.ID)
+	}
+}
+
+func (c *Controller) bootConditionMet(eq Equipment) bool {
+	v, err := c.inlets.Read(eq.Boot.Inlet)
+	if err != nil {
+		log.Println("ERROR: equipment subsystem: failed to read boot condition of", eq.Name, ". Error:", err)
+		return false
+	}
+	return v == eq.Boot.Value
+}
+
+func (c *Controller) awaitBootCondition(eq Equipment, quit chan struct{}) {
+	log.Println("INFO: equipment subsystem: waiting for boot condition of", eq.Name)
+	ticker := time.NewTicker(c.bootPoll)
+	defer ticker.Stop()
+	var timeout <-chan time.Time
+	if eq.Boot.Timeout > 0 {
+		timer := time.NewTimer(time.Duration(eq.Boot.Timeout) * time.Second)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		select {
+		case <-ticker.C:
+			if !c.isPending(eq.ID) {
+				return
+			}
+			if c.bootConditionMet(eq) {
+				c.restore(eq.ID)
+				return
+			}
+		case <-timeout:
+			c.abandon(eq.ID)
+			return
+		case <-quit:
+			return
+		}
+	}
+}
+
+// restore turns a held equipment back on
+func (c *Controller) restore(id string) {
+	c.commands.Lock()
+	defer c.commands.Unlock()
+	if !c.release(id) {
+		return
+	}
+	eq, err := c.Get(id)
+	if err != nil {
+		log.Println("ERROR: equipment subsystem: failed to restore equipment", id, ". Error:", err)
+		return
+	}
+	log.Println("INFO: equipment subsystem: boot sequence restoring", eq.Name)
+	if err := c.updateOutlet(eq); err != nil {
+		log.Println("ERROR: equipment subsystem: failed to restore", eq.Name, ". Error:", err)
+	}
+}
+
+// abandon turns off an equipment whose boot condition was not met in time
+func (c *Controller) abandon(id string) {
+	if !c.isPending(id) {
+		return
+	}
+	eq, err := c.Get(id)
+	if err != nil {
+		return
+	}
+	subject := "Boot sequence: " + eq.Name + " kept off"
+	body := fmt.Sprintf("Boot condition of %s was not met within %d seconds. It has been turned off and must be turned on manually.", eq.Name, eq.Boot.Timeout)
+	log.Println("WARNING: equipment subsystem:", body)
+	c.telemetry.Alert(subject, body)
+	eq.On = false
+	if err := c.Update(id, eq); err != nil {
+		log.Println("ERROR: equipment subsystem: failed to turn off", eq.Name, ". Error:", err)
+	}
+}
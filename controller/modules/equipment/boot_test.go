@@ -0,0 +1,121 @@
+package equipment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
+)
+
+func eventually(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBootSequence(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	drvrs := con.DM().Drivers()
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := drvrs.DigitalOutputDriver("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := d.(*virtual.Driver)
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	inlets := con.DM().Inlets()
+	if err := inlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	for pin := 0; pin < 5; pin++ {
+		if err := outlets.Create(connectors.Outlet{Name: "o", Pin: pin, Driver: "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for pin := 0; pin < 2; pin++ {
+		if err := inlets.Create(connectors.Inlet{Name: "float", Pin: pin, Driver: "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := New(con)
+	c.bootPoll = 10 * time.Millisecond
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "bad", Outlet: "1", Boot: BootSequence{Inlet: "9"}}); err == nil {
+		t.Error("expected unknown boot inlet to be rejected")
+	}
+	if err := c.Create(Equipment{Name: "bad", Outlet: "1", Boot: BootSequence{Delay: -1}}); err == nil {
+		t.Error("expected negative boot delay to be rejected")
+	}
+	for _, eq := range []Equipment{
+		{Name: "heater", Outlet: "1", On: true, Boot: BootSequence{Priority: 2}},
+		{Name: "skimmer", Outlet: "2", On: true, Boot: BootSequence{Priority: 1}},
+		{Name: "return", Outlet: "3", On: true, Boot: BootSequence{Priority: 3, Inlet: "1", Value: 1}},
+		{Name: "light", Outlet: "4", On: true},
+		{Name: "uv", Outlet: "5", On: true, Boot: BootSequence{Inlet: "2", Value: 1, Timeout: 1}},
+	} {
+		if err := c.Create(eq); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if deps, err := c.InUse("inlets", "1"); err != nil || len(deps) != 1 || deps[0] != "return" {
+		t.Error("expected boot inlet to be reported in use", deps, err)
+	}
+
+	start := time.Now()
+	c.Start()
+	defer c.Stop()
+	eventually(t, "expected heater and skimmer to be restored", func() bool {
+		return !c.isPending("1") && !c.isPending("2")
+	})
+	var restored []int
+	for _, w := range sim.Writes(start) {
+		if w.Value == 1 {
+			restored = append(restored, w.Pin)
+		}
+	}
+	if len(restored) != 3 || restored[0] != 3 || restored[1] != 1 || restored[2] != 0 {
+		t.Error("expected light, then skimmer, then heater to be turned on. Found pins:", restored)
+	}
+	if !c.isPending("3") {
+		t.Error("expected return pump to wait for the sump float")
+	}
+	if on, _ := outlets.Observe("3"); on {
+		t.Error("expected return pump to be held off")
+	}
+	c.verifyAll()
+	if on, _ := outlets.Observe("3"); on {
+		t.Error("expected verification to leave held equipment alone")
+	}
+
+	if err := sim.Set(virtual.Input{Type: "digital-input", Pin: 0, Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "expected return pump to be restored once the float is ok", func() bool {
+		on, _ := outlets.Observe("3")
+		return on && !c.isPending("3")
+	})
+
+	eventually(t, "expected uv to be turned off after its boot condition timed out", func() bool {
+		eq, err := c.Get("5")
+		return err == nil && !eq.On && !c.isPending("5")
+	})
+}
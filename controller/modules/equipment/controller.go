@@ -3,6 +3,8 @@ package equipment
 import (
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/reef-pi/reef-pi/controller"
 	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
@@ -11,9 +13,26 @@ import (
 )
 
 type Controller struct {
+	sync.Mutex
+	// commands serializes outlet writes with state verification
+	commands  sync.Mutex
 	telemetry telemetry.Telemetry
 	store     storage.Store
 	outlets   *connectors.Outlets
+	inlets    *connectors.Inlets
+	jacks     *connectors.Jacks
+	pins      *connectors.PinRegistry
+	observed  map[string]Observation
+	// pending holds equipment waiting for their turn in the boot sequence
+	pending  map[string]bool
+	bootPoll time.Duration
+	// resumers end timed overrides
+	resumers map[string]*time.Timer
+	// sequences cancel delayed group switching
+	sequences map[string]chan struct{}
+	statsMgr  telemetry.StatsManager
+	quit      chan struct{}
+	now       func() time.Time
 }
 
 func New(c controller.Controller) *Controller {
@@ -21,34 +40,81 @@ func New(c controller.Controller) *Controller {
 		telemetry: c.Telemetry(),
 		store:     c.Store(),
 		outlets:   c.DM().Outlets(),
+		inlets:    c.DM().Inlets(),
+		jacks:     c.DM().Jacks(),
+		pins:      c.DM().Pins(),
+		observed:  make(map[string]Observation),
+		pending:   make(map[string]bool),
+		bootPoll:  bootPollInterval,
+		resumers:  make(map[string]*time.Timer),
+		sequences: make(map[string]chan struct{}),
+		statsMgr:  c.Telemetry().NewStatsManager(UsageBucket),
+		now:       time.Now,
 	}
 }
 
 func (c *Controller) Setup() error {
-	return c.store.CreateBucket(Bucket)
+	for _, b := range []string{Bucket, UsageBucket, RuntimeBucket, MaintenanceBucket, GroupBucket} {
+		if err := c.store.CreateBucket(b); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (c *Controller) Start() {
+	c.resumeOverrides()
 	eqs, err := c.List()
 	if err != nil {
 		log.Println("ERROR: equipment subsystem: failed to list equipment. Error:", err)
 		return
 	}
+	c.checkpointRuntime(true)
+	var sequence []Equipment
 	for _, eq := range eqs {
+		c.loadEnergy(eq.ID)
 		if eq.StayOffOnBoot {
 			eq.On = false
 			if err := c.Update(eq.ID, eq); err != nil {
 				log.Println("ERROR: equipment subsystem: Failed to turn off ", eq.Name, " which is set up to stay off upon boot. Error:", err)
 			}
 		}
+		if eq.On && eq.Boot.sequenced() {
+			c.hold(eq)
+			sequence = append(sequence, eq)
+			continue
+		}
 		if err := c.updateOutlet(eq); err != nil {
 			log.Println("ERROR: equipment subsystem: Failed to sync equipment", eq.Name, ". Error:", err)
 		}
 	}
 	log.Println("INFO: equipment subsystem: Finished syncing all equipment")
+	c.Lock()
+	if c.quit == nil {
+		c.quit = make(chan struct{})
+		go c.verifyLoop(c.quit)
+		go c.energyLoop(c.quit)
+		go c.maintenanceLoop(c.quit)
+	}
+	quit := c.quit
+	c.Unlock()
+	if len(sequence) > 0 {
+		log.Println("INFO: equipment subsystem: Restoring", len(sequence), "equipment in boot sequence")
+		go c.boot(sequence, quit)
+	}
 }
 
 func (c *Controller) Stop() {
+	c.Lock()
+	if c.quit != nil {
+		close(c.quit)
+		c.quit = nil
+	}
+	c.Unlock()
+	c.stopResumers()
+	c.stopSequences()
+	c.saveEnergy()
+	c.checkpointRuntime(false)
 }
 
 func (c *Controller) InUse(depType, id string) ([]string, error) {
@@ -65,31 +131,77 @@ func (c *Controller) InUse(depType, id string) ([]string, error) {
 			}
 		}
 		return deps, nil
+	case storage.JackBucket:
+		eqs, err := c.List()
+		if err != nil {
+			return deps, err
+		}
+		for _, eq := range eqs {
+			if eq.Jack == id {
+				deps = append(deps, eq.Name)
+			}
+		}
+		return deps, nil
+	case storage.InletBucket:
+		eqs, err := c.List()
+		if err != nil {
+			return deps, err
+		}
+		for _, eq := range eqs {
+			if eq.Boot.Inlet == id {
+				deps = append(deps, eq.Name)
+			}
+		}
+		return deps, nil
+	case storage.EquipmentBucket:
+		return c.groupsUsing(id)
 	default:
 		return deps, fmt.Errorf("unknown error type:%s", depType)
 	}
 }
 
+// On is used by timers, macros and controllers. It has no effect while the equipment is overridden.
+// Prefixed group ids switch all members of the group
 func (c *Controller) On(id string, b bool) error {
+	if gid, ok := groupID(id); ok {
+		return c.GroupOn(gid, b)
+	}
 	e, err := c.Get(id)
 	if err != nil {
 		return err
 	}
+	if e.Override != nil {
+		log.Println("INFO: equipment subsystem:", e.Name, "is overridden, ignoring request to set On:", b)
+		return nil
+	}
 	log.Println("Equipment:", e.Name, "On:", b)
 	e.On = b
 	return c.Update(id, e)
 }
 func (c *Controller) updateOutlet(eq Equipment) error {
-	if err := c.outlets.Configure(eq.Outlet, eq.On); err != nil {
+	if err := c.drive(eq, eq.On); err != nil {
 		return err
 	}
+	c.recordState(eq)
 	m := 0.0
+	action := "off"
 	if eq.On {
 		m = 1.0
+		action = "on"
 	}
-	c.telemetry.EmitMetric("equipment", eq.Name+"-state", m)
+	c.telemetry.ControlAction(Bucket, eq.ID, action)
+	c.telemetry.EmitEntityMetric(telemetry.EntityMetric{
+		Module: Bucket,
+		ID:     eq.ID,
+		Name:   eq.Name,
+		Metric: "state",
+		Feed:   "equipment-" + eq.Name + "-state",
+	}, m)
 	return nil
 }
 func (c *Controller) GetEntity(id string) (controller.Entity, error) {
-	return nil, fmt.Errorf("equipment subsystem does not support 'GetEntity' interface")
+	if gid, ok := groupID(id); ok {
+		return c.GetGroup(gid)
+	}
+	return c.Get(id)
 }
@@ -8,6 +8,8 @@ import (
 
 	"github.com/reef-pi/reef-pi/controller"
 	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
 	"github.com/reef-pi/reef-pi/controller/utils"
 )
 
@@ -34,6 +36,7 @@ func TestEquipmentController(t *testing.T) {
 		t.Fatal(err)
 	}
 	o.Equipment = "1"
+	o.Pin = 24
 	// Create another outlet thats in use
 	if err := outlets.Create(o); err != nil {
 		t.Fatal(err)
@@ -108,6 +111,7 @@ func TestEquipmentController(t *testing.T) {
 	}
 
 	o.Name = "updated"
+	o.Pin = 23
 	buf := new(bytes.Buffer)
 	json.NewEncoder(buf).Encode(&o)
 	if err := tr.Do("POST", "/api/outlets/1", buf, nil); err != nil {
@@ -177,3 +181,82 @@ func TestUpdateEquipment(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestOutletVerification(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	drvrs := con.DM().Drivers()
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := drvrs.DigitalOutputDriver("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := d.(*virtual.Driver)
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Create(connectors.Outlet{Name: "plug", Pin: 0, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Create(connectors.Outlet{Name: "gpio", Pin: 21, Driver: "rpi"}); err != nil {
+		t.Fatal(err)
+	}
+	c := New(con)
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "heater", Outlet: "1", On: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "pump", Outlet: "2", On: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.verifyAll()
+	eq, err := c.Get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eq.Observed == nil || !eq.Observed.On || eq.Observed.Drift {
+		t.Error("Expected heater to be observed on without drift. Found:", eq.Observed)
+	}
+	if eq, _ := c.Get("2"); eq.Observed != nil {
+		t.Error("Expected no observed state for outlet that can not report state")
+	}
+
+	// someone pressed the button on the plug
+	if err := sim.Set(virtual.Input{Type: "digital-output", Pin: 0, Value: 0}); err != nil {
+		t.Fatal(err)
+	}
+	c.verifyAll()
+	eq, _ = c.Get("1")
+	if eq.Observed == nil || eq.Observed.On || !eq.Observed.Drift {
+		t.Error("Expected drift to be detected. Found:", eq.Observed)
+	}
+	if on, err := outlets.Observe("1"); err != nil || !on {
+		t.Error("Expected desired state to be re-asserted", on, err)
+	}
+	c.verifyAll()
+	eq, _ = c.Get("1")
+	if eq.Observed == nil || eq.Observed.Drift {
+		t.Error("Expected drift to be resolved. Found:", eq.Observed)
+	}
+
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+	var eqs []Equipment
+	if err := tr.Do("GET", "/api/equipment", strings.NewReader("{}"), &eqs); err != nil {
+		t.Fatal(err)
+	}
+	if len(eqs) != 2 || eqs[0].Observed == nil {
+		t.Error("Expected observed state in equipment api", eqs)
+	}
+	c.Start()
+	c.Stop()
+}
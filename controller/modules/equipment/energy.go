@@ -0,0 +1,246 @@
+package equipment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/telemetry"
+)
+
+const UsageBucket = storage.EquipmentUsageBucket
+
+// tariff is stored alongside energy stats, which are keyed by numeric equipment ids
+const tariffKey = "tariff"
+
+// interval between energy samples
+var energyInterval = time.Minute
+
+// Tariff is the price of electricity used to estimate running costs
+//
+// swagger:model tariff
+type Tariff struct {
+	// Rate is the cost of one kWh
+	Rate     float64 `json:"rate"`
+	Currency string  `json:"currency"`
+}
+
+// Energy is a power sample of an equipment. Historical stats hold daily totals, with the peak power of the day
+//
+// swagger:model energy
+type Energy struct {
+	Watts float64 `json:"watts"`
+	KWh   float64 `json:"kwh"`
+	Cost  float64 `json:"cost"`
+	// Metered is true when the power was measured by the outlet driver rather than estimated
+	Metered bool               `json:"metered"`
+	Time    telemetry.TeleTime `json:"time"`
+}
+
+func (e1 Energy) Rollup(ex telemetry.Metric) (telemetry.Metric, bool) {
+	e2 := ex.(Energy)
+	t1, t2 := time.Time(e1.Time), time.Time(e2.Time)
+	if t1.YearDay() != t2.YearDay() || t1.Year() != t2.Year() {
+		return e2, true
+	}
+	return Energy{
+		Watts:   math.Max(e1.Watts, e2.Watts),
+		KWh:     e1.KWh + e2.KWh,
+		Cost:    e1.Cost + e2.Cost,
+		Metered: e1.Metered || e2.Metered,
+		Time:    e1.Time,
+	}, false
+}
+
+func (e1 Energy) Before(ex telemetry.Metric) bool {
+	e2 := ex.(Energy)
+	return e1.Time.Before(e2.Time)
+}
+
+// EnergyUsage is the energy used by an equipment over a day (2006-01-02) or a month (2006-01)
+//
+// swagger:model energyUsage
+type EnergyUsage struct {
+	Period string  `json:"period"`
+	KWh    float64 `json:"kwh"`
+	Cost   float64 `json:"cost"`
+}
+
+// EnergyReport summarizes the power draw and energy usage of an equipment
+//
+// swagger:model energyReport
+type EnergyReport struct {
+	// Watts is the latest power sample
+	Watts    float64       `json:"watts"`
+	Metered  bool          `json:"metered"`
+	Currency string        `json:"currency"`
+	Daily    []EnergyUsage `json:"daily"`
+	Monthly  []EnergyUsage `json:"monthly"`
+}
+
+func (c *Controller) Tariff() (Tariff, error) {
+	var t Tariff
+	data, err := c.store.RawGet(UsageBucket, tariffKey)
+	if err != nil || len(data) == 0 {
+		return t, err
+	}
+	return t, json.Unmarshal(data, &t)
+}
+
+func (c *Controller) SetTariff(t Tariff) error {
+	if t.Rate < 0 {
+		return fmt.Errorf("tariff rate can not be negative")
+	}
+	return c.store.Update(UsageBucket, tariffKey, t)
+}
+
+func (c *Controller) loadEnergy(id string) {
+	if data, err := c.store.RawGet(UsageBucket, id); err != nil || len(data) == 0 {
+		return
+	}
+	fn := func(d json.RawMessage) interface{} {
+		e := Energy{}
+		json.Unmarshal(d, &e)
+		return e
+	}
+	if err := c.statsMgr.Load(id, fn); err != nil {
+		log.Println("ERROR: equipment subsystem: failed to load energy usage. Error:", err)
+	}
+}
+
+func (c *Controller) saveEnergy() {
+	eqs, err := c.List()
+	if err != nil {
+		log.Println("ERROR: equipment subsystem: failed to list equipment. Error:", err)
+		return
+	}
+	for _, eq := range eqs {
+		if !c.statsMgr.IsLoaded(eq.ID) {
+			continue
+		}
+		if err := c.statsMgr.Save(eq.ID); err != nil {
+			log.Println("ERROR: equipment subsystem: failed to save energy usage of", eq.Name, ". Error:", err)
+		}
+	}
+}
+
+func (c *Controller) energyLoop(quit chan struct{}) {
+	ticker := time.NewTicker(energyInterval)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case now := <-ticker.C:
+			c.sampleEnergy(now.Sub(last))
+			last = now
+		case <-quit:
+			return
+		}
+	}
+}
+
+// power returns the power drawn by an equipment, measured by the outlet driver if it can,
+// otherwise estimated from its wattage, scaled by the speed of variable speed equipment.
+// Returns false if neither is available
+func (c *Controller) power(eq Equipment) (Energy, bool) {
+	if eq.Outlet != "" {
+		w, err := c.outlets.Power(eq.Outlet)
+		if err == nil {
+			return Energy{Watts: w, Metered: true}, true
+		}
+		if !errors.Is(err, connectors.ErrPowerMeteringUnsupported) {
+			log.Println("ERROR: equipment subsystem: failed to read power of", eq.Name, ". Error:", err)
+		}
+	}
+	if eq.Watts <= 0 {
+		return Energy{}, false
+	}
+	if !eq.On {
+		return Energy{}, true
+	}
+	if eq.variableSpeed() {
+		return Energy{Watts: eq.Watts * eq.Speed / 100}, true
+	}
+	return Energy{Watts: eq.Watts}, true
+}
+
+// sampleEnergy records the energy used by each equipment over the elapsed interval
+func (c *Controller) sampleEnergy(elapsed time.Duration) {
+	eqs, err := c.List()
+	if err != nil {
+		log.Println("ERROR: equipment subsystem: failed to list equipment. Error:", err)
+		return
+	}
+	tariff, err := c.Tariff()
+	if err != nil {
+		log.Println("ERROR: equipment subsystem: failed to read tariff. Error:", err)
+	}
+	now := telemetry.TeleTime(time.Now())
+	for _, eq := range eqs {
+		e, ok := c.power(eq)
+		if !ok {
+			continue
+		}
+		e.KWh = e.Watts * elapsed.Hours() / 1000
+		e.Cost = e.KWh * tariff.Rate
+		e.Time = now
+		c.statsMgr.Update(eq.ID, e)
+		c.telemetry.EmitEntityMetric(telemetry.EntityMetric{
+			Module: Bucket,
+			ID:     eq.ID,
+			Name:   eq.Name,
+			Metric: "power",
+			Feed:   "equipment-" + eq.Name + "-power",
+		}, e.Watts)
+	}
+}
+
+// Energy reports the latest power sample and the daily and monthly energy usage of an equipment
+func (c *Controller) Energy(id string) (EnergyReport, error) {
+	r := EnergyReport{
+		Daily:   []EnergyUsage{},
+		Monthly: []EnergyUsage{},
+	}
+	if _, err := c.Get(id); err != nil {
+		return r, err
+	}
+	tariff, err := c.Tariff()
+	if err != nil {
+		return r, err
+	}
+	r.Currency = tariff.Currency
+	resp, err := c.statsMgr.Get(id)
+	if err != nil {
+		// no energy has been recorded yet
+		return r, nil
+	}
+	if n := len(resp.Current); n > 0 {
+		last := resp.Current[n-1].(Energy)
+		r.Watts = last.Watts
+		r.Metered = last.Metered
+	}
+	for _, m := range resp.Historical {
+		e := m.(Energy)
+		t := time.Time(e.Time)
+		r.Daily = append(r.Daily, EnergyUsage{Period: t.Format("2006-01-02"), KWh: e.KWh, Cost: e.Cost})
+		month := t.Format("2006-01")
+		if n := len(r.Monthly); n > 0 && r.Monthly[n-1].Period == month {
+			r.Monthly[n-1].KWh += e.KWh
+			r.Monthly[n-1].Cost += e.Cost
+			continue
+		}
+		r.Monthly = append(r.Monthly, EnergyUsage{Period: month, KWh: e.KWh, Cost: e.Cost})
+	}
+	for _, usage := range [][]EnergyUsage{r.Daily, r.Monthly} {
+		for i := range usage {
+			usage[i].KWh = math.Round(usage[i].KWh*1000) / 1000
+			usage[i].Cost = math.Round(usage[i].Cost*100) / 100
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,152 @@
+package equipment
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
+	"github.com/reef-pi/reef-pi/controller/telemetry"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestEnergyRollup(t *testing.T) {
+	day := time.Date(2024, 3, 31, 23, 0, 0, 0, time.Local)
+	e1 := Energy{Watts: 40, KWh: 0.04, Cost: 0.01, Time: telemetry.TeleTime(day)}
+	e2 := Energy{Watts: 60, KWh: 0.06, Cost: 0.02, Metered: true, Time: telemetry.TeleTime(day.Add(30 * time.Minute))}
+	m, moved := e1.Rollup(e2)
+	if moved {
+		t.Fatal("expected samples of the same day to be rolled up")
+	}
+	e := m.(Energy)
+	if e.Watts != 60 || e.KWh != 0.1 || e.Cost != 0.03 || !e.Metered || e.Time != e1.Time {
+		t.Error("unexpected daily total", e)
+	}
+	e2.Time = telemetry.TeleTime(day.Add(2 * time.Hour))
+	if m, moved := e.Rollup(e2); !moved || m.(Energy) != e2 {
+		t.Error("expected a new day to start a new total", m)
+	}
+	if !e1.Before(e2) {
+		t.Error("expected e1 to be before e2")
+	}
+}
+
+func TestEnergy(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	drvrs := con.DM().Drivers()
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := drvrs.DigitalOutputDriver("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := d.(*virtual.Driver)
+	if err := sim.Set(virtual.Input{Type: "power", Pin: 0, Value: 40}); err != nil {
+		t.Fatal(err)
+	}
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range []connectors.Outlet{
+		{Name: "plug", Pin: 0, Driver: "1"},
+		{Name: "gpio", Pin: 21, Driver: "rpi"},
+		{Name: "relay", Pin: 20, Driver: "rpi"},
+	} {
+		if err := outlets.Create(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := New(con)
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "heater", Outlet: "1", Watts: -5}); err == nil {
+		t.Error("expected negative wattage to be rejected")
+	}
+	for _, eq := range []Equipment{
+		{Name: "heater", Outlet: "1", On: true},
+		{Name: "pump", Outlet: "2", On: true, Watts: 10},
+		{Name: "skimmer", Outlet: "3", On: true},
+	} {
+		if err := c.Create(eq); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(Tariff{Rate: 0.25, Currency: "USD"})
+	if err := tr.Do("POST", "/api/equipment/energy/tariff", body, nil); err != nil {
+		t.Fatal("Failed to update tariff using api", err)
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(Tariff{Rate: -1})
+	if err := tr.Do("POST", "/api/equipment/energy/tariff", body, nil); err == nil {
+		t.Error("expected negative tariff to be rejected")
+	}
+	var tariff Tariff
+	if err := tr.Do("GET", "/api/equipment/energy/tariff", strings.NewReader("{}"), &tariff); err != nil || tariff.Rate != 0.25 {
+		t.Error("Failed to get tariff using api", tariff, err)
+	}
+
+	c.sampleEnergy(time.Hour)
+	if err := c.On("2", false); err != nil {
+		t.Fatal(err)
+	}
+	c.sampleEnergy(time.Hour)
+
+	var r EnergyReport
+	if err := tr.Do("GET", "/api/equipment/1/energy", strings.NewReader("{}"), &r); err != nil {
+		t.Fatal("Failed to get energy usage using api", err)
+	}
+	if r.Watts != 40 || !r.Metered || r.Currency != "USD" {
+		t.Error("expected metered power of heater", r)
+	}
+	if len(r.Daily) != 1 || r.Daily[0].KWh != 0.08 || r.Daily[0].Cost != 0.02 {
+		t.Error("unexpected daily energy usage of heater", r.Daily)
+	}
+	if len(r.Monthly) != 1 || r.Monthly[0].KWh != 0.08 || r.Monthly[0].Period != time.Now().Format("2006-01") {
+		t.Error("unexpected monthly energy usage of heater", r.Monthly)
+	}
+
+	r, err = c.Energy("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Watts != 0 || r.Metered || len(r.Daily) != 1 || r.Daily[0].KWh != 0.01 {
+		t.Error("expected estimated energy usage of pump", r)
+	}
+
+	r, err = c.Energy("3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Daily) != 0 {
+		t.Error("expected no energy usage without metering or estimated wattage", r)
+	}
+
+	c.Stop()
+	c2 := New(con)
+	c2.loadEnergy("1")
+	if r, err := c2.Energy("1"); err != nil || len(r.Daily) != 1 {
+		t.Error("expected energy usage to be saved and loaded", r, err)
+	}
+	if err := c.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := con.Store().RawGet(UsageBucket, "1"); len(data) != 0 {
+		t.Error("expected energy usage to be deleted with the equipment")
+	}
+}
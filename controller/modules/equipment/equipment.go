@@ -2,8 +2,10 @@ package equipment
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
 	"github.com/reef-pi/reef-pi/controller/storage"
 )
 
@@ -16,27 +18,84 @@ type Equipment struct {
 	Outlet        string `json:"outlet"`
 	On            bool   `json:"on"`
 	StayOffOnBoot bool   `json:"stay_off_on_boot"`
+	// Jack and Pin drive variable speed equipment, e.g. DC pumps and fans, instead of an outlet
+	Jack string `json:"jack,omitempty"`
+	Pin  int    `json:"pin"`
+	// Speed is the percentage the jack pin is set to while the equipment is on
+	Speed float64 `json:"speed"`
+	// Watts is the estimated power draw while on, used when the outlet driver can not measure power
+	Watts float64      `json:"watts"`
+	Boot  BootSequence `json:"boot"`
+	// Override is the active override, it can only be changed through the override api
+	Override *Override `json:"override,omitempty"`
+	// Observed is the outlet state read back from the device, absent if the driver can not report state
+	Observed *Observation `json:"observed,omitempty"`
 }
 
 func (c *Controller) Get(id string) (Equipment, error) {
 	var eq Equipment
-	return eq, c.store.Get(Bucket, id, &eq)
+	if err := c.store.Get(Bucket, id, &eq); err != nil {
+		return eq, err
+	}
+	eq.Observed = c.observation(id)
+	return eq, nil
 }
 
-func (c Controller) List() ([]Equipment, error) {
+func (c *Controller) List() ([]Equipment, error) {
 	es := []Equipment{}
 	fn := func(_ string, v []byte) error {
 		var eq Equipment
 		if err := json.Unmarshal(v, &eq); err != nil {
 			return err
 		}
+		eq.Observed = c.observation(eq.ID)
 		es = append(es, eq)
 		return nil
 	}
 	return es, c.store.List(Bucket, fn)
 }
 
+func (eq Equipment) IsValid() error {
+	if eq.Watts < 0 {
+		return fmt.Errorf("estimated wattage of equipment can not be negative")
+	}
+	if eq.Jack != "" && eq.Outlet != "" {
+		return fmt.Errorf("equipment can be driven by an outlet or a jack, not both")
+	}
+	if eq.Speed < 0 || eq.Speed > 100 {
+		return fmt.Errorf("invalid speed %f, must be between 0 and 100", eq.Speed)
+	}
+	return eq.Boot.IsValid()
+}
+
+func (c *Controller) validate(eq Equipment) error {
+	if err := eq.IsValid(); err != nil {
+		return err
+	}
+	if eq.Boot.Inlet != "" {
+		if _, err := c.inlets.Get(eq.Boot.Inlet); err != nil {
+			return fmt.Errorf("boot condition inlet '%s' does not exist. Error: %w", eq.Boot.Inlet, err)
+		}
+	}
+	if eq.variableSpeed() {
+		return c.validateJack(eq)
+	}
+	owner := connectors.PinOwner{Type: connectors.EquipmentPin, ID: eq.ID, Name: eq.Name}
+	return c.pins.CheckOutlet(owner, eq.Outlet)
+}
+
 func (c *Controller) Create(eq Equipment) error {
+	eq.ID = ""
+	if err := c.validate(eq); err != nil {
+		return err
+	}
+	eq.Observed = nil
+	eq.Override = nil
+	if eq.variableSpeed() && eq.Speed == 0 {
+		eq.Speed = defaultSpeed
+	}
+	c.commands.Lock()
+	defer c.commands.Unlock()
 	fn := func(id string) interface{} {
 		eq.ID = id
 		return &eq
@@ -53,18 +112,78 @@ func (c *Controller) Create(eq Equipment) error {
 
 func (c *Controller) Update(id string, eq Equipment) error {
 	eq.ID = id
-	if err := c.store.Update(Bucket, id, eq); err != nil {
+	if err := c.validate(eq); err != nil {
+		return err
+	}
+	eq.Observed = nil
+	if eq.variableSpeed() && eq.Speed == 0 {
+		eq.Speed = defaultSpeed
+	}
+	c.commands.Lock()
+	defer c.commands.Unlock()
+	// controlling an equipment takes it out of the boot sequence
+	c.release(id)
+	var old Equipment
+	if err := c.store.Get(Bucket, id, &old); err == nil && old.Override != nil {
+		eq.Override = old.Override
+		eq.On = old.Override.On
+	} else {
+		eq.Override = nil
+	}
+	return c.save(eq)
+}
+
+// save stores an equipment and applies its state, the caller must hold the commands lock
+func (c *Controller) save(eq Equipment) error {
+	eq.Observed = nil
+	if err := c.store.Update(Bucket, eq.ID, eq); err != nil {
 		return err
 	}
 	return c.updateOutlet(eq)
 }
 
+func (eq Equipment) EName() string { return eq.Name }
+
+// Status reports the desired state and the active override, if any
+func (eq Equipment) Status() (interface{}, error) {
+	return EquipmentStatus{On: eq.On, Override: eq.Override, Observed: eq.Observed}, nil
+}
+
+// EquipmentStatus is the state of an equipment, including overrides
+//
+// swagger:model equipmentStatus
+type EquipmentStatus struct {
+	On       bool         `json:"on"`
+	Override *Override    `json:"override,omitempty"`
+	Observed *Observation `json:"observed,omitempty"`
+}
+
 func (c *Controller) Delete(id string) error {
 	_, err := c.Get(id)
 	if err != nil {
 		return err
 	}
-	return c.store.Delete(Bucket, id)
+	if err := c.store.Delete(Bucket, id); err != nil {
+		return err
+	}
+	c.telemetry.DeleteEntityMetrics(Bucket, id)
+	if err := c.statsMgr.Delete(id); err != nil {
+		log.Println("ERROR: equipment subsystem: failed to delete energy usage. Error:", err)
+	}
+	if err := c.store.Delete(RuntimeBucket, id); err != nil {
+		log.Println("ERROR: equipment subsystem: failed to delete runtime. Error:", err)
+	}
+	if err := c.deleteEquipmentMaintenance(id); err != nil {
+		log.Println("ERROR: equipment subsystem: failed to delete maintenance tasks. Error:", err)
+	}
+	if err := c.removeGroupMember(id); err != nil {
+		log.Println("ERROR: equipment subsystem: failed to remove equipment from groups. Error:", err)
+	}
+	c.cancelResume(id)
+	c.Lock()
+	delete(c.observed, id)
+	c.Unlock()
+	return nil
 }
 
 func (c *Controller) synEquipment() {
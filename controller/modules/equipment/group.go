@@ -0,0 +1,267 @@
+package equipment
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+const GroupBucket = storage.EquipmentGroupBucket
+
+// GroupPrefix marks a group where an equipment id is expected. Timers, macros and controllers
+// address group 1 as equipment "group-1"
+const GroupPrefix = "group-"
+
+// Member is an equipment switched by a group
+//
+// swagger:model equipmentGroupMember
+type Member struct {
+	Equipment string `json:"equipment"`
+	// Invert turns the equipment off when the group is turned on, and vice versa
+	Invert bool `json:"invert"`
+}
+
+// Group switches its members together. Members are turned on in order and off in reverse order
+//
+// swagger:model equipmentGroup
+type Group struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Members []Member `json:"members"`
+	// Delay is the number of seconds between switching consecutive members, 0 switches them at once
+	Delay int  `json:"delay"`
+	On    bool `json:"on"`
+}
+
+func (g Group) EName() string { return g.Name }
+
+func (g Group) Status() (interface{}, error) {
+	return EquipmentStatus{On: g.On}, nil
+}
+
+// groupID returns the group id of a prefixed equipment id
+func groupID(id string) (string, bool) {
+	if !strings.HasPrefix(id, GroupPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(id, GroupPrefix), true
+}
+
+// equipmentOf returns the equipment addressed by an id, the members of a group for prefixed group ids
+func (c *Controller) equipmentOf(id string) ([]string, error) {
+	gid, ok := groupID(id)
+	if !ok {
+		return []string{id}, nil
+	}
+	g, err := c.GetGroup(gid)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(g.Members))
+	for i, m := range g.Members {
+		ids[i] = m.Equipment
+	}
+	return ids, nil
+}
+
+func (c *Controller) validateGroup(g Group) error {
+	if g.Name == "" {
+		return fmt.Errorf("equipment group name can not be empty")
+	}
+	if g.Delay < 0 {
+		return fmt.Errorf("equipment group delay can not be negative")
+	}
+	if len(g.Members) == 0 {
+		return fmt.Errorf("equipment group requires at least one member")
+	}
+	seen := make(map[string]bool)
+	for _, m := range g.Members {
+		if seen[m.Equipment] {
+			return fmt.Errorf("equipment '%s' is listed more than once", m.Equipment)
+		}
+		seen[m.Equipment] = true
+		if _, err := c.Get(m.Equipment); err != nil {
+			return fmt.Errorf("member equipment '%s' does not exist. Error: %w", m.Equipment, err)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) GetGroup(id string) (Group, error) {
+	var g Group
+	return g, c.store.Get(GroupBucket, id, &g)
+}
+
+func (c *Controller) ListGroups() ([]Group, error) {
+	gs := []Group{}
+	fn := func(_ string, v []byte) error {
+		var g Group
+		if err := json.Unmarshal(v, &g); err != nil {
+			return err
+		}
+		gs = append(gs, g)
+		return nil
+	}
+	return gs, c.store.List(GroupBucket, fn)
+}
+
+func (c *Controller) CreateGroup(g Group) error {
+	if err := c.validateGroup(g); err != nil {
+		return err
+	}
+	g.On = false
+	fn := func(id string) interface{} {
+		g.ID = id
+		return &g
+	}
+	return c.store.Create(GroupBucket, fn)
+}
+
+// UpdateGroup changes the name, members or delay of a group. Members are not switched until
+// the group is controlled again
+func (c *Controller) UpdateGroup(id string, g Group) error {
+	if err := c.validateGroup(g); err != nil {
+		return err
+	}
+	old, err := c.GetGroup(id)
+	if err != nil {
+		return err
+	}
+	g.ID = id
+	g.On = old.On
+	return c.store.Update(GroupBucket, id, g)
+}
+
+func (c *Controller) DeleteGroup(id string) error {
+	if _, err := c.GetGroup(id); err != nil {
+		return err
+	}
+	c.cancelSequence(id)
+	return c.store.Delete(GroupBucket, id)
+}
+
+// GroupOn switches all members of a group. Overridden members keep their state. With a delay the
+// members are switched in the background, a new command cancels a sequence that is still running
+func (c *Controller) GroupOn(id string, on bool) error {
+	g, err := c.GetGroup(id)
+	if err != nil {
+		return err
+	}
+	log.Println("Equipment group:", g.Name, "On:", on)
+	g.On = on
+	if err := c.store.Update(GroupBucket, id, g); err != nil {
+		return err
+	}
+	members := make([]Member, len(g.Members))
+	for i, m := range g.Members {
+		if on {
+			members[i] = m
+		} else {
+			members[len(members)-1-i] = m
+		}
+	}
+	if g.Delay == 0 {
+		c.cancelSequence(id)
+		return c.switchMembers(g, members, on, nil)
+	}
+	quit := c.startSequence(id)
+	go func() {
+		if err := c.switchMembers(g, members, on, quit); err != nil {
+			log.Println("ERROR: equipment subsystem: failed to switch group", g.Name, ". Error:", err)
+		}
+	}()
+	return nil
+}
+
+func (c *Controller) switchMembers(g Group, members []Member, on bool, quit chan struct{}) error {
+	var failed error
+	for i, m := range members {
+		if i > 0 && g.Delay > 0 {
+			select {
+			case <-time.After(time.Duration(g.Delay) * time.Second):
+			case <-quit:
+				return failed
+			}
+		}
+		if err := c.On(m.Equipment, on != m.Invert); err != nil {
+			log.Println("ERROR: equipment subsystem: group", g.Name, "failed to switch equipment", m.Equipment, ". Error:", err)
+			failed = err
+		}
+	}
+	return failed
+}
+
+// startSequence cancels a running sequence of a group and registers a new one
+func (c *Controller) startSequence(id string) chan struct{} {
+	c.Lock()
+	defer c.Unlock()
+	if quit, ok := c.sequences[id]; ok {
+		close(quit)
+	}
+	quit := make(chan struct{})
+	c.sequences[id] = quit
+	return quit
+}
+
+func (c *Controller) cancelSequence(id string) {
+	c.Lock()
+	defer c.Unlock()
+	if quit, ok := c.sequences[id]; ok {
+		close(quit)
+		delete(c.sequences, id)
+	}
+}
+
+func (c *Controller) stopSequences() {
+	c.Lock()
+	defer c.Unlock()
+	for id, quit := range c.sequences {
+		close(quit)
+		delete(c.sequences, id)
+	}
+}
+
+// groupsUsing returns the names of groups an equipment is a member of
+func (c *Controller) groupsUsing(eqID string) ([]string, error) {
+	gs, err := c.ListGroups()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, g := range gs {
+		for _, m := range g.Members {
+			if m.Equipment == eqID {
+				names = append(names, g.Name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// removeGroupMember drops a deleted equipment from all groups
+func (c *Controller) removeGroupMember(eqID string) error {
+	gs, err := c.ListGroups()
+	if err != nil {
+		return err
+	}
+	for _, g := range gs {
+		members := g.Members[:0]
+		for _, m := range g.Members {
+			if m.Equipment != eqID {
+				members = append(members, m)
+			}
+		}
+		if len(members) == len(g.Members) {
+			continue
+		}
+		g.Members = members
+		if err := c.store.Update(GroupBucket, g.ID, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
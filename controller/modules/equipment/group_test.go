@@ -0,0 +1,149 @@
+package equipment
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestGroups(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	c := New(con)
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"Return", "Skimmer", "Feed light"} {
+		if err := outlets.Create(connectors.Outlet{Name: name, Pin: 20 + i, Driver: "rpi"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Create(Equipment{Name: name, Outlet: strconv.Itoa(i + 1)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(Group{Name: "Pumps", Members: []Member{{Equipment: "9"}}})
+	if err := tr.Do("PUT", "/api/equipment_groups", body, nil); err == nil {
+		t.Error("expected group with unknown member to be rejected")
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(Group{Name: "Pumps", Members: []Member{{Equipment: "1"}, {Equipment: "1"}}})
+	if err := tr.Do("PUT", "/api/equipment_groups", body, nil); err == nil {
+		t.Error("expected group with duplicate members to be rejected")
+	}
+	body.Reset()
+	g := Group{Name: "Pumps", Members: []Member{{Equipment: "1"}, {Equipment: "2"}, {Equipment: "3", Invert: true}}}
+	json.NewEncoder(body).Encode(g)
+	if err := tr.Do("PUT", "/api/equipment_groups", body, nil); err != nil {
+		t.Fatal("Failed to create group using api", err)
+	}
+
+	if err := c.On(GroupPrefix+"1", true); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]bool{"1": true, "2": true, "3": false} {
+		if eq, _ := c.Get(id); eq.On != want {
+			t.Error("unexpected state of", eq.Name, "after turning the group on:", eq.On)
+		}
+	}
+	e, err := c.GetEntity(GroupPrefix + "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := e.Status(); !s.(EquipmentStatus).On || e.EName() != "Pumps" {
+		t.Error("expected group entity to report its state", s)
+	}
+	if deps, err := c.InUse(storage.EquipmentBucket, "2"); err != nil || len(deps) != 1 || deps[0] != "Pumps" {
+		t.Error("expected member to be reported in use by the group", deps, err)
+	}
+
+	if err := c.SetOverride("2", Override{On: true}); err != nil {
+		t.Fatal(err)
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(EquipmentAction{On: false})
+	if err := tr.Do("POST", "/api/equipment_groups/1/control", body, nil); err != nil {
+		t.Fatal("Failed to control group using api", err)
+	}
+	for id, want := range map[string]bool{"1": false, "2": true, "3": true} {
+		if eq, _ := c.Get(id); eq.On != want {
+			t.Error("unexpected state of", eq.Name, "after turning the group off:", eq.On)
+		}
+	}
+
+	g.Delay = 1
+	g.Members = g.Members[:2]
+	body.Reset()
+	json.NewEncoder(body).Encode(g)
+	if err := tr.Do("POST", "/api/equipment_groups/1", body, nil); err != nil {
+		t.Fatal("Failed to update group using api", err)
+	}
+	if err := c.ClearOverride("2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.On("2", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GroupOn("1", true); err != nil {
+		t.Fatal(err)
+	}
+	if eq, _ := c.Get("2"); eq.On {
+		t.Error("expected second member to wait for the group delay")
+	}
+	eventually(t, "expected second member to be switched after the delay", func() bool {
+		eq, err := c.Get("2")
+		return err == nil && eq.On
+	})
+	if err := c.PauseEntity(GroupPrefix+"1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "2"} {
+		if eq, _ := c.Get(id); eq.On || eq.Override == nil || !eq.Override.Paused {
+			t.Error("expected pausing a group to pause its members", eq)
+		}
+	}
+	if err := c.ResumeEntity(GroupPrefix + "1"); err != nil {
+		t.Fatal(err)
+	}
+	if eq, _ := c.Get("2"); !eq.On || eq.Override != nil {
+		t.Error("expected resuming a group to resume its members", eq)
+	}
+
+	if err := c.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Do("GET", "/api/equipment_groups/1", strings.NewReader("{}"), &g); err != nil {
+		t.Fatal("Failed to get group using api", err)
+	}
+	if len(g.Members) != 1 || g.Members[0].Equipment != "2" {
+		t.Error("expected deleted equipment to be removed from the group", g.Members)
+	}
+	var gs []Group
+	if err := tr.Do("GET", "/api/equipment_groups", strings.NewReader("{}"), &gs); err != nil || len(gs) != 1 {
+		t.Error("Failed to list groups using api", gs, err)
+	}
+	if err := tr.Do("DELETE", "/api/equipment_groups/1", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to delete group using api", err)
+	}
+	if err := c.On(GroupPrefix+"1", true); err == nil {
+		t.Error("expected deleted group to be unknown")
+	}
+}
@@ -0,0 +1,244 @@
+package equipment
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+const MaintenanceBucket = storage.MaintenanceBucket
+
+// interval between maintenance threshold checks
+var maintenanceInterval = 10 * time.Minute
+
+// Maintenance is a recurring task that falls due after an equipment has run for a number of
+// hours or has been switched on a number of times since the task was last reset
+//
+// swagger:model maintenance
+type Maintenance struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Equipment string `json:"equipment"`
+	// Hours is the on time threshold, 0 disables it
+	Hours float64 `json:"hours"`
+	// Cycles is the switch cycle threshold, 0 disables it
+	Cycles int `json:"cycles"`
+	// BaseHours and BaseCycles are the equipment runtime when the task was last reset
+	BaseHours  float64   `json:"base_hours"`
+	BaseCycles int       `json:"base_cycles"`
+	LastReset  time.Time `json:"last_reset"`
+	// Notified is true once a reminder has been sent for the current period
+	Notified bool               `json:"notified"`
+	Status   *MaintenanceStatus `json:"status,omitempty"`
+}
+
+// MaintenanceStatus is the equipment usage since a maintenance task was last reset
+//
+// swagger:model maintenanceStatus
+type MaintenanceStatus struct {
+	Hours  float64 `json:"hours"`
+	Cycles int     `json:"cycles"`
+	Due    bool    `json:"due"`
+}
+
+func (m Maintenance) IsValid() error {
+	if m.Name == "" {
+		return fmt.Errorf("maintenance task name can not be empty")
+	}
+	if m.Hours < 0 || m.Cycles < 0 {
+		return fmt.Errorf("maintenance thresholds can not be negative")
+	}
+	if m.Hours == 0 && m.Cycles == 0 {
+		return fmt.Errorf("maintenance task requires an hours or cycles threshold")
+	}
+	return nil
+}
+
+func (m Maintenance) status(rt Runtime) *MaintenanceStatus {
+	s := &MaintenanceStatus{
+		Hours:  rt.Hours - m.BaseHours,
+		Cycles: rt.Cycles - m.BaseCycles,
+	}
+	s.Due = (m.Hours > 0 && s.Hours >= m.Hours) || (m.Cycles > 0 && s.Cycles >= m.Cycles)
+	return s
+}
+
+func (c *Controller) withStatus(m Maintenance) (Maintenance, error) {
+	rt, err := c.Runtime(m.Equipment)
+	if err != nil {
+		return m, err
+	}
+	m.Status = m.status(rt)
+	return m, nil
+}
+
+func (c *Controller) GetMaintenance(id string) (Maintenance, error) {
+	var m Maintenance
+	if err := c.store.Get(MaintenanceBucket, id, &m); err != nil {
+		return m, err
+	}
+	return c.withStatus(m)
+}
+
+func (c *Controller) ListMaintenance() ([]Maintenance, error) {
+	ms := []Maintenance{}
+	fn := func(_ string, v []byte) error {
+		var m Maintenance
+		if err := json.Unmarshal(v, &m); err != nil {
+			return err
+		}
+		ms = append(ms, m)
+		return nil
+	}
+	if err := c.store.List(MaintenanceBucket, fn); err != nil {
+		return nil, err
+	}
+	for i, m := range ms {
+		m, err := c.withStatus(m)
+		if err != nil {
+			return nil, err
+		}
+		ms[i] = m
+	}
+	return ms, nil
+}
+
+func (c *Controller) CreateMaintenance(m Maintenance) error {
+	if err := m.IsValid(); err != nil {
+		return err
+	}
+	rt, err := c.Runtime(m.Equipment)
+	if err != nil {
+		return fmt.Errorf("equipment '%s' does not exist. Error: %w", m.Equipment, err)
+	}
+	m.BaseHours = rt.Hours
+	m.BaseCycles = rt.Cycles
+	m.LastReset = c.now()
+	m.Notified = false
+	m.Status = nil
+	fn := func(id string) interface{} {
+		m.ID = id
+		return &m
+	}
+	return c.store.Create(MaintenanceBucket, fn)
+}
+
+// UpdateMaintenance changes the name or thresholds of a task. The usage baseline is kept
+func (c *Controller) UpdateMaintenance(id string, m Maintenance) error {
+	if err := m.IsValid(); err != nil {
+		return err
+	}
+	old, err := c.GetMaintenance(id)
+	if err != nil {
+		return err
+	}
+	if m.Equipment != old.Equipment {
+		return fmt.Errorf("equipment of a maintenance task can not be changed")
+	}
+	m.ID = id
+	m.BaseHours = old.BaseHours
+	m.BaseCycles = old.BaseCycles
+	m.LastReset = old.LastReset
+	m.Notified = old.Notified && m.status(old.runtime()).Due
+	m.Status = nil
+	return c.store.Update(MaintenanceBucket, id, m)
+}
+
+// runtime reconstructs the equipment runtime from the usage status of a task
+func (m Maintenance) runtime() Runtime {
+	return Runtime{Hours: m.BaseHours + m.Status.Hours, Cycles: m.BaseCycles + m.Status.Cycles}
+}
+
+func (c *Controller) DeleteMaintenance(id string) error {
+	return c.store.Delete(MaintenanceBucket, id)
+}
+
+// ResetMaintenance marks a task as done, restarting its period from the current equipment runtime
+func (c *Controller) ResetMaintenance(id string) error {
+	m, err := c.GetMaintenance(id)
+	if err != nil {
+		return err
+	}
+	rt := m.runtime()
+	m.BaseHours = rt.Hours
+	m.BaseCycles = rt.Cycles
+	m.LastReset = c.now()
+	m.Notified = false
+	m.Status = nil
+	return c.store.Update(MaintenanceBucket, id, m)
+}
+
+// deleteEquipmentMaintenance removes the maintenance tasks of a deleted equipment
+func (c *Controller) deleteEquipmentMaintenance(eqID string) error {
+	var ids []string
+	fn := func(id string, v []byte) error {
+		var m Maintenance
+		if err := json.Unmarshal(v, &m); err != nil {
+			return err
+		}
+		if m.Equipment == eqID {
+			ids = append(ids, id)
+		}
+		return nil
+	}
+	if err := c.store.List(MaintenanceBucket, fn); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := c.store.Delete(MaintenanceBucket, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Controller) maintenanceLoop(quit chan struct{}) {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.maintain()
+		case <-quit:
+			return
+		}
+	}
+}
+
+// maintain checkpoints runtime, so at most one interval of on time is lost if reef-pi does not
+// stop cleanly, and sends due maintenance reminders
+func (c *Controller) maintain() {
+	c.checkpointRuntime(false)
+	c.checkMaintenance()
+}
+
+// checkMaintenance sends a reminder for each task that fell due since it was last reset
+func (c *Controller) checkMaintenance() {
+	ms, err := c.ListMaintenance()
+	if err != nil {
+		log.Println("ERROR: equipment subsystem: failed to list maintenance tasks. Error:", err)
+		return
+	}
+	for _, m := range ms {
+		if m.Notified || !m.Status.Due {
+			continue
+		}
+		eq, err := c.Get(m.Equipment)
+		if err != nil {
+			continue
+		}
+		subject := "Maintenance due: " + m.Name
+		body := fmt.Sprintf("%s has run %.1f hours and was switched on %d times since %s. Reset the task once '%s' is done.",
+			eq.Name, m.Status.Hours, m.Status.Cycles, m.LastReset.Format(time.RFC1123), m.Name)
+		log.Println("WARNING: equipment subsystem:", body)
+		c.telemetry.Alert(subject, body)
+		m.Notified = true
+		m.Status = nil
+		if err := c.store.Update(MaintenanceBucket, m.ID, m); err != nil {
+			log.Println("ERROR: equipment subsystem: failed to save maintenance task", m.Name, ". Error:", err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package equipment
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestRuntimeAndMaintenance(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Create(connectors.Outlet{Name: "uv", Pin: 21, Driver: "rpi"}); err != nil {
+		t.Fatal(err)
+	}
+	c := New(con)
+	now := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "UV sterilizer", Outlet: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(Maintenance{Name: "Replace bulb", Equipment: "1"})
+	if err := tr.Do("PUT", "/api/maintenance", body, nil); err == nil {
+		t.Error("expected maintenance task without thresholds to be rejected")
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(Maintenance{Name: "Replace bulb", Equipment: "2", Hours: 10})
+	if err := tr.Do("PUT", "/api/maintenance", body, nil); err == nil {
+		t.Error("expected maintenance task of unknown equipment to be rejected")
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(Maintenance{Name: "Replace bulb", Equipment: "1", Hours: 10, Cycles: 3})
+	if err := tr.Do("PUT", "/api/maintenance", body, nil); err != nil {
+		t.Fatal("Failed to create maintenance task using api", err)
+	}
+
+	// on for 6 hours, off for 2, on for 5
+	if err := c.On("1", true); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(6 * time.Hour)
+	if err := c.On("1", false); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Hour)
+	if err := c.On("1", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.On("1", true); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(5 * time.Hour)
+
+	var rt Runtime
+	if err := tr.Do("GET", "/api/equipment/1/runtime", strings.NewReader("{}"), &rt); err != nil {
+		t.Fatal("Failed to get runtime using api", err)
+	}
+	if !rt.On || rt.Hours != 11 || rt.Cycles != 2 || !rt.LastChanged.Equal(now.Add(-5*time.Hour)) {
+		t.Error("unexpected runtime", rt)
+	}
+
+	// time while reef-pi is down is not counted
+	c.Stop()
+	now = now.Add(24 * time.Hour)
+	c.checkpointRuntime(true)
+	if rt, err := c.Runtime("1"); err != nil || rt.Hours != 11 {
+		t.Error("expected downtime to be skipped", rt, err)
+	}
+
+	var m Maintenance
+	if err := tr.Do("GET", "/api/maintenance/1", strings.NewReader("{}"), &m); err != nil {
+		t.Fatal("Failed to get maintenance task using api", err)
+	}
+	if m.Status == nil || !m.Status.Due || m.Status.Hours != 11 || m.Status.Cycles != 2 {
+		t.Error("expected maintenance to be due", m.Status)
+	}
+	c.checkMaintenance()
+	if m, _ := c.GetMaintenance("1"); !m.Notified {
+		t.Error("expected reminder to be sent")
+	}
+
+	m.Hours = 20
+	body.Reset()
+	json.NewEncoder(body).Encode(m)
+	if err := tr.Do("POST", "/api/maintenance/1", body, nil); err != nil {
+		t.Fatal("Failed to update maintenance task using api", err)
+	}
+	if m, _ := c.GetMaintenance("1"); m.Notified || m.Status.Due || m.BaseHours != 0 {
+		t.Error("expected raised threshold to clear the reminder and keep the baseline", m)
+	}
+
+	if err := tr.Do("POST", "/api/maintenance/1/reset", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to reset maintenance task using api", err)
+	}
+	if m, _ := c.GetMaintenance("1"); m.BaseHours != 11 || m.BaseCycles != 2 || m.Status.Hours != 0 || !m.LastReset.Equal(now) {
+		t.Error("expected maintenance period to restart", m)
+	}
+
+	// on time up to the last periodic checkpoint survives reef-pi not stopping cleanly
+	now = now.Add(3 * time.Hour)
+	c.maintain()
+	now = now.Add(time.Hour)
+	c.checkpointRuntime(true)
+	if rt, err := c.Runtime("1"); err != nil || rt.Hours != 14 {
+		t.Error("expected on time up to the last checkpoint to be kept", rt, err)
+	}
+
+	var ms []Maintenance
+	if err := tr.Do("GET", "/api/maintenance", strings.NewReader("{}"), &ms); err != nil || len(ms) != 1 {
+		t.Error("Failed to list maintenance tasks using api", ms, err)
+	}
+	if err := c.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	if ms, err := c.ListMaintenance(); err != nil || len(ms) != 0 {
+		t.Error("expected maintenance tasks to be deleted with the equipment", ms, err)
+	}
+}
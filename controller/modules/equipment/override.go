@@ -0,0 +1,251 @@
+package equipment
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Override holds an equipment in a forced state. While it is active timers, macros and
+// controllers can not change the equipment
+//
+// swagger:model equipmentOverride
+type Override struct {
+	On bool `json:"on"`
+	// Duration is the number of seconds to hold the state, 0 holds it until the override is cleared
+	Duration int `json:"duration"`
+	// Until is when the override expires, zero if it is held until cleared
+	Until time.Time `json:"until"`
+	// Resume is the state restored when the override expires or is cleared
+	Resume bool `json:"resume"`
+	// Paused is set for overrides that pause the equipment, e.g. while feeding
+	Paused bool `json:"paused,omitempty"`
+	// Interlock is set for overrides holding the equipment off after a safety shutdown. Only
+	// releasing the interlock clears them
+	Interlock bool `json:"interlock,omitempty"`
+}
+
+func (o Override) IsValid() error {
+	if o.Duration < 0 {
+		return fmt.Errorf("override duration can not be negative")
+	}
+	return nil
+}
+
+func (o *Override) expired(t time.Time) bool {
+	return o != nil && !o.Until.IsZero() && !t.Before(o.Until)
+}
+
+// SetOverride forces the state of an equipment, replacing any active override. The state
+// before the first override is kept to be resumed later
+func (c *Controller) SetOverride(id string, o Override) error {
+	if err := o.IsValid(); err != nil {
+		return err
+	}
+	c.commands.Lock()
+	defer c.commands.Unlock()
+	eq, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if eq.Override != nil && eq.Override.Interlock && !o.Interlock {
+		return fmt.Errorf("equipment '%s' is held off by a safety interlock", eq.Name)
+	}
+	o.Resume = eq.On
+	if eq.Override != nil {
+		o.Resume = eq.Override.Resume
+	}
+	o.Until = time.Time{}
+	if o.Duration > 0 {
+		o.Until = c.now().Add(time.Duration(o.Duration) * time.Second)
+	}
+	c.release(id)
+	eq.On = o.On
+	eq.Override = &o
+	if err := c.save(eq); err != nil {
+		return err
+	}
+	log.Println("INFO: equipment subsystem:", eq.Name, "overridden. On:", o.On, "Duration:", o.Duration)
+	c.scheduleResume(eq)
+	return nil
+}
+
+// ClearOverride ends the override of an equipment and resumes its previous state
+func (c *Controller) ClearOverride(id string) error {
+	return c.endOverride(id, false)
+}
+
+// endOverride ends an override, safety interlocks are only cleared when release is set
+func (c *Controller) endOverride(id string, release bool) error {
+	c.commands.Lock()
+	defer c.commands.Unlock()
+	c.cancelResume(id)
+	eq, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if eq.Override == nil {
+		return nil
+	}
+	if eq.Override.Interlock && !release {
+		return fmt.Errorf("equipment '%s' is held off by a safety interlock", eq.Name)
+	}
+	eq.On = eq.Override.Resume
+	eq.Override = nil
+	log.Println("INFO: equipment subsystem:", eq.Name, "override cleared. On:", eq.On)
+	return c.save(eq)
+}
+
+// PauseEntity turns an equipment, or all members of a group, off for d, e.g. while feeding.
+// Equipment under a manual override is left alone
+func (c *Controller) PauseEntity(id string, d time.Duration) error {
+	ids, err := c.equipmentOf(id)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := c.pause(id, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Controller) pause(id string, d time.Duration) error {
+	eq, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if eq.Override != nil && !eq.Override.Paused {
+		log.Println("INFO: equipment subsystem:", eq.Name, "is overridden, not pausing it")
+		return nil
+	}
+	return c.SetOverride(id, Override{Duration: pauseSeconds(d), Paused: true})
+}
+
+// ResumeEntity ends a pause early, manual overrides are kept
+func (c *Controller) ResumeEntity(id string) error {
+	ids, err := c.equipmentOf(id)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := c.resume(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Controller) resume(id string) error {
+	eq, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if eq.Override == nil || !eq.Override.Paused {
+		return nil
+	}
+	return c.ClearOverride(id)
+}
+
+// Interlock holds an equipment, or all members of a group, off until released, e.g. after a leak
+// is detected. It replaces manual overrides and pauses
+func (c *Controller) Interlock(id string) error {
+	ids, err := c.equipmentOf(id)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		eq, err := c.Get(id)
+		if err != nil {
+			return err
+		}
+		if eq.Override != nil && eq.Override.Interlock {
+			continue
+		}
+		if err := c.SetOverride(id, Override{Interlock: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release ends an interlock, equipment get their state from before any override back
+func (c *Controller) Release(id string) error {
+	ids, err := c.equipmentOf(id)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		eq, err := c.Get(id)
+		if err != nil {
+			return err
+		}
+		if eq.Override == nil || !eq.Override.Interlock {
+			continue
+		}
+		if err := c.endOverride(id, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pauseSeconds rounds a pause up to whole seconds, as an override of 0 seconds never expires
+func pauseSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+func (c *Controller) scheduleResume(eq Equipment) {
+	c.cancelResume(eq.ID)
+	if eq.Override == nil || eq.Override.Until.IsZero() {
+		return
+	}
+	id := eq.ID
+	t := time.AfterFunc(eq.Override.Until.Sub(c.now()), func() {
+		if err := c.ClearOverride(id); err != nil {
+			log.Println("ERROR: equipment subsystem: failed to resume equipment", id, "after override. Error:", err)
+		}
+	})
+	c.Lock()
+	c.resumers[id] = t
+	c.Unlock()
+}
+
+func (c *Controller) cancelResume(id string) {
+	c.Lock()
+	defer c.Unlock()
+	if t, ok := c.resumers[id]; ok {
+		t.Stop()
+		delete(c.resumers, id)
+	}
+}
+
+// resumeOverrides clears overrides that expired while reef-pi was down and schedules the others
+func (c *Controller) resumeOverrides() {
+	eqs, err := c.List()
+	if err != nil {
+		log.Println("ERROR: equipment subsystem: failed to list equipment. Error:", err)
+		return
+	}
+	for _, eq := range eqs {
+		switch {
+		case eq.Override == nil:
+		case eq.Override.expired(c.now()):
+			if err := c.ClearOverride(eq.ID); err != nil {
+				log.Println("ERROR: equipment subsystem: failed to clear expired override of", eq.Name, ". Error:", err)
+			}
+		default:
+			c.scheduleResume(eq)
+		}
+	}
+}
+
+func (c *Controller) stopResumers() {
+	c.Lock()
+	defer c.Unlock()
+	for id, t := range c.resumers {
+		t.Stop()
+		delete(c.resumers, id)
+	}
+}
@@ -0,0 +1,128 @@
+package equipment
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestOverride(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Create(connectors.Outlet{Name: "return", Pin: 21, Driver: "rpi"}); err != nil {
+		t.Fatal(err)
+	}
+	c := New(con)
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "Return pump", Outlet: "1", On: true}); err != nil {
+		t.Fatal(err)
+	}
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+	c.Start()
+	defer c.Stop()
+
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(Override{On: false, Duration: -1})
+	if err := tr.Do("POST", "/api/equipment/1/override", body, nil); err == nil {
+		t.Error("expected negative override duration to be rejected")
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(Override{On: false})
+	if err := tr.Do("POST", "/api/equipment/1/override", body, nil); err != nil {
+		t.Fatal("Failed to override equipment using api", err)
+	}
+	if err := c.On("1", true); err != nil {
+		t.Error(err)
+	}
+	if eq, _ := c.Get("1"); eq.On {
+		t.Error("expected automation to be ignored while overridden")
+	}
+	if err := c.Control("1", true); err == nil {
+		t.Error("expected manual control to be rejected while overridden")
+	}
+	eq, _ := c.Get("1")
+	eq.On = true
+	eq.Name = "Main return"
+	if err := c.Update("1", eq); err != nil {
+		t.Fatal(err)
+	}
+	e, err := c.GetEntity("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := e.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := s.(EquipmentStatus)
+	if status.On || status.Override == nil || !status.Override.Resume || e.EName() != "Main return" {
+		t.Error("expected status to report the override", status)
+	}
+
+	if err := tr.Do("DELETE", "/api/equipment/1/override", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to clear override using api", err)
+	}
+	if eq, _ := c.Get("1"); !eq.On || eq.Override != nil {
+		t.Error("expected equipment to resume its previous state", eq)
+	}
+
+	if err := c.SetOverride("1", Override{On: false, Duration: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetOverride("1", Override{On: true, Duration: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if eq, _ := c.Get("1"); !eq.On || !eq.Override.Resume || eq.Override.Until.IsZero() {
+		t.Error("expected replaced override to keep the original resume state", eq.Override)
+	}
+	if err := c.On("1", false); err != nil {
+		t.Error(err)
+	}
+	eventually(t, "expected equipment to resume after the override expired", func() bool {
+		eq, err := c.Get("1")
+		return err == nil && eq.On && eq.Override == nil
+	})
+
+	if err := c.SetOverride("1", Override{On: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Interlock("1"); err != nil {
+		t.Fatal(err)
+	}
+	if eq, _ := c.Get("1"); eq.On || eq.Override == nil || !eq.Override.Interlock {
+		t.Error("expected interlock to replace the manual override", eq.Override)
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(Override{On: true, Interlock: true})
+	if err := tr.Do("POST", "/api/equipment/1/override", body, nil); err == nil {
+		t.Error("expected override of interlocked equipment to be rejected")
+	}
+	if err := tr.Do("DELETE", "/api/equipment/1/override", strings.NewReader("{}"), nil); err == nil {
+		t.Error("expected interlock to survive clearing the override")
+	}
+	if err := c.PauseEntity("1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Release("1"); err != nil {
+		t.Fatal(err)
+	}
+	if eq, _ := c.Get("1"); !eq.On || eq.Override != nil {
+		t.Error("expected released equipment to resume its state from before any override", eq)
+	}
+}
@@ -0,0 +1,109 @@
+package equipment
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+const RuntimeBucket = storage.EquipmentRuntimeBucket
+
+// Runtime tracks how long and how often an equipment has been switched on
+//
+// swagger:model equipmentRuntime
+type Runtime struct {
+	On bool `json:"on"`
+	// Hours is the cumulative on time
+	Hours float64 `json:"hours"`
+	// Cycles is the number of times the equipment was switched on
+	Cycles      int       `json:"cycles"`
+	LastChanged time.Time `json:"last_changed"`
+	// Checkpoint is the time up to which on time is accounted in Hours
+	Checkpoint time.Time `json:"checkpoint"`
+}
+
+// at returns the runtime with on time accounted up to t
+func (rt Runtime) at(t time.Time) Runtime {
+	if rt.On && t.After(rt.Checkpoint) {
+		rt.Hours += t.Sub(rt.Checkpoint).Hours()
+	}
+	rt.Checkpoint = t
+	return rt
+}
+
+// loadRuntime expects the caller to hold the lock
+func (c *Controller) loadRuntime(id string) (Runtime, error) {
+	var rt Runtime
+	data, err := c.store.RawGet(RuntimeBucket, id)
+	if err != nil || len(data) == 0 {
+		return rt, err
+	}
+	return rt, json.Unmarshal(data, &rt)
+}
+
+// Runtime returns the cumulative on time, switch cycles and last state change of an equipment
+func (c *Controller) Runtime(id string) (Runtime, error) {
+	if _, err := c.Get(id); err != nil {
+		return Runtime{}, err
+	}
+	c.Lock()
+	defer c.Unlock()
+	rt, err := c.loadRuntime(id)
+	if err != nil {
+		return rt, err
+	}
+	return rt.at(c.now()), nil
+}
+
+// recordState accounts a change of equipment state
+func (c *Controller) recordState(eq Equipment) {
+	c.Lock()
+	defer c.Unlock()
+	rt, err := c.loadRuntime(eq.ID)
+	if err != nil {
+		log.Println("ERROR: equipment subsystem: failed to load runtime of", eq.Name, ". Error:", err)
+		return
+	}
+	if !rt.LastChanged.IsZero() && rt.On == eq.On {
+		return
+	}
+	now := c.now()
+	rt = rt.at(now)
+	if eq.On {
+		rt.Cycles++
+	}
+	rt.On = eq.On
+	rt.LastChanged = now
+	if err := c.store.Update(RuntimeBucket, eq.ID, rt); err != nil {
+		log.Println("ERROR: equipment subsystem: failed to save runtime of", eq.Name, ". Error:", err)
+	}
+}
+
+// checkpointRuntime accounts on time up to now. It runs periodically and on stop. With resume, time
+// elapsed since the last checkpoint is skipped instead, as equipment is not powered while reef-pi is down
+func (c *Controller) checkpointRuntime(resume bool) {
+	eqs, err := c.List()
+	if err != nil {
+		log.Println("ERROR: equipment subsystem: failed to list equipment. Error:", err)
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	now := c.now()
+	for _, eq := range eqs {
+		rt, err := c.loadRuntime(eq.ID)
+		if err != nil || !rt.On {
+			continue
+		}
+		if resume {
+			rt.Checkpoint = now
+		} else {
+			rt = rt.at(now)
+		}
+		if err := c.store.Update(RuntimeBucket, eq.ID, rt); err != nil {
+			log.Println("ERROR: equipment subsystem: failed to save runtime of", eq.Name, ". Error:", err)
+		}
+	}
+}
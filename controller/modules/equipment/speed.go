@@ -0,0 +1,73 @@
+package equipment
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+)
+
+// default speed of variable speed equipment created without one
+const defaultSpeed = 100
+
+//swagger:model equipmentSpeed
+type EquipmentSpeed struct {
+	// Speed in percent, 0 turns the equipment off
+	Speed float64 `json:"speed"`
+}
+
+// variableSpeed is true for equipment driven from a jack pin instead of an outlet
+func (eq Equipment) variableSpeed() bool {
+	return eq.Jack != ""
+}
+
+func (c *Controller) validateJack(eq Equipment) error {
+	j, err := c.jacks.Get(eq.Jack)
+	if err != nil {
+		return fmt.Errorf("jack '%s' does not exist. Error: %w", eq.Jack, err)
+	}
+	for _, pin := range j.Pins {
+		if pin == eq.Pin {
+			owner := connectors.PinOwner{Type: connectors.EquipmentPin, ID: eq.ID, Name: eq.Name}
+			return c.pins.CheckJack(owner, eq.Jack, eq.Pin)
+		}
+	}
+	return fmt.Errorf("jack '%s' does not have pin %d", j.Name, eq.Pin)
+}
+
+// drive switches the outlet of an equipment, or sets its jack pin to the configured speed
+func (c *Controller) drive(eq Equipment, on bool) error {
+	if !eq.variableSpeed() {
+		return c.outlets.Configure(eq.Outlet, on)
+	}
+	v := 0.0
+	if on {
+		v = eq.Speed
+	}
+	return c.jacks.Control(eq.Jack, connectors.PinValues{eq.Pin: v})
+}
+
+// SetSpeed runs a variable speed equipment at the given speed, 0 turns it off and keeps the
+// configured speed. It has no effect while the equipment is overridden
+func (c *Controller) SetSpeed(id string, speed float64) error {
+	if speed < 0 || speed > 100 {
+		return fmt.Errorf("invalid speed %f, must be between 0 and 100", speed)
+	}
+	eq, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if !eq.variableSpeed() {
+		return fmt.Errorf("equipment '%s' is not driven by a jack and has no speed control", eq.Name)
+	}
+	if eq.Override != nil {
+		log.Println("INFO: equipment subsystem:", eq.Name, "is overridden, ignoring request to set speed:", speed)
+		return nil
+	}
+	log.Println("Equipment:", eq.Name, "Speed:", speed)
+	eq.On = speed > 0
+	if eq.On {
+		eq.Speed = speed
+	}
+	return c.Update(id, eq)
+}
@@ -0,0 +1,137 @@
+package equipment
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestVariableSpeed(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Create(connectors.Outlet{Name: "heater", Pin: 21, Driver: "rpi"}); err != nil {
+		t.Fatal(err)
+	}
+	jacks := con.DM().Jacks()
+	if err := jacks.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.Create(connectors.Jack{Name: "DC", Pins: []int{0, 1}, Driver: "rpi"}); err != nil {
+		t.Fatal(err)
+	}
+	c := New(con)
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "bad", Outlet: "1", Jack: "1"}); err == nil {
+		t.Error("expected equipment with an outlet and a jack to be rejected")
+	}
+	if err := c.Create(Equipment{Name: "bad", Jack: "1", Pin: 5}); err == nil {
+		t.Error("expected unknown jack pin to be rejected")
+	}
+	if err := c.Create(Equipment{Name: "bad", Jack: "1", Speed: 120}); err == nil {
+		t.Error("expected speed above 100 to be rejected")
+	}
+	if err := c.Create(Equipment{Name: "Heater", Outlet: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "Return pump", Jack: "1", Pin: 1, Watts: 40}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "bad", Jack: "1", Pin: 1}); err == nil {
+		t.Error("expected jack pin driven by another equipment to be rejected")
+	}
+	if err := con.Store().CreateBucket(storage.LightingBucket); err != nil {
+		t.Fatal(err)
+	}
+	light := func(id string) interface{} {
+		return map[string]interface{}{"id": id, "name": "Blue", "jack": "1", "channels": map[string]interface{}{"0": map[string]int{"pin": 0}}}
+	}
+	if err := con.Store().Create(storage.LightingBucket, light); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "bad", Jack: "1", Pin: 0}); err == nil {
+		t.Error("expected jack pin driven by a light to be rejected")
+	}
+	if err := outlets.Create(connectors.Outlet{Name: "powerhead", Pin: 20, Driver: "rpi"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := con.Store().CreateBucket(storage.WavemakerBucket); err != nil {
+		t.Fatal(err)
+	}
+	gyre := func(id string) interface{} {
+		return map[string]interface{}{"id": id, "name": "Gyre", "pumps": []map[string]string{{"outlet": "2"}}}
+	}
+	if err := con.Store().Create(storage.WavemakerBucket, gyre); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Equipment{Name: "bad", Outlet: "2"}); err == nil {
+		t.Error("expected outlet switched by a wavemaker to be rejected")
+	}
+	if err := c.Update("1", Equipment{Name: "Heater", Outlet: "2"}); err == nil {
+		t.Error("expected equipment moving to an outlet switched by a wavemaker to be rejected")
+	}
+	if err := c.Update("1", Equipment{Name: "Heater", Outlet: "1"}); err != nil {
+		t.Error("expected equipment to keep its own outlet", err)
+	}
+	if eq, _ := c.Get("2"); eq.Speed != defaultSpeed {
+		t.Error("expected default speed, found:", eq.Speed)
+	}
+	if deps, err := c.InUse(storage.JackBucket, "1"); err != nil || len(deps) != 1 {
+		t.Error("expected jack to be reported in use", deps, err)
+	}
+
+	if err := c.On("2", true); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := jacks.Values("1"); v[1] != 100 {
+		t.Error("expected pump to run at its configured speed", v)
+	}
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(EquipmentSpeed{Speed: 40})
+	if err := tr.Do("POST", "/api/equipment/2/speed", body, nil); err != nil {
+		t.Fatal("Failed to set speed using api", err)
+	}
+	if v, _ := jacks.Values("1"); v[1] != 40 {
+		t.Error("expected pump to run at 40%", v)
+	}
+	eq, _ := c.Get("2")
+	if e, ok := c.power(eq); !ok || e.Watts != 16 {
+		t.Error("expected power estimate to scale with speed", e)
+	}
+	if err := c.SetSpeed("2", 0); err != nil {
+		t.Fatal(err)
+	}
+	if eq, _ := c.Get("2"); eq.On || eq.Speed != 40 {
+		t.Error("expected speed 0 to turn the pump off and keep its speed", eq)
+	}
+	if v, _ := jacks.Values("1"); v[1] != 0 {
+		t.Error("expected pump to be stopped", v)
+	}
+	if err := c.On("2", true); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := jacks.Values("1"); v[1] != 40 {
+		t.Error("expected pump to resume at its last speed", v)
+	}
+	if err := c.SetSpeed("1", 50); err == nil {
+		t.Error("expected speed control of outlet equipment to be rejected")
+	}
+	if err := c.SetSpeed("2", -1); err == nil {
+		t.Error("expected negative speed to be rejected")
+	}
+}
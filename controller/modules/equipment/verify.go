@@ -0,0 +1,112 @@
+package equipment
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+)
+
+// interval between outlet state read-backs
+var verifyInterval = time.Minute
+
+// Observation is the outlet state last read back from the device
+//
+// swagger:model observedState
+type Observation struct {
+	On bool `json:"on"`
+	// Drift is true when the device did not match the desired equipment state
+	Drift bool      `json:"drift"`
+	Time  time.Time `json:"time"`
+	Error string    `json:"error,omitempty"`
+}
+
+func (c *Controller) observation(id string) *Observation {
+	c.Lock()
+	defer c.Unlock()
+	o, ok := c.observed[id]
+	if !ok {
+		return nil
+	}
+	return &o
+}
+
+func (c *Controller) verifyLoop(quit chan struct{}) {
+	ticker := time.NewTicker(verifyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.verifyAll()
+		case <-quit:
+			return
+		}
+	}
+}
+
+func (c *Controller) verifyAll() {
+	eqs, err := c.List()
+	if err != nil {
+		log.Println("ERROR: equipment subsystem: failed to list equipment. Error:", err)
+		return
+	}
+	for _, eq := range eqs {
+		c.verify(eq.ID)
+	}
+}
+
+// verify reads back the outlet state of an equipment. If it does not match the desired
+// state, the desired state is re-asserted and an alert is sent when the drift starts
+func (c *Controller) verify(id string) {
+	c.commands.Lock()
+	defer c.commands.Unlock()
+	eq, err := c.Get(id)
+	if err != nil || eq.Outlet == "" || c.isPending(id) {
+		return
+	}
+	on, err := c.outlets.Observe(eq.Outlet)
+	if errors.Is(err, connectors.ErrReadBackUnsupported) {
+		c.Lock()
+		delete(c.observed, id)
+		c.Unlock()
+		return
+	}
+	o := Observation{On: on, Time: time.Now()}
+	if err != nil {
+		o.Error = err.Error()
+		if prev := eq.Observed; prev != nil {
+			o.On = prev.On
+			o.Drift = prev.Drift
+		}
+		c.Lock()
+		c.observed[id] = o
+		c.Unlock()
+		return
+	}
+	o.Drift = on != eq.On
+	c.Lock()
+	c.observed[id] = o
+	c.Unlock()
+	if !o.Drift {
+		return
+	}
+	if eq.Observed == nil || !eq.Observed.Drift {
+		subject := "Equipment state drift: " + eq.Name
+		body := fmt.Sprintf("%s is expected to be %s but its outlet reports %s. Re-asserting desired state.", eq.Name, onOff(eq.On), onOff(on))
+		log.Println("WARNING: equipment subsystem:", body)
+		c.telemetry.LogError("equipment-drift-"+id, body)
+		c.telemetry.Alert(subject, body)
+	}
+	if err := c.outlets.Configure(eq.Outlet, eq.On); err != nil {
+		log.Println("ERROR: equipment subsystem: failed to re-assert state of", eq.Name, ". Error:", err)
+	}
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,183 @@
+package feeding
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func (c *Controller) LoadAPI(r *mux.Router) {
+
+	// swagger:route GET /api/feed_modes Feeding feedModeList
+	// List all feed modes.
+	// List all feed modes in reef-pi, with whether they are feeding.
+	// responses:
+	// 	200: body:[]feedMode
+	r.HandleFunc("/api/feed_modes", c.list).Methods("GET")
+
+	// swagger:operation PUT /api/feed_modes Feeding feedModeCreate
+	// Create a feed mode.
+	// Create a new feed mode.
+	// ---
+	// parameters:
+	//  - in: body
+	//    name: feedMode
+	//    description: The feed mode to create
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/feedMode'
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/feed_modes", c.create).Methods("PUT")
+
+	// swagger:operation GET /api/feed_modes/{id} Feeding feedModeGet
+	// Get a feed mode by id.
+	// Get an existing feed mode by id.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the feed mode
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    $ref: '#/definitions/feedMode'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/feed_modes/{id}", c.get).Methods("GET")
+
+	// swagger:operation POST /api/feed_modes/{id} Feeding feedModeUpdate
+	// Update a feed mode.
+	// Update an existing feed mode. An active feeding keeps its original settings.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the feed mode to update
+	//    required: true
+	//    schema:
+	//     type: integer
+	//  - in: body
+	//    name: feedMode
+	//    description: The feed mode to update
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/feedMode'
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/feed_modes/{id}", c.update).Methods("POST")
+
+	// swagger:operation DELETE /api/feed_modes/{id} Feeding feedModeDelete
+	// Delete a feed mode.
+	// Delete an existing feed mode, ending an active feeding.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the feed mode to delete
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/feed_modes/{id}", c.delete).Methods("DELETE")
+
+	// swagger:operation POST /api/feed_modes/{id}/feed Feeding feedModeFeed
+	// Start feeding.
+	// Pause the equipment, lights and wavemakers of a feed mode and dose food. Everything is
+	// restored once the feed mode duration passes. Feeding again restarts the duration.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the feed mode
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/feed_modes/{id}/feed", c.feed).Methods("POST")
+
+	// swagger:operation DELETE /api/feed_modes/{id}/feed Feeding feedModeEnd
+	// End feeding.
+	// End feeding early, restoring the equipment, lights and wavemakers of a feed mode.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the feed mode
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/feed_modes/{id}/feed", c.end).Methods("DELETE")
+}
+
+func (c *Controller) get(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return c.Get(id)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (c *Controller) list(w http.ResponseWriter, r *http.Request) {
+	fn := func() (interface{}, error) {
+		return c.List()
+	}
+	utils.JSONListResponse(fn, w, r)
+}
+
+func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
+	var f FeedMode
+	fn := func() error {
+		return c.Create(f)
+	}
+	utils.JSONCreateResponse(&f, fn, w, r)
+}
+
+func (c *Controller) update(w http.ResponseWriter, r *http.Request) {
+	var f FeedMode
+	fn := func(id string) error {
+		return c.Update(id, f)
+	}
+	utils.JSONUpdateResponse(&f, fn, w, r)
+}
+
+func (c *Controller) delete(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.Delete(id)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
+
+func (c *Controller) feed(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.On(id, true)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
+
+func (c *Controller) end(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.On(id, false)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
@@ -0,0 +1,248 @@
+package feeding
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
+	"github.com/reef-pi/reef-pi/controller/modules/doser"
+	"github.com/reef-pi/reef-pi/controller/modules/equipment"
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+// testController serves real equipment and doser subsystems, other subsystems are mocked
+type testController struct {
+	controller.Controller
+	eqs    *equipment.Controller
+	dosers *doser.Controller
+}
+
+func (t *testController) Subsystem(s string) (controller.Subsystem, error) {
+	switch s {
+	case storage.EquipmentBucket:
+		return t.eqs, nil
+	case storage.DoserBucket:
+		return t.dosers, nil
+	}
+	return t.Controller.Subsystem(s)
+}
+
+func TestFeedModeAPI(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	drvrs := con.DM().Drivers()
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := drvrs.DigitalOutputDriver("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := d.(*virtual.Driver)
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Create(connectors.Outlet{Name: "return", Pin: 0, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	inlets := con.DM().Inlets()
+	if err := inlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inlets.Create(connectors.Inlet{Name: "feed button", Pin: 1, Driver: "1", Debounce: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err := inlets.Create(connectors.Inlet{Name: "float switch", Pin: 2, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	defer inlets.Stop()
+	eqs := equipment.New(con)
+	if err := eqs.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := eqs.Create(equipment.Equipment{Name: "Return pump", Outlet: "1", On: true}); err != nil {
+		t.Fatal(err)
+	}
+	eqs.Start()
+	defer eqs.Stop()
+	jacks := con.DM().Jacks()
+	if err := jacks.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.Create(connectors.Jack{Name: "doser", Pins: []int{0}, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	dosers, err := doser.New(false, con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dosers.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	schedule := doser.Schedule{Day: "*", Hour: "*", Minute: "*", Second: "0", Month: "*", Week: "*"}
+	for _, p := range []doser.Pump{
+		{Name: "Food", Jack: "1", Pin: 0, Regiment: doser.DosingRegiment{Speed: 50, Schedule: schedule}},
+		{Name: "Unplugged", Jack: "9", Pin: 0, Regiment: doser.DosingRegiment{Speed: 50, Schedule: schedule}},
+	} {
+		if err := dosers.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := New(&testController{Controller: con, eqs: eqs, dosers: dosers})
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+	c.Start()
+	defer c.Stop()
+
+	eventually := func(msg string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal(msg)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	active := func() bool {
+		f, err := c.Get("1")
+		return err == nil && f.State.Active
+	}
+	returnOn := func() bool {
+		on, _ := outlets.Observe("1")
+		return on
+	}
+
+	for _, f := range []FeedMode{
+		{Name: "bad", Equipment: []string{"1"}},
+		{Name: "bad", Equipment: []string{"9"}, Duration: 5},
+		{Name: "bad", Lights: []string{"1"}, Duration: 5},
+		{Name: "bad", Duration: 5, Inlet: "9"},
+		{Name: "bad", Duration: 5, Inlet: "2"},
+		{Name: "bad", Duration: 5, Dose: -1},
+		{Name: "bad", Duration: 5, Doser: "9", Dose: 1},
+	} {
+		body := new(bytes.Buffer)
+		json.NewEncoder(body).Encode(f)
+		if err := tr.Do("PUT", "/api/feed_modes", body, nil); err == nil {
+			t.Error("expected invalid feed mode to be rejected", f)
+		}
+	}
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(FeedMode{
+		Name:      "Evening",
+		Equipment: []string{"1"},
+		Doser:     "1",
+		Dose:      0.01,
+		Duration:  5,
+		Inlet:     "1",
+	})
+	if err := tr.Do("PUT", "/api/feed_modes", body, nil); err != nil {
+		t.Fatal("Failed to create feed mode using api", err)
+	}
+	for _, dep := range []string{storage.EquipmentBucket, storage.DoserBucket, storage.InletBucket} {
+		if deps, err := c.InUse(dep, "1"); err != nil || len(deps) != 1 || deps[0] != "Evening" {
+			t.Error("expected feed mode to be reported in use by", dep, deps, err)
+		}
+	}
+
+	if err := tr.Do("POST", "/api/feed_modes/1/feed", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to start feeding using api", err)
+	}
+	var f FeedMode
+	if err := tr.Do("GET", "/api/feed_modes/1", new(bytes.Buffer), &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.State == nil || !f.State.Active || time.Until(f.State.Until) < 4*time.Minute {
+		t.Error("expected feed mode to be active for its duration", f.State)
+	}
+	if f.State.Error != "" {
+		t.Error("expected food to be dosed", f.State.Error)
+	}
+	if returnOn() {
+		t.Error("expected return pump to be paused while feeding")
+	}
+	if eq, _ := eqs.Get("1"); eq.Override == nil || !eq.Override.Paused {
+		t.Error("expected return pump to be overridden while feeding")
+	}
+	if err := tr.Do("DELETE", "/api/feed_modes/1/feed", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to end feeding using api", err)
+	}
+	if active() || !returnOn() {
+		t.Error("expected return pump to be restored when feeding ends")
+	}
+
+	press := connectors.Edge{Inlet: "1", Rising: true}
+	c.onEdge(press)
+	if !active() {
+		t.Error("expected feed button to start feeding")
+	}
+	c.onEdge(connectors.Edge{Inlet: "1"})
+	if !active() {
+		t.Error("expected releasing the feed button to keep feeding")
+	}
+	c.onEdge(connectors.Edge{Inlet: "2", Rising: true})
+	if !active() {
+		t.Error("expected edges of other inlets to be ignored")
+	}
+	c.onEdge(press)
+	if active() || !returnOn() {
+		t.Error("expected feed button to end feeding early and restore the return pump")
+	}
+	// let the sampler read the released button before pressing it
+	time.Sleep(100 * time.Millisecond)
+	if err := sim.Set(virtual.Input{Type: "digital-input", Pin: 1, Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	eventually("expected debounced feed button press to start feeding", active)
+	c.End(f)
+
+	if err := c.On("1", true); err != nil {
+		t.Fatal(err)
+	}
+	c.expire("1")
+	if active() || !returnOn() {
+		t.Error("expected return pump to be restored once feeding duration passes")
+	}
+
+	if err := c.On("1", true); err != nil {
+		t.Fatal(err)
+	}
+	f, err = c.Get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Doser = "2"
+	if err := c.Update("1", f); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.On("1", true); err != nil {
+		t.Fatal(err)
+	}
+	eventually("expected failed food dose to be reported", func() bool {
+		f, err := c.Get("1")
+		return err == nil && f.State.Error != ""
+	})
+
+	if err := tr.Do("DELETE", "/api/feed_modes/1", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to delete feed mode using api", err)
+	}
+	if !returnOn() {
+		t.Error("expected deleting an active feed mode to restore the return pump")
+	}
+}
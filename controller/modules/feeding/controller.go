@@ -0,0 +1,257 @@
+package feeding
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+const Bucket = storage.FeedModeBucket
+
+type Controller struct {
+	sync.Mutex
+	c        controller.Controller
+	sessions map[string]*session
+	// listening is set while started, feed buttons are ignored otherwise
+	listening bool
+	subscribe sync.Once
+}
+
+// session is an active feeding
+type session struct {
+	mode  FeedMode
+	until time.Time
+	timer *time.Timer
+	// err is a failed food dose
+	err string
+}
+
+func New(c controller.Controller) *Controller {
+	return &Controller{
+		c:        c,
+		sessions: make(map[string]*session),
+	}
+}
+
+func (c *Controller) Setup() error {
+	return c.c.Store().CreateBucket(Bucket)
+}
+
+func (c *Controller) Start() {
+	c.subscribe.Do(func() { c.c.DM().Inlets().OnEdge(c.onEdge) })
+	c.Lock()
+	c.listening = true
+	c.Unlock()
+}
+
+// Stop ends all feeding sessions, restoring paused equipment, lights and wavemakers
+func (c *Controller) Stop() {
+	c.Lock()
+	c.listening = false
+	var active []FeedMode
+	for _, s := range c.sessions {
+		active = append(active, s.mode)
+	}
+	c.Unlock()
+	for _, f := range active {
+		c.End(f)
+	}
+	log.Println("Stopped feeding subsystem")
+}
+
+// On starts feeding, or ends it early. Used by timers and macros
+func (c *Controller) On(id string, on bool) error {
+	f, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if on {
+		c.Feed(f)
+		return nil
+	}
+	c.End(f)
+	return nil
+}
+
+func (c *Controller) InUse(depType, id string) ([]string, error) {
+	var deps []string
+	fs, err := c.List()
+	if err != nil {
+		return deps, err
+	}
+	for _, f := range fs {
+		switch depType {
+		case storage.EquipmentBucket, storage.LightingBucket, storage.WavemakerBucket:
+			for _, t := range f.targets()[depType] {
+				if t == id {
+					deps = append(deps, f.Name)
+				}
+			}
+		case storage.DoserBucket:
+			if f.Doser == id {
+				deps = append(deps, f.Name)
+			}
+		case storage.InletBucket:
+			if f.Inlet == id {
+				deps = append(deps, f.Name)
+			}
+		default:
+			return deps, fmt.Errorf("unknown dep type:%s", depType)
+		}
+	}
+	return deps, nil
+}
+
+func (c *Controller) GetEntity(id string) (controller.Entity, error) {
+	return c.Get(id)
+}
+
+// Feed pauses the equipment, lights and wavemakers of a feed mode, doses food and schedules
+// their restoration. Feeding again while active restarts the duration
+func (c *Controller) Feed(f FeedMode) {
+	d := f.duration()
+	log.Println("feeding subsystem:", f.Name, "started for", d)
+	for sub, ids := range f.targets() {
+		if len(ids) == 0 {
+			continue
+		}
+		p, err := c.pauser(sub)
+		if err != nil {
+			log.Println("ERROR: feeding subsystem:", err)
+			continue
+		}
+		for _, id := range ids {
+			if err := p.PauseEntity(id, d); err != nil {
+				log.Println("ERROR: feeding subsystem: failed to pause", sub, id, ". Error:", err)
+			}
+		}
+	}
+	c.Lock()
+	if s, ok := c.sessions[f.ID]; ok {
+		s.timer.Stop()
+	}
+	id := f.ID
+	c.sessions[id] = &session{
+		mode:  f,
+		until: time.Now().Add(d),
+		timer: time.AfterFunc(d, func() { c.expire(id) }),
+	}
+	c.Unlock()
+	if f.Doser != "" && f.Dose > 0 {
+		c.dose(f)
+	}
+	c.c.Telemetry().ControlAction(Bucket, f.ID, "feed")
+}
+
+// End restores the equipment, lights and wavemakers of an active feeding session
+func (c *Controller) End(f FeedMode) {
+	c.Lock()
+	s, ok := c.sessions[f.ID]
+	if ok {
+		s.timer.Stop()
+		delete(c.sessions, f.ID)
+	}
+	c.Unlock()
+	if !ok {
+		return
+	}
+	for sub, ids := range s.mode.targets() {
+		if len(ids) == 0 {
+			continue
+		}
+		p, err := c.pauser(sub)
+		if err != nil {
+			log.Println("ERROR: feeding subsystem:", err)
+			continue
+		}
+		for _, id := range ids {
+			if err := p.ResumeEntity(id); err != nil {
+				log.Println("ERROR: feeding subsystem: failed to resume", sub, id, ". Error:", err)
+			}
+		}
+	}
+	log.Println("feeding subsystem:", f.Name, "ended")
+}
+
+func (c *Controller) expire(id string) {
+	c.Lock()
+	s, ok := c.sessions[id]
+	c.Unlock()
+	if ok {
+		c.End(s.mode)
+	}
+}
+
+func (c *Controller) pauser(sub string) (controller.Pauser, error) {
+	s, err := c.c.Subsystem(sub)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := s.(controller.Pauser)
+	if !ok {
+		return nil, fmt.Errorf("%s subsystem can not be paused", sub)
+	}
+	return p, nil
+}
+
+// dose runs the doser pump of a feed mode for its dose duration, a failure is reported in the
+// status of the feeding session
+func (c *Controller) dose(f FeedMode) {
+	s, err := c.c.Subsystem(storage.DoserBucket)
+	if err != nil {
+		c.doseFailed(f.ID, err)
+		return
+	}
+	d, ok := s.(controller.Doser)
+	if !ok {
+		c.doseFailed(f.ID, fmt.Errorf("doser subsystem can not dose on demand"))
+		return
+	}
+	go func() {
+		if err := d.Dose(f.Doser, f.Dose); err != nil {
+			c.doseFailed(f.ID, err)
+		}
+	}()
+}
+
+func (c *Controller) doseFailed(id string, err error) {
+	log.Println("ERROR: feeding subsystem: failed to dose food. Error:", err)
+	c.Lock()
+	defer c.Unlock()
+	if s, ok := c.sessions[id]; ok {
+		s.err = err.Error()
+	}
+}
+
+// onEdge starts feeding when a feed button is pressed, or ends it early if it is active
+func (c *Controller) onEdge(e connectors.Edge) {
+	if !e.Rising {
+		return
+	}
+	c.Lock()
+	listening := c.listening
+	c.Unlock()
+	if !listening {
+		return
+	}
+	fs, err := c.List()
+	if err != nil {
+		log.Println("ERROR: feeding subsystem: failed to list feed modes. Error:", err)
+		return
+	}
+	for _, f := range fs {
+		if f.Inlet != e.Inlet {
+			continue
+		}
+		if f.State.Active {
+			c.End(f)
+		} else {
+			c.Feed(f)
+		}
+	}
+}
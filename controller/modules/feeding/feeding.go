@@ -0,0 +1,182 @@
+package feeding
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+// FeedMode pauses equipment, lights and wavemakers while feeding, optionally doses food, and
+// restores everything after a number of minutes
+//
+// swagger:model feedMode
+type FeedMode struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Equipment  []string `json:"equipment"`
+	Lights     []string `json:"lights"`
+	Wavemakers []string `json:"wavemakers"`
+	// Doser optionally doses food when feeding starts, running the pump for Dose seconds
+	Doser string  `json:"doser"`
+	Dose  float64 `json:"dose"`
+	// Duration is the number of minutes to feed for
+	Duration int `json:"duration"`
+	// Inlet is an optional button that starts feeding, or ends it early when pressed while feeding.
+	// It needs a debounce, presses are detected from the debounced edges of the inlet
+	Inlet string      `json:"inlet"`
+	State *FeedStatus `json:"status,omitempty"`
+}
+
+// swagger:model feedStatus
+type FeedStatus struct {
+	Active bool      `json:"active"`
+	Until  time.Time `json:"until"`
+	// Error reports a failed food dose of the active feeding session
+	Error string `json:"error,omitempty"`
+}
+
+func (f FeedMode) EName() string { return f.Name }
+
+func (f FeedMode) Status() (interface{}, error) {
+	if f.State == nil {
+		return FeedStatus{}, nil
+	}
+	return *f.State, nil
+}
+
+func (f FeedMode) duration() time.Duration {
+	return time.Duration(f.Duration) * time.Minute
+}
+
+// targets returns the paused entities of a feed mode by subsystem
+func (f FeedMode) targets() map[string][]string {
+	return map[string][]string{
+		storage.EquipmentBucket: f.Equipment,
+		storage.LightingBucket:  f.Lights,
+		storage.WavemakerBucket: f.Wavemakers,
+	}
+}
+
+func (c *Controller) validate(f FeedMode) error {
+	if f.Name == "" {
+		return fmt.Errorf("feed mode name can not be empty")
+	}
+	if f.Duration <= 0 {
+		return fmt.Errorf("feed mode duration must be positive")
+	}
+	if f.Dose < 0 {
+		return fmt.Errorf("dose duration can not be negative")
+	}
+	for sub, ids := range f.targets() {
+		if len(ids) == 0 {
+			continue
+		}
+		s, err := c.c.Subsystem(sub)
+		if err != nil {
+			return err
+		}
+		if _, ok := s.(controller.Pauser); !ok {
+			return fmt.Errorf("%s subsystem can not be paused", sub)
+		}
+		for _, id := range ids {
+			if _, err := s.GetEntity(id); err != nil {
+				return fmt.Errorf("%s '%s' does not exist. Error: %w", sub, id, err)
+			}
+		}
+	}
+	if f.Doser != "" {
+		s, err := c.c.Subsystem(storage.DoserBucket)
+		if err != nil {
+			return err
+		}
+		if _, ok := s.(controller.Doser); !ok {
+			return fmt.Errorf("doser subsystem can not dose on demand")
+		}
+		if _, err := s.GetEntity(f.Doser); err != nil {
+			return fmt.Errorf("doser '%s' does not exist. Error: %w", f.Doser, err)
+		}
+	}
+	if f.Inlet != "" {
+		i, err := c.c.DM().Inlets().Get(f.Inlet)
+		if err != nil {
+			return fmt.Errorf("inlet '%s' does not exist. Error: %w", f.Inlet, err)
+		}
+		if i.Debounce <= 0 || i.Mode == connectors.PulseMode {
+			return fmt.Errorf("feed button inlet '%s' needs a debounce and level mode to report presses", i.Name)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) withStatus(f FeedMode) FeedMode {
+	c.Lock()
+	defer c.Unlock()
+	f.State = &FeedStatus{}
+	if s, ok := c.sessions[f.ID]; ok {
+		f.State = &FeedStatus{Active: true, Until: s.until, Error: s.err}
+	}
+	return f
+}
+
+func (c *Controller) Get(id string) (FeedMode, error) {
+	var f FeedMode
+	if err := c.c.Store().Get(Bucket, id, &f); err != nil {
+		return f, err
+	}
+	return c.withStatus(f), nil
+}
+
+func (c *Controller) List() ([]FeedMode, error) {
+	fs := []FeedMode{}
+	fn := func(_ string, v []byte) error {
+		var f FeedMode
+		if err := json.Unmarshal(v, &f); err != nil {
+			return err
+		}
+		fs = append(fs, c.withStatus(f))
+		return nil
+	}
+	return fs, c.c.Store().List(Bucket, fn)
+}
+
+func (c *Controller) Create(f FeedMode) error {
+	if err := c.validate(f); err != nil {
+		return err
+	}
+	f.State = nil
+	fn := func(id string) interface{} {
+		f.ID = id
+		return &f
+	}
+	return c.c.Store().Create(Bucket, fn)
+}
+
+// Update changes a feed mode, an active feeding session keeps its original settings
+func (c *Controller) Update(id string, f FeedMode) error {
+	if err := c.validate(f); err != nil {
+		return err
+	}
+	if _, err := c.Get(id); err != nil {
+		return err
+	}
+	f.ID = id
+	f.State = nil
+	return c.c.Store().Update(Bucket, id, f)
+}
+
+// Delete removes a feed mode, ending an active feeding session
+func (c *Controller) Delete(id string) error {
+	f, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := c.c.Store().Delete(Bucket, id); err != nil {
+		return err
+	}
+	c.End(f)
+	return nil
+}
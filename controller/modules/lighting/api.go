@@ -110,6 +110,46 @@ func (c *Controller) LoadAPI(r *mux.Router) {
 	//  404:
 	//   description: Not Found
 	r.HandleFunc("/api/lights/{id}/usage", c.getUsage).Methods("GET")
+
+	// swagger:operation POST /api/lights/{id}/override Lights lightOverride
+	// Override a light.
+	// Hold channels of a light at forced values for a duration, or until the override is cleared. Profiles, timers and macros can not change the light meanwhile.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the light to override
+	//    required: true
+	//    schema:
+	//     type: integer
+	//  - in: body
+	//    name: override
+	//    description: The forced channel values and duration
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/lightOverride'
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/lights/{id}/override", c.override).Methods("POST")
+
+	// swagger:operation DELETE /api/lights/{id}/override Lights lightOverrideClear
+	// Clear a light override.
+	// Clear the override of a light. Enabled lights follow their profiles again.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the light
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/lights/{id}/override", c.deleteOverride).Methods("DELETE")
 }
 
 func (c *Controller) GetLight(w http.ResponseWriter, r *http.Request) {
@@ -150,3 +190,19 @@ func (c *Controller) getUsage(w http.ResponseWriter, req *http.Request) {
 	fn := func(id string) (interface{}, error) { return c.statsMgr.Get(id) }
 	utils.JSONGetResponse(fn, w, req)
 }
+
+func (c *Controller) override(w http.ResponseWriter, r *http.Request) {
+	var o Override
+	fn := func(id string) error {
+		o.Paused = false
+		return c.SetOverride(id, o)
+	}
+	utils.JSONUpdateResponse(&o, fn, w, r)
+}
+
+func (c *Controller) deleteOverride(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.ClearOverride(id)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
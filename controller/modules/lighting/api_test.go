@@ -10,6 +10,7 @@ import (
 	"github.com/reef-pi/reef-pi/controller"
 	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
 	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/storage"
 	"github.com/reef-pi/reef-pi/controller/utils"
 )
 
@@ -115,6 +116,28 @@ func TestLightingAPI(t *testing.T) {
 	if err := c.Create(l); err != nil {
 		t.Error("Light with empty channels should be allowed to create")
 	}
+	if err := c.Create(Light{Name: "bad", Jack: "1"}); err == nil {
+		t.Error("expected jack pins driven by another light to be rejected")
+	}
+	if err := c.jacks.Create(connectors.Jack{Name: "J2", Pins: []int{4}, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := con.Store().CreateBucket(storage.DoserBucket); err != nil {
+		t.Fatal(err)
+	}
+	doser := func(id string) interface{} {
+		return map[string]interface{}{"id": id, "name": "Alk", "jack": "2", "pin": 4}
+	}
+	if err := con.Store().Create(storage.DoserBucket, doser); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Light{Name: "bad", Jack: "2"}); err == nil {
+		t.Error("expected jack pin driven by a doser to be rejected")
+	}
+	owner := connectors.PinOwner{Type: connectors.DoserPin, Name: "Ca"}
+	if err := con.DM().Pins().CheckJack(owner, "1", 3); err == nil {
+		t.Error("expected jack pin driven by a light to be rejected for other entities")
+	}
 	ch.Min = 2
 	ch.Max = 8
 	channels[1] = ch
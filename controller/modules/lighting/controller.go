@@ -29,10 +29,13 @@ var DefaultConfig = Config{
 type Controller struct {
 	sync.Mutex
 	jacks    *connectors.Jacks
+	pins     *connectors.PinRegistry
 	config   Config
 	c        controller.Controller
 	quitters map[string]chan struct{}
 	statsMgr telemetry.StatsManager
+	// resumers end timed overrides
+	resumers map[string]*time.Timer
 }
 
 func New(conf Config, c controller.Controller) (*Controller, error) {
@@ -40,9 +43,11 @@ func New(conf Config, c controller.Controller) (*Controller, error) {
 		Mutex:    sync.Mutex{},
 		c:        c,
 		jacks:    c.DM().Jacks(),
+		pins:     c.DM().Pins(),
 		config:   conf,
 		quitters: make(map[string]chan struct{}),
 		statsMgr: c.Telemetry().NewStatsManager(UsageBucket),
+		resumers: make(map[string]*time.Timer),
 	}, nil
 }
 
@@ -55,6 +60,14 @@ func (c *Controller) Start() {
 		return
 	}
 	for _, l := range lights {
+		if l.Override != nil && !l.Override.Until.IsZero() && time.Now().After(l.Override.Until) {
+			cleared, err := c.clearOverride(l)
+			if err != nil {
+				log.Println("ERROR: lighting subsystem: Failed to clear expired override of", l.Name, ". Error:", err)
+			}
+			l = cleared
+		}
+		c.scheduleResume(l)
 		if !l.Enable {
 			continue
 		}
@@ -79,6 +92,7 @@ func (c *Controller) Stop() {
 		close(quit)
 		delete(c.quitters, id)
 	}
+	c.stopResumers()
 	log.Println("Stopped lighting subsystem")
 }
 
@@ -91,11 +105,16 @@ func (c *Controller) Setup() error {
 	return c.c.Store().CreateBucket(UsageBucket)
 }
 
+// On is used by timers and macros. It has no effect while the light is overridden
 func (c *Controller) On(id string, on bool) error {
 	l, err := c.Get(id)
 	if err != nil {
 		return err
 	}
+	if l.Override != nil {
+		log.Println("INFO: lighting subsystem:", l.Name, "is overridden, ignoring request to set enable:", on)
+		return nil
+	}
 	l.Enable = on
 	return c.Update(id, l)
 }
@@ -119,5 +138,5 @@ func (c *Controller) InUse(depType, id string) ([]string, error) {
 	}
 }
 func (c *Controller) GetEntity(id string) (controller.Entity, error) {
-	return nil, fmt.Errorf("journal subsystem does not support 'GetEntity' interface")
+	return c.Get(id)
 }
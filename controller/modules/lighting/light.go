@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
 	"github.com/reef-pi/reef-pi/controller/telemetry"
 )
 
@@ -29,6 +30,23 @@ type Light struct {
 	Channels map[int]*Channel `json:"channels"`
 	Jack     string           `json:"jack"`
 	Enable   bool             `json:"enable"`
+	// Override is the active override, it can only be changed through the override api
+	Override *Override `json:"override,omitempty"`
+}
+
+func (l Light) EName() string { return l.Name }
+
+// Status reports whether the light is enabled and the active override, if any
+func (l Light) Status() (interface{}, error) {
+	return LightStatus{Enable: l.Enable, Override: l.Override}, nil
+}
+
+// LightStatus is the state of a light, including overrides
+//
+// swagger:model lightStatus
+type LightStatus struct {
+	Enable   bool      `json:"enable"`
+	Override *Override `json:"override,omitempty"`
 }
 
 func (l *Light) LoadChannels() error {
@@ -68,6 +86,10 @@ func (c *Controller) validate(l *Light) error {
 	if err != nil {
 		return fmt.Errorf("Non existent jack: '%s'. Error: %s", l.Jack, err.Error())
 	}
+	owner := connectors.PinOwner{Type: connectors.LightPin, ID: l.ID, Name: l.Name}
+	if err := c.pins.CheckJack(owner, l.Jack, j.Pins...); err != nil {
+		return err
+	}
 	if l.Channels == nil {
 		l.Channels = make(map[int]*Channel)
 	}
@@ -98,9 +120,11 @@ func (c *Controller) validate(l *Light) error {
 }
 
 func (c *Controller) Create(l Light) error {
+	l.ID = ""
 	if err := c.validate(&l); err != nil {
 		return err
 	}
+	l.Override = nil
 	fn := func(id string) interface{} {
 		l.ID = id
 		return &l
@@ -111,7 +135,9 @@ func (c *Controller) Create(l Light) error {
 	c.statsMgr.Initialize(l.ID)
 	if l.Enable {
 		quit := make(chan struct{})
+		c.Lock()
 		c.quitters[l.ID] = quit
+		c.Unlock()
 		go c.Run(l, quit)
 	}
 	return nil
@@ -124,19 +150,14 @@ func (c *Controller) Update(id string, l Light) error {
 	if err := c.validate(&l); err != nil {
 		return err
 	}
+	l.Override = nil
+	if old, err := c.Get(id); err == nil {
+		l.Override = old.Override
+	}
 	if err := c.c.Store().Update(Bucket, id, l); err != nil {
 		return err
 	}
-	quit, ok := c.quitters[l.ID]
-	if ok {
-		close(quit)
-		delete(c.quitters, l.ID)
-	}
-	if l.Enable {
-		quit := make(chan struct{})
-		c.quitters[l.ID] = quit
-		go c.Run(l, quit)
-	}
+	c.restart(l)
 	return nil
 }
 
@@ -148,8 +169,11 @@ func (c *Controller) Delete(id string) error {
 	if err := c.c.Store().Delete(Bucket, id); err != nil {
 		return err
 	}
-	quit, ok := c.quitters[id]
-	if ok {
+	c.c.Telemetry().DeleteEntityMetrics(Bucket, id)
+	c.Lock()
+	defer c.Unlock()
+	c.cancelResume(id)
+	if quit, ok := c.quitters[id]; ok {
 		close(quit)
 		delete(c.quitters, id)
 	}
@@ -159,15 +183,25 @@ func (c *Controller) Delete(id string) error {
 func (c *Controller) syncLight(light Light) {
 	vals := make(map[string]float64)
 	for _, ch := range light.Channels {
-		v, err := ch.ValueAt(time.Now())
-		if err != nil {
-			log.Println("ERROR: lighting subsystem. Profile value computation error. Light:", light.Name, "channel:", ch.Name, "Error:", err)
+		v, overridden := light.Override.value(ch.Pin)
+		if !overridden {
+			var err error
+			v, err = ch.ValueAt(time.Now())
+			if err != nil {
+				log.Println("ERROR: lighting subsystem. Profile value computation error. Light:", light.Name, "channel:", ch.Name, "Error:", err)
+			}
 		}
 		log.Println("lighting subsystem: Setting Light: ", light.Name, "Channel:", ch.Name, "Value:", v)
 		c.UpdateChannel(light.Jack, *ch, v)
 		ch.Value = v
 		vals[ch.Name] = v
-		c.c.Telemetry().EmitMetric(light.Name, ch.Name, v)
+		c.c.Telemetry().EmitEntityMetric(telemetry.EntityMetric{
+			Module: Bucket,
+			ID:     light.ID,
+			Name:   light.Name,
+			Metric: ch.Name,
+			Feed:   light.Name + "-" + ch.Name,
+		}, v)
 	}
 	c.statsMgr.Update(light.ID, Usage{
 		Time:     telemetry.TeleTime(time.Now()),
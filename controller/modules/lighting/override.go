@@ -0,0 +1,209 @@
+package lighting
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Override holds channels of a light at forced values. While it is active profiles, timers
+// and macros can not change the light
+//
+// swagger:model lightOverride
+type Override struct {
+	// Channels maps channel pins to forced values in percent. Channels not listed follow their profile
+	Channels map[int]float64 `json:"channels"`
+	// Duration is the number of seconds to hold the values, 0 holds them until the override is cleared
+	Duration int `json:"duration"`
+	// Until is when the override expires, zero if it is held until cleared
+	Until time.Time `json:"until"`
+	// Resume holds the channel values before the override, restored on lights that are not enabled
+	Resume map[int]float64 `json:"resume"`
+	// Paused is set for overrides that pause the light, e.g. while feeding
+	Paused bool `json:"paused,omitempty"`
+}
+
+func (o Override) validate(l Light) error {
+	if o.Duration < 0 {
+		return fmt.Errorf("override duration can not be negative")
+	}
+	if len(o.Channels) == 0 {
+		return fmt.Errorf("override requires at least one channel")
+	}
+	for pin, v := range o.Channels {
+		if _, ok := l.Channels[pin]; !ok {
+			return fmt.Errorf("light '%s' does not have a channel on pin %d", l.Name, pin)
+		}
+		if v < 0 || v > 100 {
+			return fmt.Errorf("invalid value %f for channel on pin %d, must be between 0 and 100", v, pin)
+		}
+	}
+	return nil
+}
+
+func (o *Override) value(pin int) (float64, bool) {
+	if o == nil {
+		return 0, false
+	}
+	v, ok := o.Channels[pin]
+	return v, ok
+}
+
+// SetOverride forces channel values of a light, replacing any active override
+func (c *Controller) SetOverride(id string, o Override) error {
+	c.Lock()
+	defer c.Unlock()
+	l, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := o.validate(l); err != nil {
+		return err
+	}
+	if l.Override != nil {
+		o.Resume = l.Override.Resume
+	} else {
+		current, err := c.jacks.Values(l.Jack)
+		if err != nil {
+			return err
+		}
+		o.Resume = make(map[int]float64)
+		for pin := range l.Channels {
+			o.Resume[pin] = current[pin]
+		}
+	}
+	o.Until = time.Time{}
+	if o.Duration > 0 {
+		o.Until = time.Now().Add(time.Duration(o.Duration) * time.Second)
+	}
+	l.Override = &o
+	if err := c.c.Store().Update(Bucket, id, l); err != nil {
+		return err
+	}
+	log.Println("INFO: lighting subsystem:", l.Name, "overridden. Channels:", o.Channels, "Duration:", o.Duration)
+	for pin, v := range o.Channels {
+		c.UpdateChannel(l.Jack, *l.Channels[pin], v)
+	}
+	c.restart(l)
+	c.scheduleResume(l)
+	return nil
+}
+
+// ClearOverride ends the override of a light. Enabled lights follow their profiles again,
+// others get their channel values from before the override back
+func (c *Controller) ClearOverride(id string) error {
+	c.Lock()
+	defer c.Unlock()
+	l, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if l.Override == nil {
+		return nil
+	}
+	l, err = c.clearOverride(l)
+	if err != nil {
+		return err
+	}
+	c.restart(l)
+	return nil
+}
+
+// clearOverride removes the override of a light, restoring channel values of lights that are
+// not enabled. The caller must hold the lock
+func (c *Controller) clearOverride(l Light) (Light, error) {
+	c.cancelResume(l.ID)
+	if l.Override == nil {
+		return l, nil
+	}
+	o := l.Override
+	l.Override = nil
+	if err := c.c.Store().Update(Bucket, l.ID, l); err != nil {
+		return l, err
+	}
+	log.Println("INFO: lighting subsystem:", l.Name, "override cleared")
+	if !l.Enable {
+		for pin := range o.Channels {
+			if ch, ok := l.Channels[pin]; ok {
+				c.UpdateChannel(l.Jack, *ch, o.Resume[pin])
+			}
+		}
+	}
+	return l, nil
+}
+
+// PauseEntity turns all channels of a light off for d, e.g. while feeding. Lights under a
+// manual override are left alone
+func (c *Controller) PauseEntity(id string, d time.Duration) error {
+	l, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if l.Override != nil && !l.Override.Paused {
+		log.Println("INFO: lighting subsystem:", l.Name, "is overridden, not pausing it")
+		return nil
+	}
+	o := Override{
+		Channels: make(map[int]float64),
+		Duration: int((d + time.Second - 1) / time.Second),
+		Paused:   true,
+	}
+	for pin := range l.Channels {
+		o.Channels[pin] = 0
+	}
+	return c.SetOverride(id, o)
+}
+
+// ResumeEntity ends a pause early, manual overrides are kept
+func (c *Controller) ResumeEntity(id string) error {
+	l, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if l.Override == nil || !l.Override.Paused {
+		return nil
+	}
+	return c.ClearOverride(id)
+}
+
+// restart stops the control loop of a light and starts it again if the light is enabled.
+// The caller must hold the lock
+func (c *Controller) restart(l Light) {
+	if quit, ok := c.quitters[l.ID]; ok {
+		close(quit)
+		delete(c.quitters, l.ID)
+	}
+	if l.Enable {
+		quit := make(chan struct{})
+		c.quitters[l.ID] = quit
+		go c.Run(l, quit)
+	}
+}
+
+// scheduleResume expects the caller to hold the lock
+func (c *Controller) scheduleResume(l Light) {
+	c.cancelResume(l.ID)
+	if l.Override == nil || l.Override.Until.IsZero() {
+		return
+	}
+	id := l.ID
+	c.resumers[id] = time.AfterFunc(time.Until(l.Override.Until), func() {
+		if err := c.ClearOverride(id); err != nil {
+			log.Println("ERROR: lighting subsystem: failed to resume light", id, "after override. Error:", err)
+		}
+	})
+}
+
+// cancelResume expects the caller to hold the lock
+func (c *Controller) cancelResume(id string) {
+	if t, ok := c.resumers[id]; ok {
+		t.Stop()
+		delete(c.resumers, id)
+	}
+}
+
+func (c *Controller) stopResumers() {
+	for id := range c.resumers {
+		c.cancelResume(id)
+	}
+}
@@ -0,0 +1,184 @@
+package lighting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestLightOverride(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	jacks := con.DM().Jacks()
+	if err := jacks.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.Create(connectors.Jack{Name: "J1", Pins: []int{0, 1}, Driver: "rpi"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(DefaultConfig, con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+	if err := c.Create(Light{Name: "Refugium", Jack: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	l, err := c.Get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.UpdateChannel("1", *l.Channels[0], 20)
+	c.UpdateChannel("1", *l.Channels[1], 30)
+
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(Override{Channels: map[int]float64{5: 50}})
+	if err := tr.Do("POST", "/api/lights/1/override", body, nil); err == nil {
+		t.Error("expected override of unknown channel to be rejected")
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(Override{Channels: map[int]float64{0: 150}})
+	if err := tr.Do("POST", "/api/lights/1/override", body, nil); err == nil {
+		t.Error("expected override value above 100 to be rejected")
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(Override{Channels: map[int]float64{0: 80}})
+	if err := tr.Do("POST", "/api/lights/1/override", body, nil); err != nil {
+		t.Fatal("Failed to override light using api", err)
+	}
+	if v, _ := jacks.Values("1"); v[0] != 80 || v[1] != 30 {
+		t.Error("expected overridden channel to be forced", v)
+	}
+	if err := c.On("1", true); err != nil {
+		t.Error(err)
+	}
+	if l, _ := c.Get("1"); l.Enable {
+		t.Error("expected automation to be ignored while overridden")
+	}
+	e, err := c.GetEntity("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := e.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := s.(LightStatus); status.Override == nil || status.Override.Resume[0] != 20 {
+		t.Error("expected status to report the override", status)
+	}
+
+	if err := tr.Do("DELETE", "/api/lights/1/override", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to clear override using api", err)
+	}
+	if v, _ := jacks.Values("1"); v[0] != 20 {
+		t.Error("expected channel to get its value from before the override back", v)
+	}
+
+	if err := c.SetOverride("1", Override{Channels: map[int]float64{1: 0}, Duration: 1}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		l, _ := c.Get("1")
+		if v, _ := jacks.Values("1"); l.Override == nil && v[1] == 30 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected light to resume after the override expired")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := c.PauseEntity("1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := jacks.Values("1"); v[0] != 0 || v[1] != 0 {
+		t.Error("expected paused light to be dark", v)
+	}
+	if err := c.ResumeEntity("1"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := jacks.Values("1"); v[0] != 20 || v[1] != 30 {
+		t.Error("expected light to resume after pause", v)
+	}
+	if err := c.SetOverride("1", Override{Channels: map[int]float64{0: 80}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PauseEntity("1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ResumeEntity("1"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := jacks.Values("1"); v[0] != 80 {
+		t.Error("expected pause to leave a manual override alone", v)
+	}
+	c.Stop()
+}
+
+func TestLightOverrideExpiryRace(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	if err := con.DM().Drivers().Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	jacks := con.DM().Jacks()
+	if err := jacks.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.Create(connectors.Jack{Name: "J1", Pins: []int{0, 1}, Driver: "rpi"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.Create(connectors.Jack{Name: "J2", Pins: []int{0, 1}, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(DefaultConfig, con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	if err := c.Create(Light{Name: "Refugium", Jack: "1", Enable: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetOverride("1", Override{Channels: map[int]float64{0: 50}, Duration: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// lights created and deleted while the override expires must not race with its restart
+	deadline := time.Now().Add(5 * time.Second)
+	for n := 2; ; n++ {
+		if l, _ := c.Get("1"); l.Override == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected light to resume after the override expired")
+		}
+		if err := c.Create(Light{Name: "Sump", Jack: "2", Enable: true}); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Delete(fmt.Sprint(n)); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -13,6 +13,8 @@ import (
 type GenericStep struct {
 	ID string `json:"id"`
 	On bool   `json:"on"`
+	// Speed runs the target at a percentage instead of just switching it on, for subsystems with speed control
+	Speed float64 `json:"speed,omitempty"`
 }
 
 type WaitStep struct {
@@ -31,7 +33,8 @@ type Step struct {
 func (s *Step) Run(c controller.Controller, reverse bool) error {
 	switch s.Type {
 	case storage.EquipmentBucket, storage.ATOBucket, storage.TemperatureBucket, storage.LightingBucket,
-		storage.DoserBucket, storage.PhBucket, storage.TimerBucket, storage.MacroBucket:
+		storage.DoserBucket, storage.PhBucket, storage.TimerBucket, storage.MacroBucket, storage.WavemakerBucket,
+		storage.FeedModeBucket:
 		var g GenericStep
 		if err := json.Unmarshal(s.Config, &g); err != nil {
 			return err
@@ -44,6 +47,14 @@ func (s *Step) Run(c controller.Controller, reverse bool) error {
 		if err != nil {
 			return err
 		}
+		if state && g.Speed > 0 {
+			sc, ok := sub.(controller.SpeedController)
+			if !ok {
+				return fmt.Errorf("%s subsystem does not support speed control", s.Type)
+			}
+			log.Println("macro-subsystem: executing step: ", s.Type, "id:", g.ID, " speed:", g.Speed)
+			return sc.SetSpeed(g.ID, g.Speed)
+		}
 		log.Println("macro-subsystem: executing step: ", s.Type, "id:", g.ID, " state:", state)
 		return sub.On(g.ID, state)
 	case "subsystem":
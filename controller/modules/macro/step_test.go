@@ -24,6 +24,13 @@ func TestStep(t *testing.T) {
 	if err := s.Run(c, false); err == nil {
 		t.Error("Equipment step with invalid config should raise error")
 	}
+	s.Config = []byte(`{"id":"1", "on":true, "speed":50}`)
+	if err := s.Run(c, false); err == nil {
+		t.Error("Speed step should raise error for subsystems without speed control")
+	}
+	if err := s.Run(c, true); err != nil {
+		t.Error("Reversed speed step should switch off", err)
+	}
 
 	s.Type = "foo"
 	s.Config = []byte(`{}`)
@@ -59,6 +59,10 @@ func (s *Subsystem) InUse(depType, id string) ([]string, error) {
 	case storage.TimerBucket:
 		fallthrough
 	case storage.MacroBucket:
+		fallthrough
+	case storage.WavemakerBucket:
+		fallthrough
+	case storage.FeedModeBucket:
 		ms, err := s.List()
 		if err != nil {
 			return deps, nil
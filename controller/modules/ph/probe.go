@@ -58,6 +58,8 @@ type Probe struct {
 func (p *Probe) loadHomeostasis(c controller.Controller) {
 	hConf := controller.HomeoStasisConfig{
 		Name:       p.Name,
+		Module:     Bucket,
+		ID:         p.ID,
 		Upper:      p.UpperEq,
 		Downer:     p.DownerEq,
 		Min:        p.Min,
@@ -169,6 +171,7 @@ func (c *Controller) Delete(id string) error {
 
 	c.c.Store().Delete(CalibrationBucket, id)
 	delete(c.calibrators, id)
+	c.c.Telemetry().DeleteEntityMetrics(Bucket, id)
 
 	quit, ok := c.quitters[id]
 	if ok {
@@ -238,7 +241,9 @@ func (c *Controller) Run(p Probe, quit chan struct{}) error {
 }
 
 func (c *Controller) checkAndControl(p Probe) (float64, error) {
+	start := time.Now()
 	reading, err := c.Read(p)
+	c.c.Telemetry().ObserveSensorRead(Bucket, p.ID, start, err)
 	if err != nil {
 		log.Println("ph sub-system: ERROR: Failed to read probe:", p.Name, ". Error:", err)
 		c.c.LogError("ph-"+p.ID, "ph subsystem: Failed read probe:"+p.Name+"Error:"+err.Error())
@@ -261,7 +266,13 @@ func (c *Controller) checkAndControl(p Probe) (float64, error) {
 		}
 	}
 	c.statsMgr.Update(p.ID, u)
-	c.c.Telemetry().EmitMetric("ph", p.Name, reading)
+	c.c.Telemetry().EmitEntityMetric(telemetry.EntityMetric{
+		Module: Bucket,
+		ID:     p.ID,
+		Name:   p.Name,
+		Metric: "reading",
+		Feed:   "ph-" + p.Name,
+	}, reading)
 	return reading, nil
 }
 
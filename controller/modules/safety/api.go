@@ -0,0 +1,158 @@
+package safety
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func (c *Controller) LoadAPI(r *mux.Router) {
+
+	// swagger:route GET /api/safety/sensors Safety safetySensorList
+	// List all safety sensors.
+	// List all leak and overflow sensors in reef-pi, with their trips.
+	// responses:
+	// 	200: body:[]safetySensor
+	r.HandleFunc("/api/safety/sensors", c.list).Methods("GET")
+
+	// swagger:operation PUT /api/safety/sensors Safety safetySensorCreate
+	// Create a safety sensor.
+	// Create a new leak or overflow sensor.
+	// ---
+	// parameters:
+	//  - in: body
+	//    name: sensor
+	//    description: The safety sensor to create
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/safetySensor'
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/safety/sensors", c.create).Methods("PUT")
+
+	// swagger:operation GET /api/safety/sensors/{id} Safety safetySensorGet
+	// Get a safety sensor by id.
+	// Get an existing leak or overflow sensor by id.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the safety sensor
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    $ref: '#/definitions/safetySensor'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/safety/sensors/{id}", c.get).Methods("GET")
+
+	// swagger:operation POST /api/safety/sensors/{id} Safety safetySensorUpdate
+	// Update a safety sensor.
+	// Update an existing leak or overflow sensor. Tripped sensors have to be acknowledged first.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the safety sensor to update
+	//    required: true
+	//    schema:
+	//     type: integer
+	//  - in: body
+	//    name: sensor
+	//    description: The safety sensor to update
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/safetySensor'
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/safety/sensors/{id}", c.update).Methods("POST")
+
+	// swagger:operation DELETE /api/safety/sensors/{id} Safety safetySensorDelete
+	// Delete a safety sensor.
+	// Delete an existing leak or overflow sensor. Tripped sensors have to be acknowledged first.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the safety sensor to delete
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/safety/sensors/{id}", c.delete).Methods("DELETE")
+
+	// swagger:operation POST /api/safety/sensors/{id}/acknowledge Safety safetySensorAcknowledge
+	// Acknowledge a tripped safety sensor.
+	// Clear the trip of a sensor, releasing its equipment and atos unless another tripped sensor
+	// holds them. Fails while the sensor still detects a leak or overflow.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the safety sensor
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/safety/sensors/{id}/acknowledge", c.acknowledge).Methods("POST")
+}
+
+func (c *Controller) get(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return c.Get(id)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (c *Controller) list(w http.ResponseWriter, r *http.Request) {
+	fn := func() (interface{}, error) {
+		return c.List()
+	}
+	utils.JSONListResponse(fn, w, r)
+}
+
+func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
+	var s Sensor
+	fn := func() error {
+		return c.Create(s)
+	}
+	utils.JSONCreateResponse(&s, fn, w, r)
+}
+
+func (c *Controller) update(w http.ResponseWriter, r *http.Request) {
+	var s Sensor
+	fn := func(id string) error {
+		return c.Update(id, s)
+	}
+	utils.JSONUpdateResponse(&s, fn, w, r)
+}
+
+func (c *Controller) delete(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.Delete(id)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
+
+func (c *Controller) acknowledge(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.Acknowledge(id)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
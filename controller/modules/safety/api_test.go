@@ -0,0 +1,507 @@
+package safety
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
+	"github.com/reef-pi/reef-pi/controller/modules/ato"
+	"github.com/reef-pi/reef-pi/controller/modules/doser"
+	"github.com/reef-pi/reef-pi/controller/modules/equipment"
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/telemetry"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+// testController serves real equipment, ato and doser subsystems, other subsystems are mocked
+type testController struct {
+	controller.Controller
+	subs map[string]controller.Subsystem
+	tele telemetry.Telemetry
+}
+
+func (t *testController) Subsystem(s string) (controller.Subsystem, error) {
+	if sub, ok := t.subs[s]; ok {
+		return sub, nil
+	}
+	return t.Controller.Subsystem(s)
+}
+
+func (t *testController) Telemetry() telemetry.Telemetry {
+	if t.tele != nil {
+		return t.tele
+	}
+	return t.Controller.Telemetry()
+}
+
+// alertRecorder records the subjects of raised alerts
+type alertRecorder struct {
+	telemetry.Telemetry
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (a *alertRecorder) Alert(subject, body string) (bool, error) {
+	a.mu.Lock()
+	a.subjects = append(a.subjects, subject)
+	a.mu.Unlock()
+	return a.Telemetry.Alert(subject, body)
+}
+
+func (a *alertRecorder) alerts() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string{}, a.subjects...)
+}
+
+func TestSafetyAPI(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	drvrs := con.DM().Drivers()
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := drvrs.DigitalOutputDriver("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := d.(*virtual.Driver)
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Create(connectors.Outlet{Name: "return", Pin: 0, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	inlets := con.DM().Inlets()
+	if err := inlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inlets.Create(connectors.Inlet{Name: "leak", Pin: 1, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	ais := con.DM().AnalogInputs()
+	if err := ais.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ais.Create(connectors.AnalogInput{Name: "sump level", Pin: 0, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	eqs := equipment.New(con)
+	if err := eqs.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := eqs.Create(equipment.Equipment{Name: "Return pump", Outlet: "1", On: true}); err != nil {
+		t.Fatal(err)
+	}
+	eqs.Start()
+	defer eqs.Stop()
+	atos, err := ato.New(true, con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := atos.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := atos.Create(ato.ATO{Name: "Top off", Inlet: "1", Period: 60, Enable: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer atos.Stop()
+	jacks := con.DM().Jacks()
+	if err := jacks.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.Create(connectors.Jack{Name: "dosing", Pins: []int{0}, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	dosers, err := doser.New(false, con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dosers.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	schedule := doser.Schedule{Day: "*", Hour: "*", Minute: "*", Second: "0", Month: "*", Week: "*"}
+	if err := dosers.Create(doser.Pump{Name: "Kalk", Jack: "1", Pin: 0, Regiment: doser.DosingRegiment{Speed: 50, Schedule: schedule}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dosers.On("1", true); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(&testController{
+		Controller: con,
+		subs: map[string]controller.Subsystem{
+			storage.EquipmentBucket: eqs,
+			storage.ATOBucket:       atos,
+			storage.DoserBucket:     dosers,
+		},
+	})
+	c.interval = 10 * time.Millisecond
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+	c.Start()
+	defer c.Stop()
+
+	eventually := func(msg string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal(msg)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	tripped := func(id string) func() bool {
+		return func() bool {
+			s, err := c.Get(id)
+			return err == nil && s.Trip != nil
+		}
+	}
+	returnOn := func() bool {
+		on, _ := outlets.Observe("1")
+		return on
+	}
+	atoEnabled := func() bool {
+		a, _ := atos.Get("1")
+		return a.Enable
+	}
+	doserSpeed := func() float64 {
+		v, _ := jacks.Values("1")
+		return v[0]
+	}
+
+	for _, s := range []Sensor{
+		{Name: "bad", Equipment: []string{"1"}},
+		{Name: "bad", Inlet: "1", AnalogInput: "1", Equipment: []string{"1"}},
+		{Name: "bad", Inlet: "1"},
+		{Name: "bad", Inlet: "9", Equipment: []string{"1"}},
+		{Name: "bad", Inlet: "1", Equipment: []string{"9"}},
+		{Name: "bad", Inlet: "1", Dosers: []string{"9"}},
+	} {
+		body := new(bytes.Buffer)
+		json.NewEncoder(body).Encode(s)
+		if err := tr.Do("PUT", "/api/safety/sensors", body, nil); err == nil {
+			t.Error("expected invalid sensor to be rejected", s)
+		}
+	}
+	body := new(bytes.Buffer)
+	leak := Sensor{Name: "Sump leak", Enable: true, Inlet: "1", Equipment: []string{"1"}, ATOs: []string{"1"}, Dosers: []string{"1"}}
+	json.NewEncoder(body).Encode(leak)
+	if err := tr.Do("PUT", "/api/safety/sensors", body, nil); err != nil {
+		t.Fatal("Failed to create sensor using api", err)
+	}
+	if err := c.Create(Sensor{Name: "Overflow", Enable: true, AnalogInput: "1", Threshold: 5, Equipment: []string{"1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if deps, err := c.InUse(storage.EquipmentBucket, "1"); err != nil || len(deps) != 2 {
+		t.Error("expected equipment to be reported in use", deps, err)
+	}
+	if deps, err := c.InUse(storage.ATOBucket, "1"); err != nil || len(deps) != 1 || deps[0] != "Sump leak" {
+		t.Error("expected ato to be reported in use", deps, err)
+	}
+	if deps, err := c.InUse(storage.DoserBucket, "1"); err != nil || len(deps) != 1 || deps[0] != "Sump leak" {
+		t.Error("expected doser to be reported in use", deps, err)
+	}
+	if deps, err := c.InUse(storage.AnalogInputBucket, "1"); err != nil || len(deps) != 1 || deps[0] != "Overflow" {
+		t.Error("expected analog input to be reported in use", deps, err)
+	}
+	if err := tr.Do("POST", "/api/safety/sensors/1/acknowledge", strings.NewReader("{}"), nil); err == nil {
+		t.Error("expected acknowledging a sensor that has not tripped to fail")
+	}
+
+	if err := sim.Set(virtual.Input{Type: "digital-input", Pin: 1, Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	eventually("expected leak sensor to trip", tripped("1"))
+	if returnOn() {
+		t.Error("expected return pump to be shut down")
+	}
+	if atoEnabled() {
+		t.Error("expected ato to be disabled")
+	}
+	if doserSpeed() != 0 {
+		t.Error("expected doser to be switched off")
+	}
+	if err := dosers.On("1", true); err == nil || doserSpeed() != 0 {
+		t.Error("expected doser to stay off until the trip is acknowledged")
+	}
+	if err := eqs.On("1", true); err != nil {
+		t.Error(err)
+	}
+	if err := eqs.ClearOverride("1"); err == nil || returnOn() {
+		t.Error("expected return pump to stay shut down until the trip is acknowledged")
+	}
+	e, err := c.GetEntity("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := e.Status(); !s.(SensorStatus).Tripped {
+		t.Error("expected status to report the trip", s)
+	}
+	if err := tr.Do("POST", "/api/safety/sensors/1/acknowledge", strings.NewReader("{}"), nil); err == nil {
+		t.Error("expected acknowledging a sensor that still detects a leak to fail")
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(leak)
+	if err := tr.Do("POST", "/api/safety/sensors/1", body, nil); err == nil {
+		t.Error("expected update of a tripped sensor to be rejected")
+	}
+	if err := tr.Do("DELETE", "/api/safety/sensors/1", strings.NewReader("{}"), nil); err == nil {
+		t.Error("expected delete of a tripped sensor to be rejected")
+	}
+
+	if err := sim.Set(virtual.Input{Type: "analog-input", Pin: 0, Value: 10}); err != nil {
+		t.Fatal(err)
+	}
+	eventually("expected overflow sensor to trip", tripped("2"))
+	if err := sim.Set(virtual.Input{Type: "digital-input", Pin: 1, Value: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Do("POST", "/api/safety/sensors/1/acknowledge", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to acknowledge sensor using api", err)
+	}
+	if !atoEnabled() {
+		t.Error("expected ato to be enabled again once acknowledged")
+	}
+	if err := dosers.On("1", true); err != nil || doserSpeed() != 50 {
+		t.Error("expected doser to be released once acknowledged", err)
+	}
+	if returnOn() {
+		t.Error("expected return pump to stay shut down while the overflow sensor is tripped")
+	}
+	if err := sim.Set(virtual.Input{Type: "analog-input", Pin: 0, Value: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Acknowledge("2"); err != nil {
+		t.Fatal(err)
+	}
+	if !returnOn() {
+		t.Error("expected return pump to be restored once all trips are acknowledged")
+	}
+
+	var ss []Sensor
+	if err := tr.Do("GET", "/api/safety/sensors", strings.NewReader("{}"), &ss); err != nil || len(ss) != 2 {
+		t.Error("Failed to list sensors using api", ss, err)
+	}
+	leak.Enable = false
+	body.Reset()
+	json.NewEncoder(body).Encode(leak)
+	if err := tr.Do("POST", "/api/safety/sensors/1", body, nil); err != nil {
+		t.Fatal("Failed to update sensor using api", err)
+	}
+	if err := tr.Do("DELETE", "/api/safety/sensors/1", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to delete sensor using api", err)
+	}
+}
+
+func TestSafetyAcknowledgeReadFailure(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	if err := con.DM().Drivers().Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	inlets := con.DM().Inlets()
+	if err := inlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inlets.Create(connectors.Inlet{Name: "leak", Pin: 1, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	atos, err := ato.New(true, con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := atos.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := atos.Create(ato.ATO{Name: "Top off", Inlet: "1", Period: 60, Enable: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer atos.Stop()
+	c := New(&testController{
+		Controller: con,
+		subs:       map[string]controller.Subsystem{storage.ATOBucket: atos},
+	})
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Sensor{Name: "Sump leak", Enable: true, Inlet: "1", ATOs: []string{"1"}}); err != nil {
+		t.Fatal(err)
+	}
+	c.trip("1", 1)
+	if err := inlets.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Acknowledge("1"); err == nil {
+		t.Error("expected acknowledging a sensor that can not be read to fail")
+	}
+	if s, err := c.Get("1"); err != nil || s.Trip == nil {
+		t.Error("expected sensor to stay tripped", err)
+	}
+	if a, _ := atos.Get("1"); a.Enable {
+		t.Error("expected ato to stay disabled")
+	}
+}
+
+func TestSafetyStepperDose(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	drvrs := con.DM().Drivers()
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := drvrs.DigitalOutputDriver("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := d.(*virtual.Driver)
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"step", "direction", "ms a", "ms b", "ms c"} {
+		if err := outlets.Create(connectors.Outlet{Name: name, Pin: i, Driver: "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inlets := con.DM().Inlets()
+	if err := inlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inlets.Create(connectors.Inlet{Name: "leak", Pin: 1, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	dosers, err := doser.New(false, con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dosers.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	// 8000 steps of 2ms take 16s to dose
+	stepper := &doser.DRV8825{StepPin: "1", DirectionPin: "2", MSPinA: "3", MSPinB: "4", MSPinC: "5", SPR: 200, VPR: 1, MicroStepping: "Full", Delay: 1e6}
+	schedule := doser.Schedule{Day: "*", Hour: "*", Minute: "*", Second: "0", Month: "*", Week: "*"}
+	if err := dosers.Create(doser.Pump{Name: "Kalk", Type: "stepper", Stepper: stepper, Regiment: doser.DosingRegiment{Volume: 10, Schedule: schedule}}); err != nil {
+		t.Fatal(err)
+	}
+	c := New(&testController{
+		Controller: con,
+		subs:       map[string]controller.Subsystem{storage.DoserBucket: dosers},
+	})
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Sensor{Name: "Sump leak", Enable: true, Inlet: "1", Dosers: []string{"1"}}); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- dosers.Dose("1", 0) }()
+	time.Sleep(100 * time.Millisecond)
+	if err := sim.Set(virtual.Input{Type: "digital-input", Pin: 1, Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	c.check()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected stepper dose to report the abort")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected trip to stop the stepper before the dose completes")
+	}
+	if err := dosers.Dose("1", 0); err == nil {
+		t.Error("expected stepper to refuse doses until the trip is acknowledged")
+	}
+}
+
+func TestSafetyReadFailureAlert(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	if err := con.DM().Drivers().Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	inlets := con.DM().Inlets()
+	if err := inlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	for pin, name := range []string{"leak", "spare leak"} {
+		if err := inlets.Create(connectors.Inlet{Name: name, Pin: pin, Driver: "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	atos, err := ato.New(true, con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := atos.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := atos.Create(ato.ATO{Name: "Top off", Inlet: "2", Period: 60}); err != nil {
+		t.Fatal(err)
+	}
+	tele := &alertRecorder{Telemetry: con.Telemetry()}
+	c := New(&testController{
+		Controller: con,
+		subs:       map[string]controller.Subsystem{storage.ATOBucket: atos},
+		tele:       tele,
+	})
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	leak := Sensor{Name: "Sump leak", Enable: true, Inlet: "1", ATOs: []string{"1"}}
+	if err := c.Create(leak); err != nil {
+		t.Fatal(err)
+	}
+	if err := inlets.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < readFailureLimit; i++ {
+		c.check()
+	}
+	if a := tele.alerts(); len(a) != 0 {
+		t.Error("expected no alert before the read failure limit", a)
+	}
+	c.check()
+	c.check()
+	if a := tele.alerts(); len(a) != 1 || a[0] != "CRITICAL: safety sensor Sump leak can not be read" {
+		t.Error("expected a single critical alert once the sensor repeatedly can not be read", a)
+	}
+	if s, err := c.Get("1"); err != nil || s.Trip != nil {
+		t.Error("expected read failures to not trip the sensor", err)
+	}
+
+	leak.Inlet = "2"
+	if err := c.Update("1", leak); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < readFailureLimit; i++ {
+		c.check()
+	}
+	if a := tele.alerts(); len(a) != 1 {
+		t.Error("expected no alert while the sensor can be read", a)
+	}
+}
@@ -0,0 +1,295 @@
+package safety
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+const Bucket = storage.SafetyBucket
+
+// interval between reads of safety sensors
+const checkInterval = time.Second
+
+// consecutive failed reads of a sensor that raise a critical alert
+const readFailureLimit = 3
+
+type Controller struct {
+	sync.Mutex
+	c        controller.Controller
+	interval time.Duration
+	quit     chan struct{}
+	// failures counts consecutive failed reads of each sensor
+	failures map[string]int
+}
+
+func New(c controller.Controller) *Controller {
+	return &Controller{
+		c:        c,
+		interval: checkInterval,
+		failures: make(map[string]int),
+	}
+}
+
+func (c *Controller) Setup() error {
+	return c.c.Store().CreateBucket(Bucket)
+}
+
+// Start shuts down again for sensors tripped before reef-pi stopped, then starts watching sensors
+func (c *Controller) Start() {
+	ss, err := c.List()
+	if err != nil {
+		log.Println("ERROR: safety subsystem: failed to list sensors. Error:", err)
+	}
+	for _, s := range ss {
+		if s.Trip != nil {
+			c.shutdown(s)
+		}
+	}
+	c.Lock()
+	defer c.Unlock()
+	if c.quit == nil {
+		c.quit = make(chan struct{})
+		go c.run(c.quit)
+	}
+}
+
+func (c *Controller) Stop() {
+	c.Lock()
+	defer c.Unlock()
+	if c.quit != nil {
+		close(c.quit)
+		c.quit = nil
+	}
+	log.Println("Stopped safety subsystem")
+}
+
+// On enables or disables a sensor
+func (c *Controller) On(id string, on bool) error {
+	s, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	s.Enable = on
+	return c.Update(id, s)
+}
+
+func (c *Controller) InUse(depType, id string) ([]string, error) {
+	var deps []string
+	ss, err := c.List()
+	if err != nil {
+		return deps, err
+	}
+	for _, s := range ss {
+		switch depType {
+		case storage.EquipmentBucket, storage.ATOBucket, storage.DoserBucket:
+			for _, t := range s.targets()[depType] {
+				if t == id {
+					deps = append(deps, s.Name)
+				}
+			}
+		case storage.InletBucket:
+			if s.Inlet == id {
+				deps = append(deps, s.Name)
+			}
+		case storage.AnalogInputBucket:
+			if s.AnalogInput == id {
+				deps = append(deps, s.Name)
+			}
+		default:
+			return deps, fmt.Errorf("unknown dep type:%s", depType)
+		}
+	}
+	return deps, nil
+}
+
+func (c *Controller) GetEntity(id string) (controller.Entity, error) {
+	return c.Get(id)
+}
+
+func (c *Controller) run(quit chan struct{}) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.check()
+		case <-quit:
+			return
+		}
+	}
+}
+
+// check reads all enabled sensors that have not tripped yet, and trips them if needed
+func (c *Controller) check() {
+	ss, err := c.List()
+	if err != nil {
+		log.Println("ERROR: safety subsystem: failed to list sensors. Error:", err)
+		return
+	}
+	for _, s := range ss {
+		if !s.Enable || s.Trip != nil {
+			continue
+		}
+		tripped, v, err := c.read(s)
+		if err != nil {
+			log.Println("ERROR: safety subsystem: failed to read sensor", s.Name, ". Error:", err)
+			c.c.LogError("safety-"+s.ID, "Failed to read safety sensor. Name:"+s.Name+". Error:"+err.Error())
+			c.readFailed(s, err)
+			continue
+		}
+		c.Lock()
+		delete(c.failures, s.ID)
+		c.Unlock()
+		if tripped {
+			c.trip(s.ID, v)
+		}
+	}
+}
+
+// readFailed counts a failed read of a sensor, and raises a critical alert once it failed
+// readFailureLimit times in a row, as the sensor no longer protects its equipment
+func (c *Controller) readFailed(s Sensor, err error) {
+	c.Lock()
+	c.failures[s.ID]++
+	n := c.failures[s.ID]
+	c.Unlock()
+	if n != readFailureLimit {
+		return
+	}
+	subject := "CRITICAL: safety sensor " + s.Name + " can not be read"
+	body := fmt.Sprintf("Safety sensor %s failed %d reads in a row, leaks or overflows are not detected until it can be read again. Error: %v", s.Name, n, err)
+	if _, err := c.c.Telemetry().Alert(subject, body); err != nil {
+		log.Println("ERROR: safety subsystem: failed to send alert for", s.Name, ". Error:", err)
+	}
+}
+
+// read returns whether a sensor detects a leak or overflow, along with its reading
+func (c *Controller) read(s Sensor) (bool, float64, error) {
+	if s.Inlet != "" {
+		v, err := c.c.DM().Inlets().Read(s.Inlet)
+		return v == 1, float64(v), err
+	}
+	v, err := c.c.DM().AnalogInputs().Read(s.AnalogInput)
+	return v >= s.Threshold, v, err
+}
+
+// trip latches a sensor, shuts down its equipment, atos and dosers and raises a critical alert
+func (c *Controller) trip(id string, v float64) {
+	c.Lock()
+	defer c.Unlock()
+	s, err := c.Get(id)
+	if err != nil || s.Trip != nil {
+		return
+	}
+	log.Println("safety subsystem:", s.Name, "tripped. Reading:", v)
+	c.shutdown(s)
+	s.Trip = &Trip{At: time.Now(), Reading: v}
+	if err := c.c.Store().Update(Bucket, id, s); err != nil {
+		log.Println("ERROR: safety subsystem: failed to save trip of", s.Name, ". Error:", err)
+	}
+	c.c.Telemetry().ControlAction(Bucket, s.ID, "trip")
+	subject := "CRITICAL: " + s.Name + " detected a leak or overflow"
+	body := "Safety sensor " + s.Name + " tripped at " + s.Trip.At.Format(time.RFC1123) +
+		". Equipment (" + strings.Join(s.Equipment, ", ") + "), atos (" + strings.Join(s.ATOs, ", ") +
+		") and dosers (" + strings.Join(s.Dosers, ", ") + ") are shut down until the trip is acknowledged."
+	if _, err := c.c.Telemetry().Alert(subject, body); err != nil {
+		log.Println("ERROR: safety subsystem: failed to send alert for", s.Name, ". Error:", err)
+	}
+}
+
+func (c *Controller) shutdown(s Sensor) {
+	for sub, ids := range s.targets() {
+		if len(ids) == 0 {
+			continue
+		}
+		il, err := c.interlock(sub)
+		if err != nil {
+			log.Println("ERROR: safety subsystem:", err)
+			continue
+		}
+		for _, id := range ids {
+			if err := il.Interlock(id); err != nil {
+				log.Println("ERROR: safety subsystem: failed to shut down", sub, id, ". Error:", err)
+			}
+		}
+	}
+}
+
+// Acknowledge clears the trip of a sensor and releases its equipment, atos and dosers, unless
+// another tripped sensor holds them. A sensor that still detects a leak, or can not be read, can
+// not be acknowledged
+func (c *Controller) Acknowledge(id string) error {
+	c.Lock()
+	defer c.Unlock()
+	s, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if s.Trip == nil {
+		return fmt.Errorf("safety sensor '%s' is not tripped", s.Name)
+	}
+	tripped, _, err := c.read(s)
+	if err != nil {
+		return fmt.Errorf("failed to read safety sensor '%s'. Error: %w", s.Name, err)
+	}
+	if tripped {
+		return fmt.Errorf("safety sensor '%s' still detects a leak or overflow", s.Name)
+	}
+	ss, err := c.List()
+	if err != nil {
+		return err
+	}
+	held := make(map[string]bool)
+	for _, o := range ss {
+		if o.ID == s.ID || o.Trip == nil {
+			continue
+		}
+		for sub, ids := range o.targets() {
+			for _, t := range ids {
+				held[sub+"/"+t] = true
+			}
+		}
+	}
+	s.Trip = nil
+	if err := c.c.Store().Update(Bucket, id, s); err != nil {
+		return err
+	}
+	log.Println("safety subsystem:", s.Name, "acknowledged")
+	for sub, ids := range s.targets() {
+		if len(ids) == 0 {
+			continue
+		}
+		il, err := c.interlock(sub)
+		if err != nil {
+			log.Println("ERROR: safety subsystem:", err)
+			continue
+		}
+		for _, t := range ids {
+			if held[sub+"/"+t] {
+				continue
+			}
+			if err := il.Release(t); err != nil {
+				log.Println("ERROR: safety subsystem: failed to release", sub, t, ". Error:", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Controller) interlock(sub string) (controller.Interlock, error) {
+	s, err := c.c.Subsystem(sub)
+	if err != nil {
+		return nil, err
+	}
+	il, ok := s.(controller.Interlock)
+	if !ok {
+		return nil, fmt.Errorf("%s subsystem can not be shut down", sub)
+	}
+	return il, nil
+}
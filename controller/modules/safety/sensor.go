@@ -0,0 +1,167 @@
+package safety
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+// Sensor is a leak or overflow sensor. When it trips its equipment and dosers are switched off
+// and its atos are disabled, until the trip is acknowledged. A sensor that repeatedly can not be
+// read does not trip, it raises a critical alert instead
+//
+// swagger:model safetySensor
+type Sensor struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Enable bool   `json:"enable"`
+	// Inlet is a digital sensor, tripped while it reads high
+	Inlet string `json:"inlet"`
+	// AnalogInput is an analog sensor, tripped once it reads Threshold or above
+	AnalogInput string   `json:"analog_input"`
+	Threshold   float64  `json:"threshold"`
+	Equipment   []string `json:"equipment"`
+	ATOs        []string `json:"atos"`
+	Dosers      []string `json:"dosers"`
+	// Trip is set once the sensor trips and kept until acknowledged
+	Trip *Trip `json:"trip,omitempty"`
+}
+
+// swagger:model safetyTrip
+type Trip struct {
+	At      time.Time `json:"at"`
+	Reading float64   `json:"reading"`
+}
+
+// swagger:model safetySensorStatus
+type SensorStatus struct {
+	Tripped bool      `json:"tripped"`
+	Since   time.Time `json:"since"`
+}
+
+func (s Sensor) EName() string { return s.Name }
+
+func (s Sensor) Status() (interface{}, error) {
+	if s.Trip == nil {
+		return SensorStatus{}, nil
+	}
+	return SensorStatus{Tripped: true, Since: s.Trip.At}, nil
+}
+
+// targets returns the entities shut down by a sensor by subsystem
+func (s Sensor) targets() map[string][]string {
+	return map[string][]string{
+		storage.EquipmentBucket: s.Equipment,
+		storage.ATOBucket:       s.ATOs,
+		storage.DoserBucket:     s.Dosers,
+	}
+}
+
+func (c *Controller) validate(s Sensor) error {
+	if s.Name == "" {
+		return fmt.Errorf("safety sensor name can not be empty")
+	}
+	switch {
+	case s.Inlet != "" && s.AnalogInput != "":
+		return fmt.Errorf("safety sensor can use either an inlet or an analog input, not both")
+	case s.Inlet != "":
+		if _, err := c.c.DM().Inlets().Get(s.Inlet); err != nil {
+			return fmt.Errorf("inlet '%s' does not exist. Error: %w", s.Inlet, err)
+		}
+	case s.AnalogInput != "":
+		if _, err := c.c.DM().AnalogInputs().Get(s.AnalogInput); err != nil {
+			return fmt.Errorf("analog input '%s' does not exist. Error: %w", s.AnalogInput, err)
+		}
+	default:
+		return fmt.Errorf("safety sensor requires an inlet or an analog input")
+	}
+	if len(s.Equipment) == 0 && len(s.ATOs) == 0 && len(s.Dosers) == 0 {
+		return fmt.Errorf("safety sensor requires equipment, atos or dosers to shut down")
+	}
+	for sub, ids := range s.targets() {
+		if len(ids) == 0 {
+			continue
+		}
+		sys, err := c.c.Subsystem(sub)
+		if err != nil {
+			return err
+		}
+		if _, ok := sys.(controller.Interlock); !ok {
+			return fmt.Errorf("%s subsystem can not be shut down", sub)
+		}
+		for _, id := range ids {
+			if _, err := sys.GetEntity(id); err != nil {
+				return fmt.Errorf("%s '%s' does not exist. Error: %w", sub, id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Controller) Get(id string) (Sensor, error) {
+	var s Sensor
+	return s, c.c.Store().Get(Bucket, id, &s)
+}
+
+func (c *Controller) List() ([]Sensor, error) {
+	ss := []Sensor{}
+	fn := func(_ string, v []byte) error {
+		var s Sensor
+		if err := json.Unmarshal(v, &s); err != nil {
+			return err
+		}
+		ss = append(ss, s)
+		return nil
+	}
+	return ss, c.c.Store().List(Bucket, fn)
+}
+
+func (c *Controller) Create(s Sensor) error {
+	if err := c.validate(s); err != nil {
+		return err
+	}
+	s.Trip = nil
+	fn := func(id string) interface{} {
+		s.ID = id
+		return &s
+	}
+	return c.c.Store().Create(Bucket, fn)
+}
+
+// Update changes a sensor, a tripped sensor has to be acknowledged first
+func (c *Controller) Update(id string, s Sensor) error {
+	if err := c.validate(s); err != nil {
+		return err
+	}
+	c.Lock()
+	defer c.Unlock()
+	cur, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if cur.Trip != nil {
+		return fmt.Errorf("safety sensor '%s' is tripped, acknowledge it before changing it", cur.Name)
+	}
+	s.ID = id
+	s.Trip = nil
+	delete(c.failures, id)
+	return c.c.Store().Update(Bucket, id, s)
+}
+
+// Delete removes a sensor, a tripped sensor has to be acknowledged first
+func (c *Controller) Delete(id string) error {
+	c.Lock()
+	defer c.Unlock()
+	s, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if s.Trip != nil {
+		return fmt.Errorf("safety sensor '%s' is tripped, acknowledge it before deleting it", s.Name)
+	}
+	delete(c.failures, id)
+	return c.c.Store().Delete(Bucket, id)
+}
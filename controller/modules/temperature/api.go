@@ -2,7 +2,6 @@ package temperature
 
 import (
 	"net/http"
-	"path/filepath"
 
 	"github.com/gorilla/mux"
 
@@ -34,6 +33,28 @@ func (t *Controller) LoadAPI(r *mux.Router) {
 	//   description: An array of sensor ids
 	r.HandleFunc("/api/tcs/sensors", t.sensors).Methods("GET")
 
+	// swagger:operation GET /api/tcs/sensors/{source} Temperature tcsSourceSensors
+	// List temperature sensors of a source.
+	// List sensors of a source, 1-Wire device ids for w1 or analog input ids for analog_input.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: source
+	//    description: The sensor source, w1 or analog_input
+	//    required: true
+	//    schema:
+	//     type: string
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    type: array
+	//    items:
+	//     type: string
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/tcs/sensors/{source}", t.sourceSensors).Methods("GET")
+
 	// swagger:operation PUT /api/tcs Temperature tcsCreate
 	// Create a temperature controller.
 	// Create a new temperature controller.
@@ -234,19 +255,14 @@ func (c *Controller) list(w http.ResponseWriter, r *http.Request) {
 
 func (t *Controller) sensors(w http.ResponseWriter, r *http.Request) {
 	fn := func(id string) (interface{}, error) {
-		fs := mockSensors
-		if !t.devMode {
-			files, err := filepath.Glob("/sys/bus/w1/devices/28-*")
-			if err != nil {
-				return nil, err
-			}
-			fs = files
-		}
-		sensors := []string{}
-		for _, f := range fs {
-			sensors = append(sensors, filepath.Base(f))
-		}
-		return sensors, nil
+		return t.Sensors(W1Source)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (t *Controller) sourceSensors(w http.ResponseWriter, r *http.Request) {
+	fn := func(_ string) (interface{}, error) {
+		return t.Sensors(mux.Vars(r)["source"])
 	}
 	utils.JSONGetResponse(fn, w, r)
 }
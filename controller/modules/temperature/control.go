@@ -14,7 +14,9 @@ func (c *Controller) Check(tc *TC) (float64, error) {
 		return 0, nil
 	}
 
+	start := time.Now()
 	reading, err := c.Read(tc)
+	c.c.Telemetry().ObserveSensorRead(Bucket, tc.ID, start, err)
 	if err != nil {
 		log.Println("ERROR: temperature sub-system. Failed to read  sensor. Error:", err)
 		c.c.LogError("tc-"+tc.ID, "temperature sub-system. Failed to read  sensor "+tc.Name+". Error:"+err.Error())
@@ -23,14 +25,14 @@ func (c *Controller) Check(tc *TC) (float64, error) {
 		return reading, err
 	}
 
-	calibrator, exists := c.calibrators[tc.Sensor]
+	calibrator, exists := c.calibrators[tc.calibrationKey()]
 	if exists {
 		reading = calibrator.Calibrate(reading)
 	}
 
 	tc.currentValue = reading
 	log.Println("temperature sub-system:  sensor", tc.Name, "value:", reading)
-	c.c.Telemetry().EmitMetric(tc.Name, "reading", reading)
+	c.c.Telemetry().EmitEntityMetric(tc.metric("reading"), reading)
 	u := controller.Observation{
 		Time:  telemetry.TeleTime(time.Now()),
 		Value: reading,
@@ -58,14 +60,24 @@ func (c *Controller) Check(tc *TC) (float64, error) {
 		return reading, nil
 	}
 	if tc.Heater != "" {
-		c.c.Telemetry().EmitMetric(tc.Name, "heater", float64(u.Upper))
+		c.c.Telemetry().EmitEntityMetric(tc.metric("heater"), float64(u.Upper))
 	}
 	if tc.Cooler != "" {
-		c.c.Telemetry().EmitMetric(tc.Name, "cooler", float64(u.Downer))
+		c.c.Telemetry().EmitEntityMetric(tc.metric("cooler"), float64(u.Downer))
 	}
 	return reading, nil
 }
 
+func (tc *TC) metric(m string) telemetry.EntityMetric {
+	return telemetry.EntityMetric{
+		Module: Bucket,
+		ID:     tc.ID,
+		Name:   tc.Name,
+		Metric: m,
+		Feed:   tc.Name + "-" + m,
+	}
+}
+
 func (c *Controller) NotifyIfNeeded(tc *TC, reading float64) {
 	if !tc.Notify.Enable {
 		return
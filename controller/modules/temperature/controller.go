@@ -25,6 +25,7 @@ type Controller struct {
 	statsMgr    telemetry.StatsManager
 	tcs         map[string]*TC
 	calibrators map[string]hal.Calibrator
+	providers   map[string]SensorProvider
 }
 
 func New(devMode bool, c controller.Controller) (*Controller, error) {
@@ -35,6 +36,10 @@ func New(devMode bool, c controller.Controller) (*Controller, error) {
 		tcs:         make(map[string]*TC),
 		calibrators: make(map[string]hal.Calibrator),
 		statsMgr:    c.Telemetry().NewStatsManager(UsageBucket),
+		providers: map[string]SensorProvider{
+			W1Source:          &w1Provider{path: w1Path},
+			AnalogInputSource: &analogInputProvider{ais: c.DM().AnalogInputs()},
+		},
 	}, nil
 }
 
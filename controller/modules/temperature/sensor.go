@@ -11,12 +11,118 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
 	"github.com/reef-pi/reef-pi/controller/utils"
 )
 
+// Sensor sources
+const (
+	W1Source          = "w1"
+	AnalogInputSource = "analog_input"
+)
+
+// w1Path is where the kernel exposes 1-Wire devices
+const w1Path = "/sys/bus/w1/devices"
+
+// SensorProvider reads temperature sensors of one kind. Readings are in celsius
+type SensorProvider interface {
+	Read(sensor string) (float64, error)
+	Sensors() ([]string, error)
+}
+
+// w1Provider reads DS18B20 sensors through the 1-Wire sysfs interface. path is only changed by tests
+type w1Provider struct {
+	path string
+}
+
+func (p *w1Provider) Read(sensor string) (float64, error) {
+	var err error
+	for attempt := 0; attempt <= 3; attempt++ {
+		fi, oErr := os.Open(filepath.Join(p.path, sensor, "w1_slave"))
+		if oErr != nil {
+			return -1, oErr
+		}
+		var v float64
+		v, err = readW1(fi)
+		fi.Close()
+		if err == nil {
+			return v, nil
+		}
+	}
+	return -1, err
+}
+
+func (p *w1Provider) Sensors() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(p.path, "28-*"))
+	if err != nil {
+		return nil, err
+	}
+	sensors := []string{}
+	for _, f := range files {
+		sensors = append(sensors, filepath.Base(f))
+	}
+	return sensors, nil
+}
+
+// analogInputProvider reads sensors exposed as device manager analog inputs, e.g. sht31d,
+// thermistors on an ADS1115, EZO RTD boards or network sensors. Sensors are analog input ids
+type analogInputProvider struct {
+	ais *connectors.AnalogInputs
+}
+
+func (p *analogInputProvider) Read(sensor string) (float64, error) {
+	return p.ais.Read(sensor)
+}
+
+func (p *analogInputProvider) Sensors() ([]string, error) {
+	ais, err := p.ais.List()
+	if err != nil {
+		return nil, err
+	}
+	sensors := []string{}
+	for _, a := range ais {
+		sensors = append(sensors, a.ID)
+	}
+	return sensors, nil
+}
+
+// RegisterProvider adds or replaces the sensor provider of a source
+func (c *Controller) RegisterProvider(source string, p SensorProvider) {
+	c.Lock()
+	defer c.Unlock()
+	c.providers[source] = p
+}
+
+func (c *Controller) provider(source string) (SensorProvider, error) {
+	c.Lock()
+	defer c.Unlock()
+	p, ok := c.providers[source]
+	if !ok {
+		return nil, fmt.Errorf("unknown temperature sensor source: %s", source)
+	}
+	return p, nil
+}
+
+// Sensors lists the sensors available from a source
+func (c *Controller) Sensors(source string) ([]string, error) {
+	if c.devMode && source == W1Source {
+		sensors := []string{}
+		for _, f := range mockSensors {
+			sensors = append(sensors, filepath.Base(f))
+		}
+		return sensors, nil
+	}
+	p, err := c.provider(source)
+	if err != nil {
+		return nil, err
+	}
+	return p.Sensors()
+}
+
 func (c *Controller) Read(tc *TC) (float64, error) {
 	log.Println("Reading temperature from device:", tc.Sensor)
-	if c.devMode {
+	source := tc.source()
+	if c.devMode && source == W1Source {
 		log.Println("Temperature controller is running in dev mode, skipping sensor reading.")
 		if tc.Fahrenheit {
 			return utils.RoundToTwoDecimal(78.0 + (3 * rand.Float64())), nil
@@ -24,26 +130,42 @@ func (c *Controller) Read(tc *TC) (float64, error) {
 
 		return utils.RoundToTwoDecimal(24.4 + (1.5 * rand.Float64())), nil
 	}
+	p, err := c.provider(source)
+	if err != nil {
+		return -1, err
+	}
+	v, err := p.Read(tc.Sensor)
+	if err != nil {
+		return -1, err
+	}
+	return tc.fromCelsius(v), nil
+}
 
-	var v float64
-	var err error
+func (t *TC) source() string {
+	if t.Source == "" {
+		return W1Source
+	}
+	return t.Source
+}
 
-	for attempt := 0; attempt <= 3; attempt++ {
-		fi, err := os.Open(filepath.Join("/sys/bus/w1/devices", tc.Sensor, "w1_slave"))
-		if err != nil {
-			return -1, err
-		}
-		v, err = tc.readTemperature(fi)
-		fi.Close()
-		if err == nil {
-			return v, err
-		}
+// calibrationKey identifies a sensor across sources. 1-Wire sensors keep their bare id
+// so calibrations stored before sources were introduced still apply
+func (t *TC) calibrationKey() string {
+	if t.source() == W1Source {
+		return t.Sensor
 	}
+	return t.Source + ":" + t.Sensor
+}
 
-	return v, err
+func (t *TC) fromCelsius(temp float64) float64 {
+	if t.Fahrenheit {
+		temp = ((temp * 9.0) / 5.0) + 32.0
+	}
+	return utils.RoundToTwoDecimal(temp)
 }
 
-func (t *TC) readTemperature(fi io.Reader) (float64, error) {
+// readW1 parses the w1_slave file of a DS18B20 sensor
+func readW1(fi io.Reader) (float64, error) {
 	reader := bufio.NewReader(fi)
 	l1, _, err := reader.ReadLine()
 	if err != nil {
@@ -69,9 +191,5 @@ func (t *TC) readTemperature(fi io.Reader) (float64, error) {
 	if temp < -55 || temp > 125 {
 		return -1, fmt.Errorf("temperature reading out of range: -55 < %v < 125", temp)
 	}
-
-	if t.Fahrenheit {
-		temp = ((temp * 9.0) / 5.0) + 32.0
-	}
-	return utils.RoundToTwoDecimal(temp), nil
+	return temp, nil
 }
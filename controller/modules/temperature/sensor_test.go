@@ -1,10 +1,18 @@
 package temperature
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers/virtual"
+	"github.com/reef-pi/reef-pi/controller/utils"
 )
 
 func Test_ReadTemperature(t *testing.T) {
@@ -13,24 +21,20 @@ func Test_ReadTemperature(t *testing.T) {
 	tc := TC{
 		Fahrenheit: true,
 	}
-	v, err := tc.readTemperature(strings.NewReader(data))
+	v, err := readW1(strings.NewReader(data))
 	if err != nil {
 		t.Error(err)
 	}
-	if v != 74.08 {
+	if v = tc.fromCelsius(v); v != 74.08 {
 		t.Error("Expected 74.08 found:", v)
 	}
 }
 
 func Test_InvalidTemperature(t *testing.T) {
-	tc := TC{
-		Fahrenheit: false,
-	}
-
 	data := `76 01 4b 46 7f ff 0a 10 79 : crc=79 YES
 76 01 4b 46 7f ff 0a 10 79 t=-60375`
 
-	_, err := tc.readTemperature(strings.NewReader(data))
+	_, err := readW1(strings.NewReader(data))
 	if err == nil {
 		t.Error("value is out of range and should be an error")
 	}
@@ -38,13 +42,45 @@ func Test_InvalidTemperature(t *testing.T) {
 	data = `76 01 4b 46 7f ff 0a 10 79 : crc=79 YES
 76 01 4b 46 7f ff 0a 10 79 t=156000`
 
-	_, err = tc.readTemperature(strings.NewReader(data))
+	_, err = readW1(strings.NewReader(data))
 	if err == nil {
 		t.Error("value is out of range and should be an error")
 	}
 
 }
 
+func TestW1Provider(t *testing.T) {
+	base := t.TempDir()
+	dev := filepath.Join(base, "28-0316a2795bff")
+	if err := os.Mkdir(dev, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := "76 01 4b 46 7f ff 0a 10 79 : crc=79 YES\n76 01 4b 46 7f ff 0a 10 79 t=25125\n"
+	if err := os.WriteFile(filepath.Join(dev, "w1_slave"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(base, "w1_bus_master1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	p := &w1Provider{path: base}
+	sensors, err := p.Sensors()
+	if err != nil || len(sensors) != 1 || sensors[0] != "28-0316a2795bff" {
+		t.Error("expected a single 1-Wire sensor", sensors, err)
+	}
+	if v, err := p.Read("28-0316a2795bff"); err != nil || v != 25.125 {
+		t.Error("expected 25.125", v, err)
+	}
+	if _, err := p.Read("28-missing"); err == nil {
+		t.Error("expected error for missing sensor")
+	}
+	if err := os.WriteFile(filepath.Join(dev, "w1_slave"), []byte("76 01 4b 46 7f ff 0a 10 79 : crc=79 NO\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Read("28-0316a2795bff"); err == nil {
+		t.Error("expected error for failed crc")
+	}
+}
+
 func readFromFile(path string) (float32, error) {
 	fi, err := os.Open(path)
 	if err != nil {
@@ -63,3 +99,84 @@ func readFromFile(path string) (float32, error) {
 	}
 	return float32(t), nil
 }
+
+type fixedProvider struct{ v float64 }
+
+func (p *fixedProvider) Read(string) (float64, error) { return p.v, nil }
+func (p *fixedProvider) Sensors() ([]string, error)   { return []string{"probe"}, nil }
+
+func TestSensorProviders(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	drvrs := con.DM().Drivers()
+	if err := drvrs.Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := drvrs.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	for _, d := range ds {
+		if d.Type == "virtual" {
+			id = d.ID
+		}
+	}
+	d, err := drvrs.DigitalOutputDriver(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ais := con.DM().AnalogInputs()
+	if err := ais.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ais.Create(connectors.AnalogInput{Name: "rtd", Pin: 0, Driver: id}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.(*virtual.Driver).Set(virtual.Input{Type: "analog-input", Pin: 0, Value: 25}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(true, con)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	sensors, err := c.Sensors(AnalogInputSource)
+	if err != nil || len(sensors) != 1 || sensors[0] != "1" {
+		t.Error("expected analog input 1 as sensor", sensors, err)
+	}
+	tc := &TC{Name: "rtd", Sensor: "1", Source: AnalogInputSource, Fahrenheit: true, Period: 60}
+	if v, err := c.Read(tc); err != nil || v != 77 {
+		t.Error("expected 77F from analog input", v, err)
+	}
+	if tc.calibrationKey() != "analog_input:1" {
+		t.Error("unexpected calibration key", tc.calibrationKey())
+	}
+
+	tc.Source = "can"
+	if err := c.Create(tc); err == nil {
+		t.Error("expected unknown source to be rejected")
+	}
+	c.RegisterProvider("can", &fixedProvider{v: 24.5})
+	if err := c.Create(tc); err != nil {
+		t.Error(err)
+	}
+	tc.Fahrenheit = false
+	if v, err := c.Read(tc); err != nil || v != 24.5 {
+		t.Error("expected 24.5 from registered provider", v, err)
+	}
+
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+	var probes []string
+	if err := tr.Do("GET", "/api/tcs/sensors/can", new(bytes.Buffer), &probes); err != nil || len(probes) != 1 {
+		t.Error("failed to list sensors of a source using api", probes, err)
+	}
+	if err := tr.Do("GET", "/api/tcs/sensors/i2c", new(bytes.Buffer), nil); err == nil {
+		t.Error("expected error listing sensors of an unknown source")
+	}
+}
@@ -40,6 +40,7 @@ type TC struct {
 	Enable       bool          `json:"enable"`
 	Notify       Notify        `json:"notify"`
 	Sensor       string        `json:"sensor"`
+	Source       string        `json:"source"` // w1 (default) or analog_input, sensor is the analog input id for the latter
 	Fahrenheit   bool          `json:"fahrenheit"`
 	IsMacro      bool          `json:"is_macro"`
 	OneShot      bool          `json:"one_shot"`
@@ -54,6 +55,8 @@ func (t *TC) loadHomeostasis(c controller.Controller) {
 	defer t.Unlock()
 	hConf := controller.HomeoStasisConfig{
 		Name:       t.Name,
+		Module:     Bucket,
+		ID:         t.ID,
 		Upper:      t.Heater,
 		Downer:     t.Cooler,
 		Min:        t.Min,
@@ -94,6 +97,9 @@ func (c *Controller) Create(tc *TC) error {
 	if tc.Period <= 0 {
 		return fmt.Errorf("Check period for temperature controller must be greater than zero")
 	}
+	if _, ok := c.providers[tc.source()]; !ok {
+		return fmt.Errorf("unknown temperature sensor source: %s", tc.Source)
+	}
 	fn := func(id string) interface{} {
 		tc.ID = id
 		return tc
@@ -119,6 +125,9 @@ func (c *Controller) Update(id string, tc *TC) error {
 	if tc.Period <= 0 {
 		return fmt.Errorf("Period should be positive. Supplied:%d", tc.Period)
 	}
+	if _, ok := c.providers[tc.source()]; !ok {
+		return fmt.Errorf("unknown temperature sensor source: %s", tc.Source)
+	}
 	if err := c.c.Store().Update(Bucket, id, tc); err != nil {
 		return err
 	}
@@ -150,7 +159,7 @@ func (c *Controller) Delete(id string) error {
 
 	deleteCalibration := true
 	for _, t := range tcs {
-		if t.ID != tc.ID && t.Sensor == tc.Sensor {
+		if t.ID != tc.ID && t.calibrationKey() == tc.calibrationKey() {
 			deleteCalibration = false
 		}
 	}
@@ -159,8 +168,8 @@ func (c *Controller) Delete(id string) error {
 
 	if deleteCalibration {
 
-		c.c.Store().Delete(CalibrationBucket, tc.Sensor)
-		delete(c.calibrators, tc.Sensor)
+		c.c.Store().Delete(CalibrationBucket, tc.calibrationKey())
+		delete(c.calibrators, tc.calibrationKey())
 	}
 
 	if err := c.c.Store().Delete(Bucket, id); err != nil {
@@ -169,6 +178,7 @@ func (c *Controller) Delete(id string) error {
 	if err := c.c.Store().Delete(UsageBucket, id); err != nil {
 		log.Println("ERROR:  temperature sub-system: Failed to delete usage details for sensor:", id)
 	}
+	c.c.Telemetry().DeleteEntityMetrics(Bucket, id)
 
 	quit, ok := c.quitters[id]
 	if ok {
@@ -261,8 +271,8 @@ func (c *Controller) Calibrate(id string, ms []hal.Measurement) error {
 	c.Lock()
 	defer c.Unlock()
 
-	c.calibrators[tc.Sensor] = cal
-	return c.c.Store().Update(CalibrationBucket, tc.Sensor, ms)
+	c.calibrators[tc.calibrationKey()] = cal
+	return c.c.Store().Update(CalibrationBucket, tc.calibrationKey(), ms)
 }
 
 func (t *TC) WithinRange(v float64) bool {
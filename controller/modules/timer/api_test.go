@@ -133,6 +133,20 @@ func TestTimerController(t *testing.T) {
 	if err := j.Validate(); err == nil {
 		t.Error("Job validation should fail if equipment id is empty")
 	}
+	j.Target = []byte(`{"id":"1", "on":true, "speed":150}`)
+	if err := j.Validate(); err == nil {
+		t.Error("Job validation should fail if speed is above 100")
+	}
+	j.Target = []byte(`{"id":"1", "on":true, "speed":50}`)
+	if _, err := NewSubSystemRunner(j, con); err == nil {
+		t.Error("Speed trigger should fail for subsystems without speed control")
+	}
+	if err := (Trigger{ID: "1", On: true, Speed: 50}).apply(e); err == nil {
+		t.Error("Speed trigger should fail for equipment without a jack")
+	}
+	if err := (Trigger{ID: "1", On: true}).apply(e); err != nil {
+		t.Error(err)
+	}
 	j.Type = "invalid"
 	if err := j.Validate(); err == nil {
 		t.Error("Job validation should fail if job type is not valid")
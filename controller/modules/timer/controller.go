@@ -61,7 +61,9 @@ func (c *Controller) InUse(depType, id string) ([]string, error) {
 		storage.DoserBucket,
 		storage.LightingBucket,
 		storage.PhBucket,
-		storage.TemperatureBucket:
+		storage.TemperatureBucket,
+		storage.WavemakerBucket,
+		storage.FeedModeBucket:
 		ts, err := c.List()
 		if err != nil {
 			return deps, err
@@ -94,7 +96,7 @@ func (c *Controller) Runner(j Job) (cron.Job, error) {
 			title: "[reef-pi Reminder]" + reminder.Title,
 			body:  reminder.Message,
 		}, nil
-	case storage.MacroBucket, storage.EquipmentBucket, storage.ATOBucket, storage.CameraBucket, storage.DoserBucket, storage.LightingBucket, storage.PhBucket, storage.TemperatureBucket:
+	case storage.MacroBucket, storage.EquipmentBucket, storage.ATOBucket, storage.CameraBucket, storage.DoserBucket, storage.LightingBucket, storage.PhBucket, storage.TemperatureBucket, storage.WavemakerBucket, storage.FeedModeBucket:
 		return NewSubSystemRunner(j, c.c)
 	default:
 		return nil, fmt.Errorf("Failed to find suitable job runner")
@@ -53,7 +53,9 @@ func (j *Job) Validate() error {
 		storage.DoserBucket,
 		storage.LightingBucket,
 		storage.PhBucket,
-		storage.TemperatureBucket:
+		storage.TemperatureBucket,
+		storage.WavemakerBucket,
+		storage.FeedModeBucket:
 		var m Trigger
 		if err := json.Unmarshal(j.Target, &m); err != nil {
 			return err
@@ -61,6 +63,9 @@ func (j *Job) Validate() error {
 		if m.ID == "" {
 			return fmt.Errorf("Missing %s ID", j.Type)
 		}
+		if m.Speed < 0 || m.Speed > 100 {
+			return fmt.Errorf("Invalid speed %f, must be between 0 and 100", m.Speed)
+		}
 	default:
 		return fmt.Errorf("Invalid timer type: %s", j.Type)
 	}
@@ -16,6 +16,8 @@ type Trigger struct {
 	Revert   bool          `json:"revert"`
 	On       bool          `json:"on"`
 	Duration time.Duration `json:"duration"`
+	// Speed runs the target at a percentage instead of just switching it on, for subsystems with speed control
+	Speed float64 `json:"speed,omitempty"`
 }
 
 type SubSystemRunner struct {
@@ -33,6 +35,9 @@ func NewSubSystemRunner(j Job, c controller.Controller) (cron.Job, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load target subsyetm %s error: %w", j.Type, err)
 	}
+	if _, ok := sub.(controller.SpeedController); trigger.Speed > 0 && !ok {
+		return nil, fmt.Errorf("target subsystem %s does not support speed control", j.Type)
+	}
 	return &SubSystemRunner{
 		Type:    j.Type,
 		sub:     sub,
@@ -42,7 +47,7 @@ func NewSubSystemRunner(j Job, c controller.Controller) (cron.Job, error) {
 
 func (m *SubSystemRunner) Run() {
 	log.Println("timer subsystem. Executing module ", m.Type, "element", m.trigger.ID)
-	if err := m.sub.On(m.trigger.ID, m.trigger.On); err != nil {
+	if err := m.trigger.apply(m.sub); err != nil {
 		log.Println("ERROR:", m.Type, "sub-system, Failed to trigger. Error:", err)
 	}
 	if m.trigger.Revert {
@@ -55,3 +60,15 @@ func (m *SubSystemRunner) Run() {
 		}
 	}
 }
+
+// apply switches the target, or sets its speed when it is switched on with one
+func (t Trigger) apply(sub controller.Subsystem) error {
+	if !t.On || t.Speed == 0 {
+		return sub.On(t.ID, t.On)
+	}
+	sc, ok := sub.(controller.SpeedController)
+	if !ok {
+		return fmt.Errorf("subsystem does not support speed control")
+	}
+	return sc.SetSpeed(t.ID, t.Speed)
+}
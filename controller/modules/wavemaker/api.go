@@ -0,0 +1,189 @@
+package wavemaker
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func (c *Controller) LoadAPI(r *mux.Router) {
+
+	// pause routes are registered ahead of /api/wavemakers/{id} which would match them otherwise
+
+	// swagger:route GET /api/wavemakers/pause Wavemakers wavemakerPauseGet
+	// Get the pause status of wavemakers.
+	// Get whether wavemakers are paused, e.g. while feeding, and until when.
+	// responses:
+	// 	200: body:wavemakerPause
+	r.HandleFunc("/api/wavemakers/pause", c.getPause).Methods("GET")
+
+	// swagger:operation POST /api/wavemakers/pause Wavemakers wavemakerPause
+	// Pause wavemakers.
+	// Stop the pumps of all wavemakers for a duration, e.g. while feeding. Patterns resume afterwards.
+	// ---
+	// parameters:
+	//  - in: body
+	//    name: pause
+	//    description: The pause duration
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/wavemakerPause'
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/wavemakers/pause", c.pauseAll).Methods("POST")
+
+	// swagger:route DELETE /api/wavemakers/pause Wavemakers wavemakerResume
+	// Resume wavemakers.
+	// End a pause, resuming the patterns of all enabled wavemakers.
+	// responses:
+	// 	200:
+	r.HandleFunc("/api/wavemakers/pause", c.resume).Methods("DELETE")
+
+	// swagger:route GET /api/wavemakers Wavemakers wavemakersList
+	// List all wavemakers.
+	// List all wavemakers in reef-pi.
+	// responses:
+	// 	200: body:[]wavemaker
+	r.HandleFunc("/api/wavemakers", c.list).Methods("GET")
+
+	// swagger:operation PUT /api/wavemakers Wavemakers wavemakerCreate
+	// Create a wavemaker.
+	// Create a new wavemaker. Enabling it disables other wavemakers sharing its pumps.
+	// ---
+	// parameters:
+	//  - in: body
+	//    name: wavemaker
+	//    description: The wavemaker to create
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/wavemaker'
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/wavemakers", c.create).Methods("PUT")
+
+	// swagger:operation GET /api/wavemakers/{id} Wavemakers wavemakerGet
+	// Get a wavemaker by id.
+	// Get an existing wavemaker by id.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the wavemaker
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	//   schema:
+	//    $ref: '#/definitions/wavemaker'
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/wavemakers/{id}", c.get).Methods("GET")
+
+	// swagger:operation POST /api/wavemakers/{id} Wavemakers wavemakerUpdate
+	// Update a wavemaker.
+	// Update an existing wavemaker. Enabling it disables other wavemakers sharing its pumps.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the wavemaker to update
+	//    required: true
+	//    schema:
+	//     type: integer
+	//  - in: body
+	//    name: wavemaker
+	//    description: The wavemaker to update
+	//    required: true
+	//    schema:
+	//     $ref: '#/definitions/wavemaker'
+	// responses:
+	//  200:
+	//   description: OK
+	//  404:
+	//   description: Not Found
+	r.HandleFunc("/api/wavemakers/{id}", c.update).Methods("POST")
+
+	// swagger:operation DELETE /api/wavemakers/{id} Wavemakers wavemakerDelete
+	// Delete a wavemaker.
+	// Delete an existing wavemaker, turning its pumps off.
+	// ---
+	// parameters:
+	//  - in: path
+	//    name: id
+	//    description: The Id of the wavemaker to delete
+	//    required: true
+	//    schema:
+	//     type: integer
+	// responses:
+	//  200:
+	//   description: OK
+	r.HandleFunc("/api/wavemakers/{id}", c.delete).Methods("DELETE")
+}
+
+func (c *Controller) get(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) (interface{}, error) {
+		return c.Get(id)
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (c *Controller) list(w http.ResponseWriter, r *http.Request) {
+	fn := func() (interface{}, error) {
+		return c.List()
+	}
+	utils.JSONListResponse(fn, w, r)
+}
+
+func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
+	var wm Wavemaker
+	fn := func() error {
+		return c.Create(wm)
+	}
+	utils.JSONCreateResponse(&wm, fn, w, r)
+}
+
+func (c *Controller) update(w http.ResponseWriter, r *http.Request) {
+	var wm Wavemaker
+	fn := func(id string) error {
+		return c.Update(id, wm)
+	}
+	utils.JSONUpdateResponse(&wm, fn, w, r)
+}
+
+func (c *Controller) delete(w http.ResponseWriter, r *http.Request) {
+	fn := func(id string) error {
+		return c.Delete(id)
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
+
+func (c *Controller) getPause(w http.ResponseWriter, r *http.Request) {
+	fn := func(_ string) (interface{}, error) {
+		return c.PauseStatus(), nil
+	}
+	utils.JSONGetResponse(fn, w, r)
+}
+
+func (c *Controller) pauseAll(w http.ResponseWriter, r *http.Request) {
+	var p PauseStatus
+	fn := func(_ string) error {
+		c.Pause(time.Duration(p.Duration) * time.Second)
+		return nil
+	}
+	utils.JSONUpdateResponse(&p, fn, w, r)
+}
+
+func (c *Controller) resume(w http.ResponseWriter, r *http.Request) {
+	fn := func(_ string) error {
+		c.Resume()
+		return nil
+	}
+	utils.JSONDeleteResponse(fn, w, r)
+}
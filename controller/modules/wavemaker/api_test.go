@@ -0,0 +1,190 @@
+package wavemaker
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/device_manager/drivers"
+	"github.com/reef-pi/reef-pi/controller/storage"
+	"github.com/reef-pi/reef-pi/controller/utils"
+)
+
+func TestWavemakerAPI(t *testing.T) {
+	con, err := controller.TestController()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer con.Store().Close()
+	jacks := con.DM().Jacks()
+	if err := jacks.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := jacks.Create(connectors.Jack{Name: "Powerheads", Pins: []int{0, 1}, Driver: "rpi"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := con.DM().Drivers().Create(drivers.Driver{Name: "sim", Type: "virtual", Config: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	outlets := con.DM().Outlets()
+	if err := outlets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := outlets.Create(connectors.Outlet{Name: "Koralia", Pin: 0, Driver: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	c := New(con)
+	c.interval = 10 * time.Millisecond
+	if err := c.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	tr := utils.NewTestRouter()
+	c.LoadAPI(tr.Router)
+	c.Start()
+	defer c.Stop()
+
+	eventually := func(msg string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatal(msg)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	speeds := func(a, b float64) func() bool {
+		return func() bool {
+			v, _ := jacks.Values("1")
+			return v[0] == a && v[1] == b
+		}
+	}
+
+	pumps := []Pump{{Jack: "1", Pin: 0}, {Jack: "1", Pin: 1}}
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(Wavemaker{Name: "bad", Pumps: []Pump{{Jack: "1", Pin: 7}}, Pattern: Pattern{Mode: Constant, Max: 50}})
+	if err := tr.Do("PUT", "/api/wavemakers", body, nil); err == nil {
+		t.Error("expected unknown jack pin to be rejected")
+	}
+	body.Reset()
+	json.NewEncoder(body).Encode(Wavemaker{Name: "bad", Pumps: []Pump{{Jack: "1", Outlet: "1"}}, Pattern: Pattern{Mode: Constant, Max: 50}})
+	if err := tr.Do("PUT", "/api/wavemakers", body, nil); err == nil {
+		t.Error("expected pump with a jack and an outlet to be rejected")
+	}
+	body.Reset()
+	gyre := Wavemaker{Name: "Day gyre", Enable: true, Pumps: pumps, Pattern: Pattern{Mode: Gyre, Min: 10, Max: 90, Period: 3600}}
+	json.NewEncoder(body).Encode(gyre)
+	if err := tr.Do("PUT", "/api/wavemakers", body, nil); err != nil {
+		t.Fatal("Failed to create wavemaker using api", err)
+	}
+	eventually("expected gyre to drive pumps in anti-phase", speeds(90, 10))
+
+	night := Wavemaker{Name: "Night export", Pumps: append(pumps, Pump{Outlet: "1"}), Pattern: Pattern{Mode: Export, Min: 20, Max: 100, Period: 3600, Burst: 1800}}
+	if err := c.Create(night); err != nil {
+		t.Fatal(err)
+	}
+	if deps, err := c.InUse(storage.JackBucket, "1"); err != nil || len(deps) != 4 {
+		t.Error("expected jack to be reported in use", deps, err)
+	}
+	if deps, err := c.InUse(storage.OutletBucket, "1"); err != nil || len(deps) != 1 || deps[0] != "Night export" {
+		t.Error("expected outlet to be reported in use", deps, err)
+	}
+	if err := c.On("2", true); err != nil {
+		t.Fatal(err)
+	}
+	eventually("expected export burst on all pumps", speeds(100, 100))
+	eventually("expected outlet pump to run during the burst", func() bool {
+		on, _ := outlets.Observe("1")
+		return on
+	})
+	if w, _ := c.Get("1"); w.Enable {
+		t.Error("expected gyre to be disabled by a wavemaker sharing its pumps")
+	}
+	e, err := c.GetEntity("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := e.Status(); s.(WavemakerStatus).Mode != Export || !s.(WavemakerStatus).Enable {
+		t.Error("unexpected status", s)
+	}
+
+	body.Reset()
+	json.NewEncoder(body).Encode(PauseStatus{Duration: 60})
+	if err := tr.Do("POST", "/api/wavemakers/pause", body, nil); err != nil {
+		t.Fatal("Failed to pause wavemakers using api", err)
+	}
+	eventually("expected pumps to stop while paused", speeds(0, 0))
+	var p PauseStatus
+	if err := tr.Do("GET", "/api/wavemakers/pause", strings.NewReader("{}"), &p); err != nil || !p.Paused || p.Until.IsZero() {
+		t.Error("expected pause status to be reported", p, err)
+	}
+	if err := tr.Do("DELETE", "/api/wavemakers/pause", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to resume wavemakers using api", err)
+	}
+	eventually("expected pumps to resume", speeds(100, 100))
+	c.Pause(20 * time.Millisecond)
+	eventually("expected timed pause to end by itself", func() bool { return !c.Paused() })
+	if err := c.PauseEntity("2", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	eventually("expected paused wavemaker to stop its pumps", speeds(0, 0))
+	if err := c.ResumeEntity("2"); err != nil {
+		t.Fatal(err)
+	}
+	eventually("expected wavemaker to resume", speeds(100, 100))
+
+	if err := c.On("2", false); err != nil {
+		t.Fatal(err)
+	}
+	eventually("expected disabled wavemaker to stop its pumps", speeds(0, 0))
+	eventually("expected outlet pump to be off", func() bool {
+		on, err := outlets.Observe("1")
+		return err == nil && !on
+	})
+	var ws []Wavemaker
+	if err := tr.Do("GET", "/api/wavemakers", strings.NewReader("{}"), &ws); err != nil || len(ws) != 2 {
+		t.Error("Failed to list wavemakers using api", ws, err)
+	}
+	if err := tr.Do("GET", "/api/wavemakers/1", strings.NewReader("{}"), nil); err != nil {
+		t.Error("Failed to get wavemaker using api", err)
+	}
+	gyre.Enable = true
+	body.Reset()
+	json.NewEncoder(body).Encode(gyre)
+	if err := tr.Do("POST", "/api/wavemakers/1", body, nil); err != nil {
+		t.Fatal("Failed to update wavemaker using api", err)
+	}
+	eventually("expected gyre to run again", speeds(90, 10))
+	if err := tr.Do("DELETE", "/api/wavemakers/1", strings.NewReader("{}"), nil); err != nil {
+		t.Fatal("Failed to delete wavemaker using api", err)
+	}
+	eventually("expected deleted wavemaker to stop its pumps", speeds(0, 0))
+
+	for _, b := range []string{storage.EquipmentBucket, storage.DoserBucket} {
+		if err := con.Store().CreateBucket(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	skimmer := func(id string) interface{} {
+		return map[string]string{"id": id, "name": "Skimmer", "outlet": "1"}
+	}
+	if err := con.Store().Create(storage.EquipmentBucket, skimmer); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update("2", night); err == nil {
+		t.Error("expected outlet switched by an equipment to be rejected")
+	}
+	doser := func(id string) interface{} {
+		return map[string]interface{}{"id": id, "name": "Alk", "jack": "1", "pin": 0}
+	}
+	if err := con.Store().Create(storage.DoserBucket, doser); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Create(Wavemaker{Name: "bad", Pumps: []Pump{{Jack: "1", Pin: 0}}, Pattern: Pattern{Mode: Constant, Max: 50}}); err == nil {
+		t.Error("expected jack pin driven by a doser to be rejected")
+	}
+}
@@ -0,0 +1,197 @@
+package wavemaker
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller"
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+const Bucket = storage.WavemakerBucket
+
+// interval between pump speed updates
+const tickInterval = time.Second
+
+type Controller struct {
+	sync.Mutex
+	c        controller.Controller
+	jacks    *connectors.Jacks
+	outlets  *connectors.Outlets
+	pins     *connectors.PinRegistry
+	quitters map[string]*loop
+	interval time.Duration
+	// pause is held separately as pattern loops read it while the lock is held to stop them
+	pause struct {
+		sync.Mutex
+		on    bool
+		until time.Time
+		// entities holds wavemakers paused individually, with the time they resume
+		entities map[string]time.Time
+	}
+}
+
+type loop struct {
+	quit chan struct{}
+	done chan struct{}
+}
+
+func New(c controller.Controller) *Controller {
+	ctrl := &Controller{
+		c:        c,
+		jacks:    c.DM().Jacks(),
+		outlets:  c.DM().Outlets(),
+		pins:     c.DM().Pins(),
+		quitters: make(map[string]*loop),
+		interval: tickInterval,
+	}
+	ctrl.pause.entities = make(map[string]time.Time)
+	return ctrl
+}
+
+func (c *Controller) Setup() error {
+	return c.c.Store().CreateBucket(Bucket)
+}
+
+func (c *Controller) Start() {
+	ws, err := c.List()
+	if err != nil {
+		log.Println("ERROR: wavemaker subsystem: failed to list wavemakers. Error:", err)
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	for _, w := range ws {
+		if w.Enable {
+			c.start(w)
+		}
+	}
+}
+
+func (c *Controller) Stop() {
+	c.Lock()
+	defer c.Unlock()
+	for id := range c.quitters {
+		c.halt(id)
+	}
+	log.Println("Stopped wavemaker subsystem")
+}
+
+// On enables or disables a wavemaker, used by timers and macros to switch modes
+func (c *Controller) On(id string, on bool) error {
+	w, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	log.Println("wavemaker subsystem:", w.Name, "enable:", on)
+	w.Enable = on
+	return c.Update(id, w)
+}
+
+func (c *Controller) InUse(depType, id string) ([]string, error) {
+	var deps []string
+	ws, err := c.List()
+	if err != nil {
+		return deps, err
+	}
+	for _, w := range ws {
+		for _, p := range w.Pumps {
+			switch depType {
+			case storage.JackBucket:
+				if p.Jack == id {
+					deps = append(deps, w.Name)
+				}
+			case storage.OutletBucket:
+				if p.Outlet == id {
+					deps = append(deps, w.Name)
+				}
+			default:
+				return deps, fmt.Errorf("unknown dep type:%s", depType)
+			}
+		}
+	}
+	return deps, nil
+}
+
+func (c *Controller) GetEntity(id string) (controller.Entity, error) {
+	return c.Get(id)
+}
+
+// start runs the pattern of a wavemaker, the caller must hold the lock
+func (c *Controller) start(w Wavemaker) {
+	c.halt(w.ID)
+	l := &loop{quit: make(chan struct{}), done: make(chan struct{})}
+	c.quitters[w.ID] = l
+	go c.run(w, l)
+}
+
+// halt stops the pattern loop of a wavemaker and waits for it to exit, leaving its pumps as
+// they are. The caller must hold the lock
+func (c *Controller) halt(id string) {
+	l, ok := c.quitters[id]
+	if !ok {
+		return
+	}
+	close(l.quit)
+	<-l.done
+	delete(c.quitters, id)
+}
+
+// stop halts a wavemaker and turns its pumps off, the caller must hold the lock
+func (c *Controller) stop(w Wavemaker) {
+	c.halt(w.ID)
+	for _, p := range w.Pumps {
+		if err := c.drive(w.Pattern, p, 0); err != nil {
+			log.Println("ERROR: wavemaker subsystem: failed to stop pump of", w.Name, ". Error:", err)
+		}
+	}
+}
+
+func (c *Controller) run(w Wavemaker, l *loop) {
+	defer close(l.done)
+	started := time.Now()
+	seed := started.UnixNano()
+	last := make([]float64, len(w.Pumps))
+	for i := range last {
+		last[i] = -1
+	}
+	tick := func() {
+		vs := w.Pattern.speeds(len(w.Pumps), time.Since(started), seed)
+		paused := c.Paused() || c.entityPaused(w.ID)
+		for i, p := range w.Pumps {
+			if paused {
+				vs[i] = 0
+			}
+			if vs[i] == last[i] {
+				continue
+			}
+			if err := c.drive(w.Pattern, p, vs[i]); err != nil {
+				log.Println("ERROR: wavemaker subsystem: failed to drive pump of", w.Name, ". Error:", err)
+				continue
+			}
+			last[i] = vs[i]
+		}
+	}
+	tick()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			tick()
+		case <-l.quit:
+			return
+		}
+	}
+}
+
+// drive sets the speed of a jack pump, or switches an outlet pump
+func (c *Controller) drive(pt Pattern, p Pump, v float64) error {
+	if p.Outlet != "" {
+		return c.outlets.Configure(p.Outlet, pt.on(v))
+	}
+	return c.jacks.Control(p.Jack, connectors.PinValues{p.Pin: v})
+}
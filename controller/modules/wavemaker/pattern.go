@@ -0,0 +1,106 @@
+package wavemaker
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	// Constant runs all pumps at the maximum speed
+	Constant = "constant"
+	// Pulse switches all pumps between the maximum and minimum speed every half period
+	Pulse = "pulse"
+	// Random picks a new speed between minimum and maximum for each pump every period
+	Random = "random"
+	// Gyre alternates pairs of pumps in anti-phase, every half period
+	Gyre = "gyre"
+	// Tidal ramps pumps smoothly between minimum and maximum over a period, pairs in anti-phase
+	Tidal = "tidal"
+	// Export runs pumps at the minimum speed with a burst at the maximum speed at the start of
+	// each period, lifting detritus into the water column for nutrient export at night
+	Export = "export"
+)
+
+// Pattern is the flow pattern of a wavemaker. Speeds are in percent
+//
+// swagger:model wavemakerPattern
+type Pattern struct {
+	Mode string  `json:"mode"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	// Period is the length of one pattern cycle in seconds, unused in constant mode
+	Period int `json:"period"`
+	// Burst is the number of seconds pumps run at the maximum speed in export mode
+	Burst int `json:"burst"`
+}
+
+func (p Pattern) IsValid() error {
+	if p.Min < 0 || p.Max > 100 || p.Min > p.Max {
+		return fmt.Errorf("invalid speed range %f-%f, must be within 0 and 100", p.Min, p.Max)
+	}
+	switch p.Mode {
+	case Constant:
+		return nil
+	case Pulse, Random, Gyre, Tidal:
+	case Export:
+		if p.Burst <= 0 || p.Burst >= p.Period {
+			return fmt.Errorf("export burst must be positive and shorter than the period")
+		}
+	default:
+		return fmt.Errorf("unknown wavemaker mode: '%s'", p.Mode)
+	}
+	if p.Period <= 0 {
+		return fmt.Errorf("%s mode requires a positive period", p.Mode)
+	}
+	return nil
+}
+
+// speeds returns the speed of n pumps, elapsed since the pattern started. Random speeds are
+// derived from the seed and the current cycle, so they only change once per period
+func (p Pattern) speeds(n int, elapsed time.Duration, seed int64) []float64 {
+	vs := make([]float64, n)
+	if p.Mode == Constant {
+		for i := range vs {
+			vs[i] = p.Max
+		}
+		return vs
+	}
+	period := time.Duration(p.Period) * time.Second
+	cycle := int64(elapsed / period)
+	phase := float64(elapsed%period) / float64(period)
+	r := rand.New(rand.NewSource(seed + cycle))
+	for i := range vs {
+		switch p.Mode {
+		case Pulse:
+			vs[i] = p.level(phase < 0.5)
+		case Random:
+			vs[i] = p.Min + r.Float64()*(p.Max-p.Min)
+		case Gyre:
+			vs[i] = p.level((phase < 0.5) == (i%2 == 0))
+		case Tidal:
+			v := (1 - math.Cos(2*math.Pi*phase)) / 2
+			if i%2 == 1 {
+				v = 1 - v
+			}
+			vs[i] = p.Min + v*(p.Max-p.Min)
+		case Export:
+			vs[i] = p.level(elapsed%period < time.Duration(p.Burst)*time.Second)
+		}
+		vs[i] = math.Round(vs[i])
+	}
+	return vs
+}
+
+func (p Pattern) level(high bool) float64 {
+	if high {
+		return p.Max
+	}
+	return p.Min
+}
+
+// on reports whether an outlet pump runs at a speed, i.e. when the pattern is in its upper half
+func (p Pattern) on(v float64) bool {
+	return v > 0 && v >= (p.Min+p.Max)/2
+}
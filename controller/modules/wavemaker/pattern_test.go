@@ -0,0 +1,71 @@
+package wavemaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPattern(t *testing.T) {
+	for _, p := range []Pattern{
+		{Mode: "storm", Period: 10},
+		{Mode: Pulse, Max: 100},
+		{Mode: Constant, Min: 60, Max: 40},
+		{Mode: Constant, Max: 120},
+		{Mode: Export, Max: 100, Period: 10, Burst: 10},
+	} {
+		if err := p.IsValid(); err == nil {
+			t.Error("expected invalid pattern to be rejected:", p)
+		}
+	}
+
+	at := func(p Pattern, n int, s float64) []float64 {
+		if err := p.IsValid(); err != nil {
+			t.Fatal(err)
+		}
+		return p.speeds(n, time.Duration(s*float64(time.Second)), 42)
+	}
+	if vs := at(Pattern{Mode: Constant, Max: 70}, 2, 5); vs[0] != 70 || vs[1] != 70 {
+		t.Error("unexpected constant speeds", vs)
+	}
+	pulse := Pattern{Mode: Pulse, Min: 20, Max: 80, Period: 10}
+	if vs := at(pulse, 1, 2); vs[0] != 80 {
+		t.Error("expected pulse to start high", vs)
+	}
+	if vs := at(pulse, 1, 7); vs[0] != 20 {
+		t.Error("expected pulse to drop in the second half", vs)
+	}
+	gyre := Pattern{Mode: Gyre, Min: 0, Max: 100, Period: 60}
+	if vs := at(gyre, 4, 10); vs[0] != 100 || vs[1] != 0 || vs[2] != 100 || vs[3] != 0 {
+		t.Error("expected pairs in anti-phase", vs)
+	}
+	if vs := at(gyre, 2, 40); vs[0] != 0 || vs[1] != 100 {
+		t.Error("expected pairs to alternate", vs)
+	}
+	tidal := Pattern{Mode: Tidal, Min: 20, Max: 80, Period: 3600}
+	if vs := at(tidal, 2, 0); vs[0] != 20 || vs[1] != 80 {
+		t.Error("expected tide to start low", vs)
+	}
+	if vs := at(tidal, 2, 900); vs[0] != 50 || vs[1] != 50 {
+		t.Error("expected tide to ramp", vs)
+	}
+	if vs := at(tidal, 2, 1800); vs[0] != 80 || vs[1] != 20 {
+		t.Error("expected tide to turn", vs)
+	}
+	export := Pattern{Mode: Export, Min: 10, Max: 100, Period: 600, Burst: 30}
+	if vs := at(export, 1, 605); vs[0] != 100 {
+		t.Error("expected export burst at the start of a period", vs)
+	}
+	if vs := at(export, 1, 700); vs[0] != 10 {
+		t.Error("expected export to run at the minimum speed", vs)
+	}
+	random := Pattern{Mode: Random, Min: 30, Max: 70, Period: 60}
+	a, b := at(random, 3, 1), at(random, 3, 59)
+	for i := range a {
+		if a[i] != b[i] || a[i] < 30 || a[i] > 70 {
+			t.Error("expected random speeds within range to hold for a period", a, b)
+		}
+	}
+	if !pulse.on(80) || pulse.on(20) || (Pattern{Mode: Constant}).on(0) {
+		t.Error("unexpected outlet state")
+	}
+}
@@ -0,0 +1,88 @@
+package wavemaker
+
+import (
+	"log"
+	"time"
+)
+
+// PauseStatus reports whether wavemakers are paused, e.g. while feeding
+//
+// swagger:model wavemakerPause
+type PauseStatus struct {
+	Paused bool `json:"paused"`
+	// Duration is the number of seconds to pause for, 0 pauses until resumed
+	Duration int `json:"duration"`
+	// Until is when wavemakers resume, zero if they are paused until resumed
+	Until time.Time `json:"until"`
+}
+
+// Pause stops the pumps of all wavemakers, resuming their patterns after d. A zero duration
+// pauses until Resume is called
+func (c *Controller) Pause(d time.Duration) {
+	c.pause.Lock()
+	defer c.pause.Unlock()
+	c.pause.on = true
+	c.pause.until = time.Time{}
+	if d > 0 {
+		c.pause.until = time.Now().Add(d)
+	}
+	log.Println("wavemaker subsystem: paused for", d)
+}
+
+func (c *Controller) Resume() {
+	c.pause.Lock()
+	defer c.pause.Unlock()
+	c.pause.on = false
+	log.Println("wavemaker subsystem: resumed")
+}
+
+func (c *Controller) Paused() bool {
+	return c.PauseStatus().Paused
+}
+
+func (c *Controller) PauseStatus() PauseStatus {
+	c.pause.Lock()
+	defer c.pause.Unlock()
+	if c.pause.on && !c.pause.until.IsZero() && time.Now().After(c.pause.until) {
+		c.pause.on = false
+	}
+	if !c.pause.on {
+		return PauseStatus{}
+	}
+	return PauseStatus{Paused: true, Until: c.pause.until}
+}
+
+// PauseEntity stops the pumps of a single wavemaker for d, e.g. while feeding
+func (c *Controller) PauseEntity(id string, d time.Duration) error {
+	w, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	c.pause.Lock()
+	defer c.pause.Unlock()
+	c.pause.entities[id] = time.Now().Add(d)
+	log.Println("wavemaker subsystem:", w.Name, "paused for", d)
+	return nil
+}
+
+// ResumeEntity ends the pause of a single wavemaker early
+func (c *Controller) ResumeEntity(id string) error {
+	c.pause.Lock()
+	defer c.pause.Unlock()
+	delete(c.pause.entities, id)
+	return nil
+}
+
+func (c *Controller) entityPaused(id string) bool {
+	c.pause.Lock()
+	defer c.pause.Unlock()
+	until, ok := c.pause.entities[id]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(c.pause.entities, id)
+		return false
+	}
+	return true
+}
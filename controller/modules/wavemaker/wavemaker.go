@@ -0,0 +1,203 @@
+package wavemaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/reef-pi/reef-pi/controller/device_manager/connectors"
+)
+
+// Pump is a powerhead driven by a jack pin at variable speed, or switched by an outlet
+//
+// swagger:model wavemakerPump
+type Pump struct {
+	Jack   string `json:"jack,omitempty"`
+	Pin    int    `json:"pin"`
+	Outlet string `json:"outlet,omitempty"`
+}
+
+func (p Pump) key() string {
+	if p.Outlet != "" {
+		return "outlet-" + p.Outlet
+	}
+	return fmt.Sprintf("jack-%s-%d", p.Jack, p.Pin)
+}
+
+// Wavemaker runs a set of pumps in a flow pattern. Wavemakers sharing pumps are exclusive,
+// enabling one disables the others. Timers and macros switch modes by enabling wavemakers
+//
+// swagger:model wavemaker
+type Wavemaker struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Enable  bool    `json:"enable"`
+	Pumps   []Pump  `json:"pumps"`
+	Pattern Pattern `json:"pattern"`
+}
+
+func (w Wavemaker) EName() string { return w.Name }
+
+func (w Wavemaker) Status() (interface{}, error) {
+	return WavemakerStatus{Enable: w.Enable, Mode: w.Pattern.Mode}, nil
+}
+
+// swagger:model wavemakerStatus
+type WavemakerStatus struct {
+	Enable bool   `json:"enable"`
+	Mode   string `json:"mode"`
+}
+
+func (w Wavemaker) shares(o Wavemaker) bool {
+	for _, p := range w.Pumps {
+		for _, q := range o.Pumps {
+			if p.key() == q.key() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Controller) validate(w Wavemaker) error {
+	if w.Name == "" {
+		return fmt.Errorf("wavemaker name can not be empty")
+	}
+	if len(w.Pumps) == 0 {
+		return fmt.Errorf("wavemaker requires at least one pump")
+	}
+	if err := w.Pattern.IsValid(); err != nil {
+		return err
+	}
+	owner := connectors.PinOwner{Type: connectors.WavemakerPin, ID: w.ID, Name: w.Name}
+	seen := make(map[string]bool)
+	for _, p := range w.Pumps {
+		if seen[p.key()] {
+			return fmt.Errorf("pump %s is listed more than once", p.key())
+		}
+		seen[p.key()] = true
+		if err := c.validatePump(owner, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Controller) validatePump(owner connectors.PinOwner, p Pump) error {
+	if (p.Jack == "") == (p.Outlet == "") {
+		return fmt.Errorf("a pump is driven by either a jack or an outlet")
+	}
+	if p.Outlet != "" {
+		if _, err := c.outlets.Get(p.Outlet); err != nil {
+			return fmt.Errorf("outlet '%s' does not exist. Error: %w", p.Outlet, err)
+		}
+		return c.pins.CheckOutlet(owner, p.Outlet)
+	}
+	j, err := c.jacks.Get(p.Jack)
+	if err != nil {
+		return fmt.Errorf("jack '%s' does not exist. Error: %w", p.Jack, err)
+	}
+	for _, pin := range j.Pins {
+		if pin == p.Pin {
+			return c.pins.CheckJack(owner, p.Jack, p.Pin)
+		}
+	}
+	return fmt.Errorf("jack '%s' does not have pin %d", j.Name, p.Pin)
+}
+
+func (c *Controller) Get(id string) (Wavemaker, error) {
+	var w Wavemaker
+	return w, c.c.Store().Get(Bucket, id, &w)
+}
+
+func (c *Controller) List() ([]Wavemaker, error) {
+	ws := []Wavemaker{}
+	fn := func(_ string, v []byte) error {
+		var w Wavemaker
+		if err := json.Unmarshal(v, &w); err != nil {
+			return err
+		}
+		ws = append(ws, w)
+		return nil
+	}
+	return ws, c.c.Store().List(Bucket, fn)
+}
+
+func (c *Controller) Create(w Wavemaker) error {
+	w.ID = ""
+	if err := c.validate(w); err != nil {
+		return err
+	}
+	c.Lock()
+	defer c.Unlock()
+	fn := func(id string) interface{} {
+		w.ID = id
+		return &w
+	}
+	if err := c.c.Store().Create(Bucket, fn); err != nil {
+		return err
+	}
+	return c.apply(w)
+}
+
+func (c *Controller) Update(id string, w Wavemaker) error {
+	w.ID = id
+	if err := c.validate(w); err != nil {
+		return err
+	}
+	c.Lock()
+	defer c.Unlock()
+	old, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if old.Enable {
+		c.stop(old)
+	}
+	if err := c.c.Store().Update(Bucket, id, w); err != nil {
+		return err
+	}
+	return c.apply(w)
+}
+
+func (c *Controller) Delete(id string) error {
+	c.Lock()
+	defer c.Unlock()
+	w, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := c.c.Store().Delete(Bucket, id); err != nil {
+		return err
+	}
+	if w.Enable {
+		c.stop(w)
+	}
+	return nil
+}
+
+// apply (re)starts the pattern of an enabled wavemaker, disabling others sharing its pumps,
+// or stops its pumps. The caller must hold the lock
+func (c *Controller) apply(w Wavemaker) error {
+	if !w.Enable {
+		c.stop(w)
+		return nil
+	}
+	ws, err := c.List()
+	if err != nil {
+		return err
+	}
+	for _, o := range ws {
+		if o.ID == w.ID || !o.Enable || !o.shares(w) {
+			continue
+		}
+		log.Println("wavemaker subsystem: disabling", o.Name, "in favor of", w.Name)
+		o.Enable = false
+		if err := c.c.Store().Update(Bucket, o.ID, o); err != nil {
+			return err
+		}
+		c.stop(o)
+	}
+	c.start(w)
+	return nil
+}
@@ -17,6 +17,9 @@ type Capabilities struct {
 	Configuration bool `json:"configuration"`
 	Journal       bool `json:"journal"`
 	AutoTester    bool `json:"autotester"`
+	Wavemaker     bool `json:"wavemaker"`
+	FeedMode      bool `json:"feed_mode"`
+	Safety        bool `json:"safety"`
 }
 
 var DefaultCapabilities = Capabilities{
@@ -1,7 +1,32 @@
 package settings
 
 type HealthCheckNotify struct {
+	Enable    bool             `json:"enable"`
+	MaxMemory float64          `json:"max_memory"`
+	MaxCPU    float64          `json:"max_cpu"`
+	Disk      HealthCheckLimit `json:"disk"`       // minimum free space (MB) on database and image directories
+	DBSize    HealthCheckLimit `json:"db_size"`    // maximum database file size (MB)
+	DBGrowth  HealthCheckLimit `json:"db_growth"`  // maximum database growth (MB per day)
+	ReadOnly  HealthCheckLimit `json:"read_only"`  // database file system remounted read only
+	ClockSync HealthCheckLimit `json:"clock_sync"` // system clock not synchronized
+	Drivers   HealthCheckLimit `json:"drivers"`    // driver response timeout (seconds)
+}
+
+// HealthCheckLimit configures an individual health check. Notify has no effect unless health
+// notifications are enabled
+type HealthCheckLimit struct {
 	Enable    bool    `json:"enable"`
-	MaxMemory float64 `json:"max_memory"`
-	MaxCPU    float64 `json:"max_cpu"`
+	Notify    bool    `json:"notify"`
+	Threshold float64 `json:"threshold"`
+}
+
+var DefaultHealthCheck = HealthCheckNotify{
+	MaxMemory: 500,
+	MaxCPU:    2,
+	Disk:      HealthCheckLimit{Enable: true, Notify: true, Threshold: 100},
+	DBSize:    HealthCheckLimit{Enable: true, Notify: true, Threshold: 512},
+	DBGrowth:  HealthCheckLimit{Enable: true, Notify: true, Threshold: 50},
+	ReadOnly:  HealthCheckLimit{Enable: true, Notify: true},
+	ClockSync: HealthCheckLimit{Enable: true},
+	Drivers:   HealthCheckLimit{Enable: true, Notify: true, Threshold: 5},
 }
@@ -22,8 +22,5 @@ var DefaultSettings = Settings{
 	Address:      "0.0.0.0:80",
 	Capabilities: DefaultCapabilities,
 	RPI_PWMFreq:  100,
-	HealthCheck: HealthCheckNotify{
-		MaxMemory: 500,
-		MaxCPU:    2,
-	},
+	HealthCheck:  DefaultHealthCheck,
 }
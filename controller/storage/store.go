@@ -17,6 +17,11 @@ const (
 	DoserBucket                  = "doser"
 	DoserUsageBucket             = "doser_usage"
 	EquipmentBucket              = "equipment"
+	EquipmentUsageBucket         = "equipment_usage"
+	EquipmentRuntimeBucket       = "equipment_runtime"
+	MaintenanceBucket            = "equipment_maintenance"
+	EquipmentGroupBucket         = "equipment_groups"
+	FeedModeBucket               = "feed_modes"
 	LightingBucket               = "lightings"
 	LightingUsageBucket          = "lightings_usage"
 	MacroBucket                  = "macro"
@@ -28,6 +33,8 @@ const (
 	TemperatureCalibrationBucket = "temperature_calibration"
 	TemperatureUsageBucket       = "temperature_usage"
 	TimerBucket                  = "timers"
+	WavemakerBucket              = "wavemakers"
+	SafetyBucket                 = "safety"
 	ErrorBucket                  = "errors"
 	DriverBucket                 = "drivers"
 	JournalBucket                = "journal"
@@ -0,0 +1,281 @@
+package telemetry
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+const (
+	ExportCSV   = "csv"
+	ExportJSONL = "jsonl"
+)
+
+type exportSource struct {
+	entities string
+	usage    string
+}
+
+// exportModules maps an exportable module to the bucket holding its entities and the bucket holding its readings/usage
+var exportModules = map[string]exportSource{
+	"ato":         {entities: storage.ATOBucket, usage: storage.ATOUsageBucket},
+	"doser":       {entities: storage.DoserBucket, usage: storage.DoserUsageBucket},
+	"equipment":   {entities: storage.EquipmentBucket, usage: storage.EquipmentUsageBucket},
+	"health":      {usage: storage.ReefPiBucket},
+	"journal":     {entities: storage.JournalBucket, usage: storage.JournalUsageBucket},
+	"lighting":    {entities: storage.LightingBucket, usage: storage.LightingUsageBucket},
+	"ph":          {entities: storage.PhBucket, usage: storage.PhReadingsBucket},
+	"temperature": {entities: storage.TemperatureBucket, usage: storage.TemperatureUsageBucket},
+}
+
+func ExportModuleNames() []string {
+	var names []string
+	for m := range exportModules {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type ExportQuery struct {
+	Module string
+	IDs    []string
+	From   time.Time
+	To     time.Time
+	Series string // current, historical or empty for both
+}
+
+type ExportRecord struct {
+	Module string                 `json:"module"`
+	ID     string                 `json:"id"`
+	Name   string                 `json:"name"`
+	Series string                 `json:"series"`
+	Time   time.Time              `json:"time"`
+	Values map[string]interface{} `json:"values"`
+}
+
+type RecordWriter interface {
+	Write(ExportRecord) error
+	Flush() error
+}
+
+// ParseExportTime parses time range boundaries, accepting RFC3339 or plain dates
+func ParseExportTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+func NewRecordWriter(format string, w io.Writer) (RecordWriter, error) {
+	switch format {
+	case "", ExportCSV:
+		cw := csv.NewWriter(w)
+		return &csvWriter{w: cw}, cw.Write([]string{"module", "id", "name", "series", "time", "field", "value"})
+	case ExportJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format:%s", format)
+	}
+}
+
+// ValidateExport returns an error if a module does not support export or the format is unknown,
+// so that requests can be rejected before any output is written
+func ValidateExport(q ExportQuery, format string) error {
+	if _, ok := exportModules[q.Module]; !ok {
+		return fmt.Errorf("module '%s' does not support export", q.Module)
+	}
+	switch format {
+	case "", ExportCSV, ExportJSONL:
+		return nil
+	default:
+		return fmt.Errorf("unsupported export format:%s", format)
+	}
+}
+
+// SaveExportStats saves the readings of a module still held in memory by its stats managers, so
+// that exporting from a running reef-pi includes them
+func SaveExportStats(t Telemetry, module string) error {
+	src, ok := exportModules[module]
+	if !ok {
+		return fmt.Errorf("module '%s' does not support export", module)
+	}
+	return t.SaveStats(src.usage)
+}
+
+// Export streams stored readings of a module for the queried entities and time range
+func Export(store storage.Store, q ExportQuery, w RecordWriter) error {
+	src, ok := exportModules[q.Module]
+	if !ok {
+		return fmt.Errorf("module '%s' does not support export", q.Module)
+	}
+	names, err := entityNames(store, src, q)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(names))
+	for id := range names {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		var stats StatsOnDisk
+		if err := store.Get(src.usage, id, &stats); err != nil {
+			continue
+		}
+		if q.Series == "" || q.Series == "historical" {
+			if err := exportSeries(q, id, names[id], "historical", stats.Historical, w); err != nil {
+				return err
+			}
+		}
+		if q.Series == "" || q.Series == "current" {
+			if err := exportSeries(q, id, names[id], "current", stats.Current, w); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+func entityNames(store storage.Store, src exportSource, q ExportQuery) (map[string]string, error) {
+	names := make(map[string]string)
+	if src.entities == "" {
+		names[HealthStatsKey] = "health"
+		return names, nil
+	}
+	want := make(map[string]bool)
+	for _, id := range q.IDs {
+		want[id] = true
+	}
+	fn := func(id string, v []byte) error {
+		if len(want) > 0 && !want[id] {
+			return nil
+		}
+		var e struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(v, &e); err != nil {
+			return err
+		}
+		names[id] = e.Name
+		return nil
+	}
+	return names, store.List(src.entities, fn)
+}
+
+func exportSeries(q ExportQuery, id, name, series string, data []json.RawMessage, w RecordWriter) error {
+	for _, d := range data {
+		values := make(map[string]interface{})
+		if err := json.Unmarshal(d, &values); err != nil {
+			return err
+		}
+		t, err := recordTime(values)
+		if err != nil {
+			return err
+		}
+		if !q.From.IsZero() && t.Before(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && t.After(q.To) {
+			continue
+		}
+		r := ExportRecord{
+			Module: q.Module,
+			ID:     id,
+			Name:   name,
+			Series: series,
+			Time:   t,
+			Values: values,
+		}
+		if err := w.Write(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readings use "time", journal entries use "timestamp". Both are stored as TeleTime in local time zone
+func recordTime(values map[string]interface{}) (time.Time, error) {
+	for _, k := range []string{"time", "timestamp"} {
+		v, ok := values[k]
+		if !ok {
+			continue
+		}
+		delete(values, k)
+		s, ok := v.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid time value:%v", v)
+		}
+		return time.ParseInLocation(format, s, time.Local)
+	}
+	return time.Time{}, fmt.Errorf("record does not have a time field")
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Write(r ExportRecord) error {
+	fields := make([]string, 0, len(r.Values))
+	for k := range r.Values {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	for _, f := range fields {
+		for _, kv := range flatten(f, r.Values[f]) {
+			row := []string{r.Module, r.ID, r.Name, r.Series, r.Time.Format(time.RFC3339), kv[0], kv[1]}
+			if err := c.w.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// nested values (e.g. lighting channels) are exported as dot separated fields
+func flatten(prefix string, v interface{}) [][2]string {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var res [][2]string
+		for _, k := range keys {
+			res = append(res, flatten(prefix+"."+k, val[k])...)
+		}
+		return res
+	case float64:
+		return [][2]string{{prefix, strconv.FormatFloat(val, 'f', -1, 64)}}
+	default:
+		return [][2]string{{prefix, strings.TrimSpace(fmt.Sprint(val))}}
+	}
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(r ExportRecord) error {
+	return j.enc.Encode(r)
+}
+
+func (j *jsonlWriter) Flush() error {
+	return nil
+}
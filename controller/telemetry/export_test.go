@@ -0,0 +1,130 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+type testReading struct {
+	Value float64  `json:"value"`
+	Time  TeleTime `json:"time"`
+}
+
+func TestExport(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for _, b := range []string{storage.TemperatureBucket, storage.TemperatureUsageBucket} {
+		if err := store.CreateBucket(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Update(storage.TemperatureBucket, "1", map[string]string{"id": "1", "name": "tank"}); err != nil {
+		t.Fatal(err)
+	}
+	t1 := time.Date(2024, 1, 10, 10, 0, 0, 0, time.Local)
+	t2 := time.Date(2024, 2, 10, 10, 0, 0, 0, time.Local)
+	payload := map[string][]testReading{
+		"current":    {{Value: 25.1, Time: TeleTime(t1)}, {Value: 25.4, Time: TeleTime(t2)}},
+		"historical": {},
+	}
+	if err := store.Update(storage.TemperatureUsageBucket, "1", payload); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	w, err := NewRecordWriter(ExportCSV, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := ExportQuery{
+		Module: "temperature",
+		From:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		To:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local),
+	}
+	if err := Export(store, q, w); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal("Expected header and one row, found:", lines)
+	}
+	if !strings.HasPrefix(lines[1], "temperature,1,tank,current,") || !strings.HasSuffix(lines[1], ",value,25.1") {
+		t.Error("Unexpected csv row:", lines[1])
+	}
+
+	buf.Reset()
+	w, err = NewRecordWriter(ExportJSONL, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.To = time.Time{}
+	if err := Export(store, q, w); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(buf)
+	count := 0
+	for dec.More() {
+		var r ExportRecord
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if count != 2 {
+		t.Error("Expected 2 records, found:", count)
+	}
+	if _, err := NewRecordWriter("xml", buf); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+	if err := Export(store, ExportQuery{Module: "foo"}, w); err == nil {
+		t.Error("Expected error for unsupported module")
+	}
+}
+
+func TestExportStatsInMemory(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for _, b := range []string{storage.TemperatureBucket, storage.TemperatureUsageBucket} {
+		if err := store.CreateBucket(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Update(storage.TemperatureBucket, "1", map[string]string{"id": "1", "name": "tank"}); err != nil {
+		t.Fatal(err)
+	}
+	tele := TestTelemetry(store)
+	m := tele.NewStatsManager(storage.TemperatureUsageBucket)
+	m.Update("1", HealthMetric{Time: TeleTime(time.Now()), Load5: 1})
+	q := ExportQuery{Module: "temperature"}
+	if err := ValidateExport(q, "xml"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+	if err := ValidateExport(ExportQuery{Module: "foo"}, ExportCSV); err == nil {
+		t.Error("Expected error for unsupported module")
+	}
+	if err := SaveExportStats(tele, q.Module); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	w, err := NewRecordWriter(ExportJSONL, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Export(store, q, w); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"cpu":1`) {
+		t.Error("Expected readings held in memory to be exported, found:", buf.String())
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/shirou/gopsutil/load"
@@ -26,6 +27,8 @@ type HealthChecker interface {
 	Start()
 	Stop()
 	GetStats(http.ResponseWriter, *http.Request)
+	Summary(http.ResponseWriter, *http.Request)
+	SetDriverProbe(DriverProbe)
 }
 
 type hc struct {
@@ -36,6 +39,13 @@ type hc struct {
 	store         storage.Store
 	statsMgr      StatsManager
 	isRaspberryPi bool
+	probe         DriverProbe
+	dbSizes       []sizeSample
+	mu            sync.Mutex
+	summary       HealthSummary
+	failing       map[string]bool
+	clock         HealthCheckResult
+	clockAt       time.Time
 }
 
 type HealthMetric struct {
@@ -86,6 +96,8 @@ func NewHealthChecker(b string, i time.Duration, notify settings.HealthCheckNoti
 		Notify:   notify,
 		statsMgr: t.NewStatsManager(b),
 		store:    store,
+		failing:  make(map[string]bool),
+		summary:  HealthSummary{Status: HealthUnknown, Checks: []HealthCheckResult{}},
 	}
 	stats, err := host.Info()
 	if err == nil && strings.HasPrefix(stats.KernelArch, "arm") {
@@ -94,18 +106,42 @@ func NewHealthChecker(b string, i time.Duration, notify settings.HealthCheckNoti
 	return h
 }
 
+func (h *hc) SetDriverProbe(p DriverProbe) {
+	h.probe = p
+}
+
 func (h *hc) Check() {
+	now := time.Now()
+	results := h.checkSystem()
+	results = append(results, h.runChecks(now)...)
+	h.notifyChecks(results)
+	summary := HealthSummary{
+		Status: HealthOK,
+		Time:   TeleTime(now),
+		Checks: results,
+	}
+	for _, r := range results {
+		if r.Status == HealthFailed {
+			summary.Status = HealthFailed
+		}
+	}
+	h.mu.Lock()
+	h.summary = summary
+	h.mu.Unlock()
+}
+
+func (h *hc) checkSystem() []HealthCheckResult {
 	loadStat, err := load.Avg()
 	if err != nil {
 		log.Println("ERROR: Failed to obtain load average. Error:", err)
-		return
+		return nil
 	}
 	h.t.EmitMetric("system", "load5", loadStat.Load5)
 
 	vmStat, err := mem.VirtualMemory()
 	if err != nil {
 		log.Println("ERROR: Failed to obtain memory stats. Error:", err)
-		return
+		return nil
 	}
 	usedMemory := (math.Floor(vmStat.UsedPercent * 100)) / 100.0
 	metric := HealthMetric{
@@ -135,6 +171,42 @@ func (h *hc) Check() {
 	log.Println("health check: Used memory:", usedMemory, " Load5:", loadStat.Load5)
 	h.statsMgr.Update(HealthStatsKey, metric)
 	h.NotifyIfNeeded(usedMemory, loadStat.Load5)
+	results := []HealthCheckResult{
+		{Name: "cpu", Status: HealthOK, Value: loadStat.Load5, Threshold: h.Notify.MaxCPU},
+		{Name: "memory", Status: HealthOK, Value: usedMemory, Threshold: h.Notify.MaxMemory},
+	}
+	if h.Notify.MaxCPU > 0 && loadStat.Load5 >= h.Notify.MaxCPU {
+		results[0].Status = HealthFailed
+	}
+	if h.Notify.MaxMemory > 0 && usedMemory >= h.Notify.MaxMemory {
+		results[1].Status = HealthFailed
+	}
+	if h.isRaspberryPi {
+		r := HealthCheckResult{Name: "under_voltage", Status: HealthOK, Value: metric.UnderVoltage}
+		if metric.UnderVoltage == 1 {
+			r.Status = HealthFailed
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// notifyChecks alerts when a check starts failing, notifications are not repeated until the check recovers
+func (h *hc) notifyChecks(results []HealthCheckResult) {
+	for _, r := range results {
+		key := r.Name + ":" + r.Target
+		failed := r.Status == HealthFailed
+		wasFailing := h.failing[key]
+		h.failing[key] = failed
+		if !failed || wasFailing {
+			continue
+		}
+		log.Println("WARNING: health check failed:", key, r.Message)
+		if r.Message == "" || !h.Notify.Enable || !h.limit(r.Name).Notify {
+			continue
+		}
+		h.t.Alert("Health check failed: "+r.Name, r.Message)
+	}
 }
 
 func (h *hc) NotifyIfNeeded(memory, load float64) {
@@ -196,6 +268,19 @@ func (h *hc) Start() {
 	}
 }
 
+func (h *hc) Summary(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	summary := h.summary
+	h.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if summary.Status == HealthFailed {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		log.Println("ERROR: Failed to encode health summary. Error:", err)
+	}
+}
+
 func (h *hc) GetStats(res http.ResponseWriter, req *http.Request) {
 	fn := func(id string) (interface{}, error) { return h.statsMgr.Get(HealthStatsKey) }
 	utils.JSONGetResponse(fn, res, req)
@@ -0,0 +1,314 @@
+package telemetry
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/disk"
+
+	"github.com/reef-pi/reef-pi/controller/settings"
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+const (
+	HealthOK      = "ok"
+	HealthFailed  = "failed"
+	HealthUnknown = "unknown"
+)
+
+const mb = 1024 * 1024
+
+// interval between clock synchronization checks, timedatectl is not run on every health check
+const clockCheckInterval = 15 * time.Minute
+
+// swagger:model healthCheckResult
+type HealthCheckResult struct {
+	Name      string  `json:"name"`
+	Target    string  `json:"target,omitempty"`
+	Status    string  `json:"status"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Message   string  `json:"message,omitempty"`
+}
+
+// swagger:model healthSummary
+type HealthSummary struct {
+	Status string              `json:"status"`
+	Time   TeleTime            `json:"time"`
+	Checks []HealthCheckResult `json:"checks"`
+}
+
+// DriverLiveness is the outcome of probing a registered hardware driver. Error is empty when the driver responded.
+// Status is HealthUnknown for drivers that can not be probed
+type DriverLiveness struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// DriverProbe probes all registered drivers, waiting at most the given duration for each of them
+type DriverProbe func(time.Duration) []DriverLiveness
+
+var (
+	mountsFile = "/proc/mounts"
+	// clockSynchronized reports whether the system clock is synchronized with a time server (NTP)
+	clockSynchronized = func() (bool, error) {
+		out, err := exec.Command("timedatectl", "show", "--property=NTPSynchronized", "--value").Output()
+		if err != nil {
+			return false, err
+		}
+		return strings.TrimSpace(string(out)) == "yes", nil
+	}
+)
+
+type sizeSample struct {
+	time time.Time
+	size float64
+}
+
+func (h *hc) runChecks(now time.Time) []HealthCheckResult {
+	var results []HealthCheckResult
+	dbPath := h.store.Path()
+	if h.Notify.Disk.Enable {
+		for _, dir := range h.directories(dbPath) {
+			results = append(results, diskCheck(dir, h.Notify.Disk.Threshold))
+		}
+	}
+	if h.Notify.DBSize.Enable || h.Notify.DBGrowth.Enable {
+		results = append(results, h.dbChecks(dbPath, now)...)
+	}
+	if h.Notify.ReadOnly.Enable {
+		results = append(results, readOnlyCheck(dbPath))
+	}
+	if h.Notify.ClockSync.Enable {
+		results = append(results, h.cachedClockCheck(now))
+	}
+	if h.Notify.Drivers.Enable && h.probe != nil {
+		timeout := time.Duration(h.Notify.Drivers.Threshold * float64(time.Second))
+		if timeout <= 0 {
+			timeout = 5 * time.Second
+		}
+		for _, l := range h.probe(timeout) {
+			r := HealthCheckResult{
+				Name:      "driver",
+				Target:    l.Name,
+				Status:    HealthOK,
+				Threshold: timeout.Seconds(),
+			}
+			switch {
+			case l.Error != "":
+				r.Status = HealthFailed
+				r.Message = "driver " + l.ID + " is not responding: " + l.Error
+			case l.Status == HealthUnknown:
+				r.Status = HealthUnknown
+				r.Message = "driver " + l.ID + " can not be probed"
+			}
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// directories returns the database directory and camera image directory, if camera is configured
+func (h *hc) directories(dbPath string) []string {
+	dirs := []string{filepath.Dir(dbPath)}
+	var camera struct {
+		ImageDirectory string `json:"image_directory"`
+	}
+	if err := h.store.Get(storage.CameraBucket, "config", &camera); err == nil && camera.ImageDirectory != "" {
+		if dir, err := filepath.Abs(camera.ImageDirectory); err == nil && dir != dirs[0] {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+func diskCheck(dir string, min float64) HealthCheckResult {
+	r := HealthCheckResult{
+		Name:      "disk",
+		Target:    dir,
+		Threshold: min,
+	}
+	usage, err := disk.Usage(dir)
+	if err != nil {
+		r.Status = HealthUnknown
+		r.Message = err.Error()
+		return r
+	}
+	r.Value = roundMB(usage.Free)
+	r.Status = HealthOK
+	if r.Value < min {
+		r.Status = HealthFailed
+		r.Message = fmt.Sprintf("free disk space %.2f MB is below threshold ( %.2f MB )", r.Value, min)
+	}
+	return r
+}
+
+func (h *hc) dbChecks(path string, now time.Time) []HealthCheckResult {
+	var results []HealthCheckResult
+	fi, err := os.Stat(path)
+	if err != nil {
+		return []HealthCheckResult{{Name: "db_size", Target: path, Status: HealthUnknown, Message: err.Error()}}
+	}
+	size := roundMB(uint64(fi.Size()))
+	if h.Notify.DBSize.Enable {
+		r := HealthCheckResult{
+			Name:      "db_size",
+			Target:    path,
+			Status:    HealthOK,
+			Value:     size,
+			Threshold: h.Notify.DBSize.Threshold,
+		}
+		if size > r.Threshold {
+			r.Status = HealthFailed
+			r.Message = fmt.Sprintf("database size %.2f MB is above threshold ( %.2f MB )", size, r.Threshold)
+		}
+		results = append(results, r)
+	}
+	if h.Notify.DBGrowth.Enable {
+		r := HealthCheckResult{
+			Name:      "db_growth",
+			Target:    path,
+			Status:    HealthUnknown,
+			Threshold: h.Notify.DBGrowth.Threshold,
+			Message:   "not enough samples",
+		}
+		if growth, ok := h.dbGrowth(size, now); ok {
+			r.Value = growth
+			r.Status = HealthOK
+			r.Message = ""
+			if growth > r.Threshold {
+				r.Status = HealthFailed
+				r.Message = fmt.Sprintf("database is growing %.2f MB per day, above threshold ( %.2f MB )", growth, r.Threshold)
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// dbGrowth keeps hourly database size samples for the last day and returns the growth extrapolated to MB per day.
+// It needs at least an hour worth of samples
+func (h *hc) dbGrowth(size float64, now time.Time) (float64, bool) {
+	if n := len(h.dbSizes); n == 0 || now.Sub(h.dbSizes[n-1].time) >= time.Hour {
+		h.dbSizes = append(h.dbSizes, sizeSample{time: now, size: size})
+	}
+	for len(h.dbSizes) > 2 && now.Sub(h.dbSizes[1].time) >= 24*time.Hour {
+		h.dbSizes = h.dbSizes[1:]
+	}
+	oldest := h.dbSizes[0]
+	elapsed := now.Sub(oldest.time)
+	if elapsed < time.Hour {
+		return 0, false
+	}
+	growth := (size - oldest.size) / elapsed.Hours() * 24
+	return float64(int(growth*100)) / 100, true
+}
+
+// readOnlyCheck detects the file system holding the database being remounted read only, typically due to SD card errors
+func readOnlyCheck(path string) HealthCheckResult {
+	r := HealthCheckResult{
+		Name:   "read_only",
+		Target: path,
+		Status: HealthUnknown,
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		r.Message = err.Error()
+		return r
+	}
+	f, err := os.Open(mountsFile)
+	if err != nil {
+		r.Message = err.Error()
+		return r
+	}
+	defer f.Close()
+	var mountPoint string
+	var options []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		if !strings.HasPrefix(abs, fields[1]) || len(fields[1]) < len(mountPoint) {
+			continue
+		}
+		if fields[1] != "/" && abs != fields[1] && !strings.HasPrefix(abs, fields[1]+"/") {
+			continue
+		}
+		mountPoint = fields[1]
+		options = strings.Split(fields[3], ",")
+	}
+	if mountPoint == "" {
+		r.Message = "mount point not found"
+		return r
+	}
+	r.Target = mountPoint
+	r.Status = HealthOK
+	for _, o := range options {
+		if o == "ro" {
+			r.Status = HealthFailed
+			r.Value = 1
+			r.Message = "file system " + mountPoint + " is mounted read only"
+		}
+	}
+	return r
+}
+
+// cachedClockCheck returns the last clock check while it is younger than clockCheckInterval
+func (h *hc) cachedClockCheck(now time.Time) HealthCheckResult {
+	if h.clockAt.IsZero() || now.Sub(h.clockAt) >= clockCheckInterval {
+		h.clock = clockCheck()
+		h.clockAt = now
+	}
+	return h.clock
+}
+
+func clockCheck() HealthCheckResult {
+	r := HealthCheckResult{
+		Name:   "clock_sync",
+		Status: HealthOK,
+	}
+	synced, err := clockSynchronized()
+	if err != nil {
+		r.Status = HealthUnknown
+		r.Message = err.Error()
+		return r
+	}
+	if !synced {
+		r.Status = HealthFailed
+		r.Value = 1
+		r.Message = "system clock is not synchronized"
+	}
+	return r
+}
+
+func (h *hc) limit(check string) settings.HealthCheckLimit {
+	switch check {
+	case "disk":
+		return h.Notify.Disk
+	case "db_size":
+		return h.Notify.DBSize
+	case "db_growth":
+		return h.Notify.DBGrowth
+	case "read_only":
+		return h.Notify.ReadOnly
+	case "clock_sync":
+		return h.Notify.ClockSync
+	case "driver":
+		return h.Notify.Drivers
+	}
+	// cpu and memory alerts are sent by NotifyIfNeeded
+	return settings.HealthCheckLimit{}
+}
+
+func roundMB(b uint64) float64 {
+	return float64(b*100/mb) / 100
+}
@@ -1,7 +1,13 @@
 package telemetry
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/reef-pi/reef-pi/controller/settings"
 	"github.com/reef-pi/reef-pi/controller/storage"
@@ -24,3 +30,101 @@ func TestHealthChecker(t *testing.T) {
 	go h.Start()
 	h.Stop()
 }
+
+func TestHealthChecks(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	mounts := filepath.Join(t.TempDir(), "mounts")
+	if err := os.WriteFile(mounts, []byte("/dev/root / ext4 ro,noatime 0 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mountsFile = mounts
+	defer func() { mountsFile = "/proc/mounts" }()
+	synced := clockSynchronized
+	clockChecks := 0
+	clockSynchronized = func() (bool, error) {
+		clockChecks++
+		return false, nil
+	}
+	defer func() { clockSynchronized = synced }()
+
+	c := settings.DefaultHealthCheck
+	c.DBSize.Threshold = 0
+	tele := TestTelemetry(store)
+	h := NewHealthChecker("reef-pi", time.Minute, c, tele, store)
+	h.SetDriverProbe(func(time.Duration) []DriverLiveness {
+		return []DriverLiveness{
+			{ID: "1", Name: "rpi", Status: HealthOK},
+			{ID: "2", Name: "foo", Status: HealthFailed, Error: "timeout"},
+			{ID: "3", Name: "bar", Status: HealthUnknown},
+		}
+	})
+	h.Check()
+
+	req := httptest.NewRequest("GET", "/api/health", nil)
+	w := httptest.NewRecorder()
+	h.Summary(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Error("Expected health summary to report failure. Code:", w.Code)
+	}
+	var summary HealthSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatal(err)
+	}
+	status := make(map[string]string)
+	for _, r := range summary.Checks {
+		status[r.Name+":"+r.Target] = r.Status
+	}
+	expected := map[string]string{
+		"read_only:/":               HealthFailed,
+		"clock_sync:":               HealthFailed,
+		"driver:rpi":                HealthOK,
+		"driver:foo":                HealthFailed,
+		"driver:bar":                HealthUnknown,
+		"db_growth:" + store.Path(): HealthUnknown,
+		"db_size:" + store.Path():   HealthFailed,
+	}
+	for k, v := range expected {
+		if status[k] != v {
+			t.Error("Unexpected status for", k, "expected:", v, "found:", status[k])
+		}
+	}
+	h.Check()
+	if clockChecks != 1 {
+		t.Error("Expected clock synchronization to be checked once per interval, found:", clockChecks)
+	}
+	if len(tele.aStats) != 0 {
+		t.Error("Expected no alerts while health notifications are disabled", tele.aStats)
+	}
+
+	c.Enable = true
+	h = NewHealthChecker("reef-pi", time.Minute, c, tele, store)
+	h.Check()
+	if _, ok := tele.aStats["[:Alert]Health check failed: read_only"]; !ok {
+		t.Error("Expected an alert for a failed check once health notifications are enabled", tele.aStats)
+	}
+}
+
+func TestDBGrowth(t *testing.T) {
+	h := &hc{}
+	now := time.Now()
+	if _, ok := h.dbGrowth(10, now); ok {
+		t.Error("Growth should not be computed from a single sample")
+	}
+	g, ok := h.dbGrowth(11, now.Add(2*time.Hour))
+	if !ok {
+		t.Fatal("Growth should be computed after an hour")
+	}
+	if g != 12 {
+		t.Error("Expected 12 MB per day growth, found:", g)
+	}
+	h.dbGrowth(20, now.Add(25*time.Hour))
+	h.dbGrowth(30, now.Add(50*time.Hour))
+	if h.dbSizes[0].time.Before(now.Add(2 * time.Hour)) {
+		t.Error("Samples older than a day should be dropped")
+	}
+}
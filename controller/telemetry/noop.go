@@ -15,12 +15,14 @@ func TestTelemetry(store storage.Store) *telemetry {
 		HistoricalLimit: HistoricalLimit,
 	}
 	return &telemetry{
-		config:     c,
-		dispatcher: &NoopMailer{},
-		aStats:     make(map[string]AlertStats),
-		mu:         &sync.Mutex{},
-		logError:   func(_, _ string) error { return nil },
-		store:      store,
-		bucket:     "telemetry",
+		config:      c,
+		dispatcher:  &NoopMailer{},
+		aStats:      make(map[string]AlertStats),
+		mu:          &sync.Mutex{},
+		logError:    func(_, _ string) error { return nil },
+		store:       store,
+		bucket:      "telemetry",
+		entityNames: make(map[string]string),
+		statsMgrs:   make(map[string][]*mgr),
 	}
 }
@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EntityMetric identifies a value emitted on behalf of a reef-pi entity (equipment, ato, probe etc).
+// Prometheus series are labeled by module and entity id, so renaming an entity does not create a new series.
+// Feed is the adafruit.io feed / mqtt topic name, it defaults to module-name-metric when empty.
+type EntityMetric struct {
+	Module string
+	ID     string
+	Name   string
+	Metric string
+	Feed   string
+}
+
+func (m EntityMetric) feed() string {
+	if m.Feed != "" {
+		return m.Feed
+	}
+	return m.Module + "-" + m.Name + "-" + m.Metric
+}
+
+var (
+	promOnce sync.Once
+
+	entityGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "reef_pi",
+		Name:      "entity_value",
+		Help:      "Current value of a metric emitted by a reef-pi entity",
+	}, []string{"module", "entity_id", "entity_name", "metric"})
+
+	controlActions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "reef_pi",
+		Name:      "control_actions_total",
+		Help:      "Number of control actions (equipment switching, dosing etc) executed",
+	}, []string{"module", "entity_id", "action"})
+
+	sensorReadDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "reef_pi",
+		Name:      "sensor_read_duration_seconds",
+		Help:      "Latency of sensor reads",
+		Buckets:   []float64{.001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"module", "entity_id"})
+
+	sensorReadFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "reef_pi",
+		Name:      "sensor_read_failures_total",
+		Help:      "Number of failed sensor reads",
+	}, []string{"module", "entity_id"})
+
+	driverErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "reef_pi",
+		Name:      "driver_errors_total",
+		Help:      "Number of errors reported by hardware drivers",
+	}, []string{"driver"})
+
+	// APILatency is observed by the http middleware of the reef-pi daemon
+	APILatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "reef_pi",
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of reef-pi API requests",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+)
+
+func registerPrometheus() {
+	promOnce.Do(func() {
+		for _, c := range []prometheus.Collector{entityGauge, controlActions, sensorReadDuration, sensorReadFailures, driverErrors, APILatency} {
+			if err := prometheus.Register(c); err != nil {
+				log.Println("ERROR: Failed to register prometheus collector. Error:", err)
+			}
+		}
+	})
+}
+
+func (t *telemetry) EmitEntityMetric(m EntityMetric, v float64) {
+	if t.config.Prometheus {
+		key := m.Module + "/" + m.ID
+		t.mu.Lock()
+		if name, ok := t.entityNames[key]; ok && name != m.Name {
+			entityGauge.DeletePartialMatch(prometheus.Labels{"module": m.Module, "entity_id": m.ID})
+		}
+		t.entityNames[key] = m.Name
+		t.mu.Unlock()
+		entityGauge.WithLabelValues(m.Module, m.ID, m.Name, m.Metric).Set(v)
+	}
+	t.emitFeed(m.feed(), v)
+}
+
+func (t *telemetry) DeleteEntityMetrics(module, id string) {
+	if !t.config.Prometheus {
+		return
+	}
+	t.mu.Lock()
+	delete(t.entityNames, module+"/"+id)
+	t.mu.Unlock()
+	labels := prometheus.Labels{"module": module, "entity_id": id}
+	entityGauge.DeletePartialMatch(labels)
+	controlActions.DeletePartialMatch(labels)
+	sensorReadDuration.DeletePartialMatch(labels)
+	sensorReadFailures.DeletePartialMatch(labels)
+}
+
+func (t *telemetry) ControlAction(module, id, action string) {
+	if !t.config.Prometheus {
+		return
+	}
+	controlActions.WithLabelValues(module, id, action).Inc()
+}
+
+func (t *telemetry) ObserveSensorRead(module, id string, start time.Time, err error) {
+	if !t.config.Prometheus {
+		return
+	}
+	sensorReadDuration.WithLabelValues(module, id).Observe(time.Since(start).Seconds())
+	if err != nil {
+		sensorReadFailures.WithLabelValues(module, id).Inc()
+	}
+}
+
+func (t *telemetry) DriverError(driver string) {
+	if !t.config.Prometheus {
+		return
+	}
+	driverErrors.WithLabelValues(driver).Inc()
+}
@@ -58,6 +58,11 @@ func (m *mgr) IsLoaded(id string) bool {
 func (m *mgr) Get(id string) (StatsResponse, error) {
 	m.Lock()
 	defer m.Unlock()
+	return m.get(id)
+}
+
+// get expects the caller to hold the lock
+func (m *mgr) get(id string) (StatsResponse, error) {
 	resp := StatsResponse{
 		Current:    []Metric{},
 		Historical: []Metric{},
@@ -122,6 +127,21 @@ func (m *mgr) Save(id string) error {
 	return m.store.Update(m.bucket, id, stats)
 }
 
+func (m *mgr) saveAll() error {
+	m.Lock()
+	defer m.Unlock()
+	for id := range m.inMemory {
+		stats, err := m.get(id)
+		if err != nil {
+			return err
+		}
+		if err := m.store.Update(m.bucket, id, stats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *mgr) Update(id string, metric Metric) {
 	m.Lock()
 	defer m.Unlock()
@@ -150,7 +170,9 @@ func (m *mgr) Update(id string, metric Metric) {
 	}
 	m.inMemory[id] = stats
 	if move {
-		m.Save(id)
+		if resp, err := m.get(id); err == nil {
+			m.store.Update(m.bucket, id, resp)
+		}
 	}
 }
 
@@ -0,0 +1,45 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reef-pi/reef-pi/controller/storage"
+)
+
+func TestStatsManagerHourlyRollup(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.CreateBucket("stats-rollup"); err != nil {
+		t.Fatal(err)
+	}
+	m := TestTelemetry(store).NewStatsManager("stats-rollup")
+	start := time.Now().Truncate(time.Hour)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Update("1", HealthMetric{Time: TeleTime(start), Load5: 1})
+		m.Update("1", HealthMetric{Time: TeleTime(start.Add(time.Minute)), Load5: 2})
+		// a metric from the next hour moves the rollup to history and saves it
+		m.Update("1", HealthMetric{Time: TeleTime(start.Add(time.Hour)), Load5: 3})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected saving an hourly rollup not to deadlock")
+	}
+	resp, err := m.Get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Historical) != 2 {
+		t.Error("expected the finished hour to be moved to history", resp.Historical)
+	}
+	var saved StatsOnDisk
+	if err := store.Get("stats-rollup", "1", &saved); err != nil || len(saved.Historical) != 2 {
+		t.Error("expected the rollup to be saved", saved, err)
+	}
+}
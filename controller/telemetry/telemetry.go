@@ -10,8 +10,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/reef-pi/adafruitio"
 
 	"github.com/reef-pi/reef-pi/controller/storage"
@@ -27,9 +25,15 @@ type Telemetry interface {
 	Alert(string, string) (bool, error)
 	Mail(string, string) (bool, error)
 	EmitMetric(string, string, float64)
+	EmitEntityMetric(EntityMetric, float64)
+	DeleteEntityMetrics(string, string)
+	ControlAction(string, string, string)
+	ObserveSensorRead(string, string, time.Time, error)
+	DriverError(string)
 	CreateFeedIfNotExist(string)
 	DeleteFeedIfExist(string)
 	NewStatsManager(string) StatsManager
+	SaveStats(string) error
 	SendTestMessage(http.ResponseWriter, *http.Request)
 	GetConfig(http.ResponseWriter, *http.Request)
 	UpdateConfig(http.ResponseWriter, *http.Request)
@@ -69,17 +73,19 @@ var DefaultTelemetryConfig = TelemetryConfig{
 }
 
 type telemetry struct {
-	name       string
-	aClient    *adafruitio.Client
-	mClient    *MQTTClient
-	dispatcher Mailer
-	config     TelemetryConfig
-	aStats     map[string]AlertStats
-	mu         *sync.Mutex
-	logError   ErrorLogger
-	store      storage.Store
-	bucket     string
-	pMs        map[string]prometheus.Gauge
+	name        string
+	aClient     *adafruitio.Client
+	mClient     *MQTTClient
+	dispatcher  Mailer
+	config      TelemetryConfig
+	aStats      map[string]AlertStats
+	mu          *sync.Mutex
+	logError    ErrorLogger
+	store       storage.Store
+	bucket      string
+	entityNames map[string]string
+	// statsMgrs holds the stats managers of each usage bucket
+	statsMgrs map[string][]*mgr
 }
 
 func Initialize(name, bucket string, store storage.Store, logError ErrorLogger, prom bool) Telemetry {
@@ -107,15 +113,19 @@ func NewTelemetry(name, bucket string, store storage.Store, config TelemetryConf
 		mailer = config.Mailer.Mailer()
 	}
 	t := &telemetry{
-		name:       name,
-		config:     config,
-		dispatcher: mailer,
-		aStats:     make(map[string]AlertStats),
-		mu:         &sync.Mutex{},
-		logError:   lr,
-		store:      store,
-		bucket:     bucket,
-		pMs:        make(map[string]prometheus.Gauge),
+		name:        name,
+		config:      config,
+		dispatcher:  mailer,
+		aStats:      make(map[string]AlertStats),
+		mu:          &sync.Mutex{},
+		logError:    lr,
+		store:       store,
+		bucket:      bucket,
+		entityNames: make(map[string]string),
+		statsMgrs:   make(map[string][]*mgr),
+	}
+	if config.Prometheus {
+		registerPrometheus()
 	}
 	if config.AdafruitIO.Enable {
 		t.aClient = adafruitio.NewClient(config.AdafruitIO.Token)
@@ -132,13 +142,31 @@ func NewTelemetry(name, bucket string, store storage.Store, config TelemetryConf
 }
 
 func (t *telemetry) NewStatsManager(b string) StatsManager {
-	return &mgr{
+	m := &mgr{
 		inMemory:        make(map[string]Stats),
 		bucket:          b,
 		store:           t.store,
 		HistoricalLimit: t.config.HistoricalLimit,
 		CurrentLimit:    t.config.CurrentLimit,
 	}
+	t.mu.Lock()
+	t.statsMgrs[b] = append(t.statsMgrs[b], m)
+	t.mu.Unlock()
+	return m
+}
+
+// SaveStats saves the readings held in memory by the stats managers of a usage bucket, which
+// are otherwise saved on the hourly rollup or when their module stops
+func (t *telemetry) SaveStats(b string) error {
+	t.mu.Lock()
+	ms := t.statsMgrs[b]
+	t.mu.Unlock()
+	for _, m := range ms {
+		if err := m.saveAll(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (t *telemetry) updateAlertStats(subject string) AlertStats {
@@ -199,31 +227,23 @@ func SanitizePrometheusMetricName(name string) string {
 }
 
 func (t *telemetry) EmitMetric(module, name string, v float64) {
-	aio := t.config.AdafruitIO
-	pName := SanitizePrometheusMetricName(module + "_" + name)
-
 	if t.config.Prometheus {
-		t.mu.Lock()
-		g, ok := t.pMs[pName]
-		if !ok {
-			g = promauto.NewGauge(prometheus.GaugeOpts{
-				Name: pName,
-				Help: "Module:" + module + " Item:" + name,
-			})
-			t.pMs[pName] = g
-		}
-		t.mu.Unlock()
-		g.Set(v)
+		entityGauge.WithLabelValues(module, "", "", name).Set(v)
 	}
+	t.emitFeed(module+"-"+name, v)
+}
+
+func (t *telemetry) emitFeed(feed string, v float64) {
+	aio := t.config.AdafruitIO
 	if aio.Enable {
-		aFeed := SanitizeAdafruitIOFeedName(aio.Prefix + module + "-" + name)
+		aFeed := SanitizeAdafruitIOFeedName(aio.Prefix + feed)
 		if err := t.EmitAIO(aio.User, aFeed, v); err != nil {
 			log.Println("ERROR: Failed to submit data to adafruit.io. User: ", aio.User, "Feed:", aFeed, "Error:", err)
 			t.logError("telemtry-"+aFeed, err.Error())
 		}
 	}
 	if t.config.MQTT.Enable {
-		if err := t.EmitMQTT(pName, v); err != nil {
+		if err := t.EmitMQTT(SanitizePrometheusMetricName(feed), v); err != nil {
 			log.Println("ERROR: Failed to publish data via mqtt. Error:", err)
 			t.logError("telemtry-mqtt", err.Error())
 		}
@@ -1,7 +1,11 @@
 package telemetry
 
 import (
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	"github.com/reef-pi/reef-pi/controller/storage"
 )
@@ -72,3 +76,38 @@ func TestSanitizePrometheusMetricName(t *testing.T) {
 		}
 	}
 }
+
+func TestEmitEntityMetric(t *testing.T) {
+	store, err := storage.TestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	tele := TestTelemetry(store)
+	tele.config.Prometheus = true
+	registerPrometheus()
+
+	m := EntityMetric{Module: "test-eq", ID: "1", Name: "foo", Metric: "state"}
+	tele.EmitEntityMetric(m, 1)
+	if v := testutil.ToFloat64(entityGauge.WithLabelValues("test-eq", "1", "foo", "state")); v != 1 {
+		t.Error("Expected 1, found:", v)
+	}
+	m.Name = "bar"
+	tele.EmitEntityMetric(m, 0)
+	if c := testutil.CollectAndCount(entityGauge); c != 1 {
+		t.Error("Expected renamed entity to replace old series. Found series:", c)
+	}
+	tele.ControlAction("test-eq", "1", "on")
+	tele.ObserveSensorRead("test-eq", "1", time.Now(), errors.New("test"))
+	if v := testutil.ToFloat64(sensorReadFailures.WithLabelValues("test-eq", "1")); v != 1 {
+		t.Error("Expected 1 read failure, found:", v)
+	}
+	tele.DeleteEntityMetrics("test-eq", "1")
+	if c := testutil.CollectAndCount(entityGauge); c != 0 {
+		t.Error("Expected series to be removed upon deletion. Found series:", c)
+	}
+	if c := testutil.CollectAndCount(controlActions); c != 0 {
+		t.Error("Expected control action series to be removed upon deletion. Found series:", c)
+	}
+}